@@ -3,65 +3,144 @@ package cli
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bryack/study_hours_tracker/domain"
 )
 
 const (
-	GreetingString  = "Let's study\nType {subject} {hours} to track hours\nOr type 'pomodoro' {subject} to use pomodoro tracker\nType 'quit' to exit"
-	PomodoroCommand = "pomodoro"
-	QuitCommand     = "quit"
+	GreetingString       = "Let's study\nType {subject} {duration} to track time, e.g. 'math 2' or 'math 1h30m'\nOr type 'pomodoro' {subject} [focus] to use pomodoro tracker, e.g. 'pomodoro math 50m'\nType 'pomodoro-cycle' {subject} [focus] for 4 Pomodoros with short and long breaks\nWhile it runs, type 'pause', 'resume' or 'cancel' to control it\nType 'report' [today|week|month] to see what you studied\nType 'undo' to remove the last entry\nType 'rename' {subject} {new name} or 'merge' {subject} {subjects...} to tidy up subjects\nType 'goals' to see your goals, 'goals set' {subject} {target} [daily|weekly|monthly] or 'goals remove' {subject} [period] to change them\nType 'stats' [weeks] to see your streaks and how consistently you study\nType 'export' [csv|jsonl] [file] to write every session to a file, or here without one\nType 'import' {csv|jsonl|toggl|clockify} {file} [column=NAME...] to import sessions, e.g. 'import csv hours.csv subject=Course start=Date duration=Hours'\nType 'quit' to exit"
+	PomodoroCommand      = "pomodoro"
+	PomodoroCycleCommand = "pomodoro-cycle"
+	PauseCommand         = "pause"
+	ResumeCommand        = "resume"
+	CancelCommand        = "cancel"
+	ReportCommand        = "report"
+	UndoCommand          = "undo"
+	RenameCommand        = "rename"
+	MergeCommand         = "merge"
+	GoalsCommand         = "goals"
+	StatsCommand         = "stats"
+	ExportCommand        = "export"
+	ImportCommand        = "import"
+	QuitCommand          = "quit"
 )
 
 var (
 	ErrNotEnoughArgs = errors.New("should be 2 arguments")
-	ErrInvalidHours  = errors.New("failed to parse hours")
+	ErrInvalidHours  = errors.New("failed to parse duration")
 )
 
+// Session is what the interactive CLI does with a study session.
+type Session interface {
+	domain.SessionRecorder
+	domain.HistoryEditor
+	domain.GoalTracker
+	domain.StatsReporter
+	domain.SessionPorter
+}
+
 // CLI provides an interactive command-line interface for tracking study hours.
 type CLI struct {
 	in      *bufio.Scanner
 	out     io.Writer
-	session domain.SessionRunner
+	session Session
+
+	mu       sync.Mutex
+	pomodoro *domain.PomodoroControl // the running Pomodoro, if any
+	running  sync.WaitGroup
 }
 
 // NewCLI creates a new CLI with the given dependencies.
-func NewCLI(in io.Reader, out io.Writer, session domain.SessionRunner) *CLI {
+func NewCLI(in io.Reader, out io.Writer, session Session) *CLI {
 	return &CLI{
 		in:      bufio.NewScanner(in),
-		out:     out,
+		out:     &syncWriter{w: out},
 		session: session,
 	}
 }
 
-// Run starts the interactive CLI loop.
-func (cli *CLI) Run() error {
+// syncWriter lets a running Pomodoro print alerts while the prompt loop writes.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (sw *syncWriter) Write(p []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Write(p)
+}
+
+// Run starts the interactive CLI loop. A Pomodoro runs in the background so
+// that it can be paused, resumed or cancelled; Run waits for it before
+// returning. Cancelling ctx aborts a running Pomodoro.
+func (cli *CLI) Run(ctx context.Context) error {
 	fmt.Fprintln(cli.out, GreetingString)
+	defer cli.running.Wait()
 
 	for cli.in.Scan() {
 		input := cli.in.Text()
-		if input == QuitCommand {
+		switch input {
+		case QuitCommand:
+			cli.controlPomodoro(CancelCommand, true)
+			cli.running.Wait()
 			fmt.Fprintln(cli.out, "Goodbye!")
-			break
+			return nil
+		case PauseCommand, ResumeCommand, CancelCommand:
+			cli.controlPomodoro(input, false)
+			continue
+		case UndoCommand:
+			cli.undo(ctx)
+			continue
 		}
-		s, h, isPomodoro, err := extractSubjectAndHours(cli.in.Text())
+		if args := strings.Fields(input); len(args) > 0 {
+			switch args[0] {
+			case ReportCommand:
+				cli.printReport(ctx, args[1:])
+				continue
+			case RenameCommand:
+				cli.renameSubject(ctx, args[1:])
+				continue
+			case MergeCommand:
+				cli.mergeSubjects(ctx, args[1:])
+				continue
+			case GoalsCommand:
+				cli.goals(ctx, args[1:])
+				continue
+			case StatsCommand:
+				cli.printStats(ctx, args[1:])
+				continue
+			case ExportCommand:
+				cli.export(ctx, args[1:])
+				continue
+			case ImportCommand:
+				cli.importSessions(ctx, args[1:])
+				continue
+			}
+		}
+		s, h, command, err := extractSubjectAndHours(cli.in.Text())
 		if err != nil {
 			fmt.Fprintf(cli.out, "failed to extract subject and hours: %v\n", err)
 			continue
 		}
 
-		if isPomodoro {
-			fmt.Fprintln(cli.out, "Pomodoro started...")
-			if err := cli.session.RecordPomodoro(s, cli.out); err != nil {
-				fmt.Fprintf(cli.out, "failed to record pomodoro: %v\n", err)
-			}
-		} else {
-			if err := cli.session.RecordManual(s, h); err != nil {
+		switch command {
+		case PomodoroCommand:
+			cli.startPomodoro(ctx, s, domain.PomodoroConfig{Focus: h}, cli.recordPomodoro)
+		case PomodoroCycleCommand:
+			cli.startPomodoro(ctx, s, domain.PomodoroConfig{Focus: h}, cli.session.RecordPomodoroCycle)
+		default:
+			if _, err := cli.session.RecordManual(ctx, s, h); err != nil {
 				fmt.Fprintf(cli.out, "failed to record hours: %v\n", err)
 			}
 		}
@@ -74,22 +153,382 @@ func (cli *CLI) Run() error {
 	return nil
 }
 
-func extractSubjectAndHours(userInput string) (subject string, hours int, isPomodoro bool, err error) {
-	args := strings.Split(userInput, " ")
+// recordFunc runs and records a single Pomodoro or a cycle of them.
+type recordFunc func(ctx context.Context, subject string, config domain.PomodoroConfig, out io.Writer, control *domain.PomodoroControl) error
+
+// recordPomodoro runs and records a single Pomodoro.
+func (cli *CLI) recordPomodoro(ctx context.Context, subject string, config domain.PomodoroConfig, out io.Writer, control *domain.PomodoroControl) error {
+	_, err := cli.session.RecordPomodoro(ctx, subject, config, out, control)
+	return err
+}
+
+// startPomodoro runs record for subject in the background unless a Pomodoro is already running.
+func (cli *CLI) startPomodoro(ctx context.Context, subject string, config domain.PomodoroConfig, record recordFunc) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+	if cli.pomodoro != nil {
+		fmt.Fprintln(cli.out, "failed to record pomodoro: a pomodoro is already running")
+		return
+	}
+	control := domain.NewPomodoroControl()
+	cli.pomodoro = control
+
+	fmt.Fprintln(cli.out, "Pomodoro started...")
+	cli.running.Go(func() {
+		err := record(ctx, subject, config, cli.out, control)
+		switch {
+		case errors.Is(err, domain.ErrPomodoroCancelled):
+			fmt.Fprintf(cli.out, "Pomodoro for %q cancelled\n", subject)
+		case err != nil:
+			fmt.Fprintf(cli.out, "failed to record pomodoro: %v\n", err)
+		}
+
+		cli.mu.Lock()
+		defer cli.mu.Unlock()
+		cli.pomodoro = nil
+	})
+}
+
+// controlPomodoro applies a pause, resume or cancel command to the running
+// Pomodoro. With quiet set, having nothing to control is not reported.
+func (cli *CLI) controlPomodoro(command string, quiet bool) {
+	cli.mu.Lock()
+	control := cli.pomodoro
+	cli.mu.Unlock()
+	if control == nil {
+		if !quiet {
+			fmt.Fprintln(cli.out, "no pomodoro is running")
+		}
+		return
+	}
+
+	var err error
+	switch command {
+	case PauseCommand:
+		if err = control.Pause(); err == nil {
+			fmt.Fprintln(cli.out, "Pomodoro paused")
+		}
+	case ResumeCommand:
+		if err = control.Resume(); err == nil {
+			fmt.Fprintln(cli.out, "Pomodoro resumed")
+		}
+	case CancelCommand:
+		err = control.Cancel()
+	}
+	if err != nil && !quiet {
+		fmt.Fprintf(cli.out, "failed to %s pomodoro: %v\n", command, err)
+	}
+}
+
+// undo removes the entry recorded last, which fixes a typo like 'math 20'.
+func (cli *CLI) undo(ctx context.Context) {
+	session, err := cli.session.UndoLastSession(ctx)
+	if errors.Is(err, domain.ErrSessionNotFound) {
+		fmt.Fprintln(cli.out, "Nothing to undo")
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(cli.out, "failed to undo: %v\n", err)
+		return
+	}
+	fmt.Fprintf(cli.out, "Removed %s of %q\n", domain.FormatDuration(session.Duration), session.Subject)
+}
+
+// renameSubject handles 'rename {subject} {new name}'.
+func (cli *CLI) renameSubject(ctx context.Context, args []string) {
+	if len(args) != 2 {
+		fmt.Fprintln(cli.out, "failed to rename: usage is 'rename {subject} {new name}'")
+		return
+	}
+	moved, err := cli.session.RenameSubject(ctx, args[0], args[1])
+	if err != nil {
+		fmt.Fprintf(cli.out, "failed to rename: %v\n", err)
+		return
+	}
+	fmt.Fprintf(cli.out, "Renamed %q to %q (%d sessions)\n", args[0], args[1], moved)
+}
+
+// mergeSubjects handles 'merge {subject} {subjects...}', which moves the
+// sessions of the other subjects into the first.
+func (cli *CLI) mergeSubjects(ctx context.Context, args []string) {
+	if len(args) < 2 {
+		fmt.Fprintln(cli.out, "failed to merge: usage is 'merge {subject} {subjects...}'")
+		return
+	}
+	moved, err := cli.session.MergeSubjects(ctx, args[0], args[1:])
+	if err != nil {
+		fmt.Fprintf(cli.out, "failed to merge: %v\n", err)
+		return
+	}
+	fmt.Fprintf(cli.out, "Merged %d sessions of %s into %q\n", moved, strings.Join(args[1:], ", "), args[0])
+}
+
+const goalsUsage = "usage is 'goals', 'goals set {subject} {target} [daily|weekly|monthly]' or 'goals remove {subject} [daily|weekly|monthly]'"
+
+// goals lists the goals with their progress, or sets or removes one. Goals
+// are weekly unless a period is given.
+func (cli *CLI) goals(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		cli.printGoals(ctx)
+		return
+	}
+
+	switch {
+	case args[0] == "set" && (len(args) == 3 || len(args) == 4):
+		period, err := goalPeriodArg(args[3:])
+		if err != nil {
+			fmt.Fprintf(cli.out, "failed to set goal: %v\n", err)
+			return
+		}
+		target, err := domain.ParseDuration(args[2])
+		if err == nil {
+			err = cli.session.SetGoal(ctx, domain.Goal{Subject: args[1], Period: period, Target: target})
+		}
+		if err != nil {
+			fmt.Fprintf(cli.out, "failed to set goal: %v\n", err)
+			return
+		}
+		fmt.Fprintf(cli.out, "Goal set: %s of %q %s\n", domain.FormatDuration(target), args[1], period)
+	case args[0] == "remove" && (len(args) == 2 || len(args) == 3):
+		period, err := goalPeriodArg(args[2:])
+		if err == nil {
+			err = cli.session.DeleteGoal(ctx, args[1], period)
+		}
+		if err != nil {
+			fmt.Fprintf(cli.out, "failed to remove goal: %v\n", err)
+			return
+		}
+		fmt.Fprintf(cli.out, "Goal removed: %q %s\n", args[1], period)
+	default:
+		fmt.Fprintf(cli.out, "failed to change goals: %s\n", goalsUsage)
+	}
+}
+
+// goalPeriodArg parses the optional period argument of the goals command.
+func goalPeriodArg(args []string) (domain.GoalPeriod, error) {
+	if len(args) == 0 {
+		return domain.GoalWeekly, nil
+	}
+	return domain.ParseGoalPeriod(args[0])
+}
+
+func (cli *CLI) printGoals(ctx context.Context) {
+	progress, err := cli.session.Goals(ctx)
+	if err != nil {
+		fmt.Fprintf(cli.out, "failed to get goals: %v\n", err)
+		return
+	}
+	if len(progress) == 0 {
+		fmt.Fprintln(cli.out, "No goals set yet, e.g. 'goals set go 10h weekly'")
+		return
+	}
+	for _, p := range progress {
+		status := fmt.Sprintf("%d%%, %s to go", p.Percent(), domain.FormatDuration(p.Remaining()))
+		if p.Met() {
+			status = fmt.Sprintf("%d%%, done", p.Percent())
+		}
+		fmt.Fprintf(cli.out, "%s (%s): %s of %s, %s\n", p.Subject, p.Period, domain.FormatDuration(p.Done), domain.FormatDuration(p.Target), status)
+	}
+}
+
+func (cli *CLI) printReport(ctx context.Context, args []string) {
+	name := domain.PeriodAll
+	if len(args) > 0 {
+		name = args[0]
+	}
+	period, err := domain.ParsePeriod(name, cli.session.Now())
+	if err != nil {
+		fmt.Fprintf(cli.out, "failed to build report: %v\n", err)
+		return
+	}
+
+	report, err := cli.session.Report(ctx, period)
+	if err != nil {
+		fmt.Fprintf(cli.out, "failed to get report: %v\n", err)
+		return
+	}
+	if len(report) == 0 {
+		fmt.Fprintln(cli.out, "Nothing studied in this period")
+		return
+	}
+	for _, activity := range report {
+		fmt.Fprintf(cli.out, "%s: %s\n", activity.Subject, domain.FormatDuration(activity.Duration))
+	}
+}
+
+// printStats prints the streaks, the active days per week over the given
+// number of weeks and the session lengths per subject.
+func (cli *CLI) printStats(ctx context.Context, args []string) {
+	weeks := domain.DefaultStatsWeeks
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Fprintf(cli.out, "failed to get stats: weeks should be a number, got %q\n", args[0])
+			return
+		}
+		weeks = n
+	}
+
+	stats, err := cli.session.Stats(ctx, weeks)
+	if err != nil {
+		fmt.Fprintf(cli.out, "failed to get stats: %v\n", err)
+		return
+	}
+	if len(stats.Subjects) == 0 {
+		fmt.Fprintln(cli.out, "Nothing studied yet")
+		return
+	}
+	fmt.Fprintf(cli.out, "Current streak: %s, longest: %s\n", countOf(stats.CurrentStreak, "day"), countOf(stats.LongestStreak, "day"))
+	fmt.Fprintf(cli.out, "Active days per week: %.1f over the last %s\n", stats.ActiveDaysPerWeek, countOf(weeks, "week"))
+	for _, subject := range stats.Subjects {
+		fmt.Fprintf(cli.out, "%s: %s, %s in total, %s on average\n", subject.Subject, countOf(subject.Sessions, "session"),
+			domain.FormatDuration(subject.Total), domain.FormatDuration(subject.Average()))
+	}
+}
+
+// export writes every session in the given format, csv by default, to the
+// named file or to the output when there is none.
+func (cli *CLI) export(ctx context.Context, args []string) {
+	if len(args) > 2 {
+		fmt.Fprintln(cli.out, "failed to export: usage is 'export [csv|jsonl] [file]'")
+		return
+	}
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	}
+	format, err := domain.ParseExportFormat(name)
+	if err != nil {
+		fmt.Fprintf(cli.out, "failed to export: %v\n", err)
+		return
+	}
 	if len(args) < 2 {
-		return "", 0, false, fmt.Errorf("failed to parse: %w, got: %d", ErrNotEnoughArgs, len(args))
+		if _, err := cli.session.Export(ctx, cli.out, format, domain.AllTime()); err != nil {
+			fmt.Fprintf(cli.out, "failed to export: %v\n", err)
+		}
+		return
 	}
 
-	if args[0] == PomodoroCommand {
-		return args[1], 1, true, nil
+	path := args[1]
+	exported, err := exportToFile(ctx, cli.session, path, format)
+	if err != nil {
+		fmt.Fprintf(cli.out, "failed to export: %v\n", err)
+		return
 	}
+	fmt.Fprintf(cli.out, "Exported %s to %s\n", countOf(exported, "session"), path)
+}
 
-	h, err := strconv.Atoi(args[1])
+const importUsage = "usage is 'import {csv|jsonl|toggl|clockify} {file} [column=NAME...]'"
+
+// importSessions sums up what importing a file would do and imports it once
+// the user confirms.
+func (cli *CLI) importSessions(ctx context.Context, args []string) {
+	if len(args) < 2 {
+		fmt.Fprintf(cli.out, "failed to import: %s\n", importUsage)
+		return
+	}
+	format, err := domain.ParseImportFormat(args[0])
 	if err != nil {
-		return "", 0, false, fmt.Errorf("%w %v: %v", ErrInvalidHours, args[1], err)
+		fmt.Fprintf(cli.out, "failed to import: %v\n", err)
+		return
+	}
+	options := domain.ImportOptions{Format: format, Location: time.Local, DryRun: true}
+	for _, arg := range args[2:] {
+		key, column, ok := strings.Cut(arg, "=")
+		if !ok {
+			fmt.Fprintf(cli.out, "failed to import: %s\n", importUsage)
+			return
+		}
+		if err := options.Columns.Set(key, column); err != nil {
+			fmt.Fprintf(cli.out, "failed to import: %v\n", err)
+			return
+		}
 	}
-	if h <= 0 {
-		return "", 0, false, fmt.Errorf("%w %d, should be 1 or more: %v", ErrInvalidHours, h, err)
+	data, err := os.ReadFile(args[1])
+	if err != nil {
+		fmt.Fprintf(cli.out, "failed to import: %v\n", err)
+		return
+	}
+
+	summary, err := cli.session.Import(ctx, bytes.NewReader(data), options)
+	if err != nil {
+		fmt.Fprintf(cli.out, "failed to import: %v\n", err)
+		return
+	}
+	printImportSummary(cli.out, summary)
+	if summary.Imported == 0 {
+		fmt.Fprintln(cli.out, "Nothing new to import")
+		return
+	}
+
+	fmt.Fprintf(cli.out, "Import %s? Type 'yes' to confirm\n", countOf(summary.Imported, "session"))
+	if !cli.in.Scan() || strings.TrimSpace(cli.in.Text()) != "yes" {
+		fmt.Fprintln(cli.out, "Import cancelled")
+		return
+	}
+	options.DryRun = false
+	summary, err = cli.session.Import(ctx, bytes.NewReader(data), options)
+	if err != nil {
+		fmt.Fprintf(cli.out, "failed to import: %v\n", err)
+		return
+	}
+	fmt.Fprintf(cli.out, "Imported %s\n", countOf(summary.Imported, "session"))
+}
+
+// printImportSummary prints what an import does or would do.
+func printImportSummary(out io.Writer, summary domain.ImportSummary) {
+	fmt.Fprintf(out, "Read %s: %d new, %d already recorded\n", countOf(summary.Read, "session"), summary.Imported, summary.Duplicates)
+	if summary.Imported == 0 {
+		return
+	}
+	fmt.Fprintf(out, "New sessions from %s to %s:\n", summary.From.Format(time.DateOnly), summary.To.Format(time.DateOnly))
+	for _, activity := range summary.Subjects {
+		fmt.Fprintf(out, "  %s: %s\n", activity.Subject, domain.FormatDuration(activity.Duration))
+	}
+}
+
+func exportToFile(ctx context.Context, session domain.SessionPorter, path string, format domain.ExportFormat) (int, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	exported, err := session.Export(ctx, f, format, domain.AllTime())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return exported, err
+}
+
+// countOf returns n followed by noun, made plural unless n is 1.
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// extractSubjectAndHours parses a manual record, or a Pomodoro command with an
+// optional focus length, in which case command is that Pomodoro command.
+func extractSubjectAndHours(userInput string) (subject string, duration time.Duration, command string, err error) {
+	args := strings.Split(userInput, " ")
+	if len(args) < 2 {
+		return "", 0, "", fmt.Errorf("failed to parse: %w, got: %d", ErrNotEnoughArgs, len(args))
+	}
+
+	if args[0] == PomodoroCommand || args[0] == PomodoroCycleCommand {
+		if len(args) < 3 {
+			return args[1], 0, args[0], nil
+		}
+		focus, err := domain.ParseFocus(args[2])
+		if err != nil {
+			return "", 0, "", err
+		}
+		return args[1], focus, args[0], nil
+	}
+
+	d, err := domain.ParseDuration(args[1])
+	if err != nil {
+		return "", 0, "", fmt.Errorf("%w %v: %w", ErrInvalidHours, args[1], err)
 	}
-	return args[0], h, false, nil
+	return args[0], d, "", nil
 }
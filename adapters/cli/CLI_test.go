@@ -2,10 +2,16 @@ package cli_test
 
 import (
 	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bryack/study_hours_tracker/adapters/cli"
+	"github.com/bryack/study_hours_tracker/domain"
 	"github.com/bryack/study_hours_tracker/testhelpers"
 	"github.com/stretchr/testify/assert"
 )
@@ -15,63 +21,70 @@ func TestCLI(t *testing.T) {
 		name                  string
 		input                 string
 		expectedOut           string
-		expectedManualCalls   map[string]int
+		expectedManualCalls   map[string]time.Duration
 		expectedPomodoroCalls []string
 	}{
 		{
 			name:                  "record 'cli' hours",
 			input:                 "cli 3",
 			expectedOut:           cli.GreetingString,
-			expectedManualCalls:   map[string]int{"cli": 3},
+			expectedManualCalls:   map[string]time.Duration{"cli": 3 * time.Hour},
+			expectedPomodoroCalls: []string{},
+		},
+		{
+			name:                  "record 'cli' duration with minutes",
+			input:                 "cli 1h30m\nbash 45m",
+			expectedOut:           cli.GreetingString,
+			expectedManualCalls:   map[string]time.Duration{"cli": 90 * time.Minute, "bash": 45 * time.Minute},
 			expectedPomodoroCalls: []string{},
 		},
 		{
 			name:                  "handle parsing errors",
 			input:                 "bufio five",
 			expectedOut:           cli.GreetingString + "\nfailed to extract subject and hours",
-			expectedManualCalls:   map[string]int{},
+			expectedManualCalls:   map[string]time.Duration{},
 			expectedPomodoroCalls: []string{},
 		},
 		{
 			name:                  "not enough arguments",
 			input:                 "bufio",
 			expectedOut:           cli.GreetingString + "\nfailed to extract subject and hours",
-			expectedManualCalls:   map[string]int{},
+			expectedManualCalls:   map[string]time.Duration{},
 			expectedPomodoroCalls: []string{},
 		},
 		{
 			name:                  "negative number of hours",
 			input:                 "bufio -2",
 			expectedOut:           cli.GreetingString + "\nfailed to extract subject and hours",
-			expectedManualCalls:   map[string]int{},
+			expectedManualCalls:   map[string]time.Duration{},
 			expectedPomodoroCalls: []string{},
 		},
 		{
 			name:                  "record multiple sessions",
 			input:                 "cli 3\nbash 2",
 			expectedOut:           cli.GreetingString,
-			expectedManualCalls:   map[string]int{"cli": 3, "bash": 2},
+			expectedManualCalls:   map[string]time.Duration{"cli": 3 * time.Hour, "bash": 2 * time.Hour},
 			expectedPomodoroCalls: []string{},
 		},
 		{
 			name:                  "continue after error",
 			input:                 "cli 3\ninvalid_data\nbash 2",
 			expectedOut:           cli.GreetingString + "\nfailed to extract subject and hours",
-			expectedManualCalls:   map[string]int{"cli": 3, "bash": 2},
+			expectedManualCalls:   map[string]time.Duration{"cli": 3 * time.Hour, "bash": 2 * time.Hour},
 			expectedPomodoroCalls: []string{},
 		},
 		{
 			name:                  "start pomodoro for tdd",
 			input:                 "pomodoro tdd",
 			expectedOut:           cli.GreetingString + "\nPomodoro started...",
-			expectedManualCalls:   map[string]int{},
+			expectedManualCalls:   map[string]time.Duration{},
 			expectedPomodoroCalls: []string{"tdd"},
 		},
 		{
 			name:                  "quit command exits gracefully",
 			input:                 "quit",
 			expectedOut:           cli.GreetingString + "\nGoodbye!",
-			expectedManualCalls:   map[string]int{},
+			expectedManualCalls:   map[string]time.Duration{},
 			expectedPomodoroCalls: []string{},
 		},
 	}
@@ -80,14 +93,14 @@ func TestCLI(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 			session := &testhelpers.SpySession{
-				ManualCalls:   map[string]int{},
+				ManualCalls:   map[string]time.Duration{},
 				PomodoroCalls: []string{},
 			}
 			in := strings.NewReader(tt.input)
 			out := &bytes.Buffer{}
 
 			trackerCLI := cli.NewCLI(in, out, session)
-			err := trackerCLI.Run()
+			err := trackerCLI.Run(t.Context())
 			assert.NoError(t, err)
 
 			assert.Equal(t, tt.expectedManualCalls, session.ManualCalls)
@@ -96,3 +109,458 @@ func TestCLI(t *testing.T) {
 		})
 	}
 }
+
+func TestCLIPomodoroFocus(t *testing.T) {
+	t.Run("passes the focus length to the session", func(t *testing.T) {
+		session := &testhelpers.SpySession{}
+		out := &bytes.Buffer{}
+
+		for _, input := range []string{"pomodoro math 50m", "pomodoro go"} {
+			trackerCLI := cli.NewCLI(strings.NewReader(input), out, session)
+			assert.NoError(t, trackerCLI.Run(t.Context()))
+		}
+
+		assert.Equal(t, []string{"math", "go"}, session.PomodoroCalls)
+		assert.Equal(t, []domain.PomodoroConfig{{Focus: 50 * time.Minute}, {}}, session.PomodoroConfigs)
+	})
+	t.Run("rejects a focus without a unit", func(t *testing.T) {
+		session := &testhelpers.SpySession{}
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("pomodoro math 50"), out, session)
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Empty(t, session.PomodoroCalls)
+		assert.Contains(t, out.String(), "invalid pomodoro config")
+	})
+}
+
+func TestCLIPomodoroCycle(t *testing.T) {
+	session := &testhelpers.SpySession{}
+	out := &bytes.Buffer{}
+
+	trackerCLI := cli.NewCLI(strings.NewReader("pomodoro-cycle math 50m"), out, session)
+	assert.NoError(t, trackerCLI.Run(t.Context()))
+
+	assert.Equal(t, []string{"math"}, session.CycleCalls)
+	assert.Empty(t, session.PomodoroCalls)
+	assert.Equal(t, []domain.PomodoroConfig{{Focus: 50 * time.Minute}}, session.PomodoroConfigs)
+}
+
+func TestCLIReport(t *testing.T) {
+	t.Run("prints report for this week", func(t *testing.T) {
+		now := time.Date(2026, 3, 18, 15, 0, 0, 0, time.Local)
+		session := &testhelpers.SpySession{
+			StubNow: now,
+			StubReport: domain.Report{
+				{Subject: "go", Duration: 90 * time.Minute},
+				{Subject: "sql", Duration: 45 * time.Minute},
+			},
+		}
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("report week"), out, session)
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Len(t, session.ReportCalls, 1)
+		assert.Equal(t, domain.ThisWeek(now), session.ReportCalls[0], "the week should be the session's")
+		assert.Contains(t, out.String(), "go: 1h30m\nsql: 45m\n")
+	})
+	t.Run("defaults to all time", func(t *testing.T) {
+		session := &testhelpers.SpySession{}
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("report"), out, session)
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Equal(t, []domain.TimeRange{domain.AllTime()}, session.ReportCalls)
+		assert.Contains(t, out.String(), "Nothing studied in this period")
+	})
+	t.Run("rejects unknown period", func(t *testing.T) {
+		session := &testhelpers.SpySession{}
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("report year"), out, session)
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Empty(t, session.ReportCalls)
+		assert.Contains(t, out.String(), "failed to build report")
+	})
+}
+
+func TestCLIUndo(t *testing.T) {
+	t.Run("removes the last entry", func(t *testing.T) {
+		session := &testhelpers.SpySession{StubSession: domain.LoggedSession{Subject: "math", Duration: 20 * time.Hour}}
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("undo"), out, session)
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Equal(t, 1, session.UndoCalls)
+		assert.Contains(t, out.String(), `Removed 20h of "math"`)
+	})
+	t.Run("reports when there is nothing to undo", func(t *testing.T) {
+		session := &testhelpers.SpySession{StubSessionErr: domain.ErrSessionNotFound}
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("undo"), out, session)
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Contains(t, out.String(), "Nothing to undo")
+	})
+}
+
+func TestCLISubjects(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		err         error
+		wantRenames [][2]string
+		wantMerges  [][]string
+		wantOutput  string
+	}{
+		{
+			name:        "renames a subject",
+			input:       "rename TDD tdd",
+			wantRenames: [][2]string{{"TDD", "tdd"}},
+			wantOutput:  `Renamed "TDD" to "tdd" (3 sessions)`,
+		},
+		{
+			name:       "merges subjects",
+			input:      "merge tdd TDD test-driven",
+			wantMerges: [][]string{{"tdd", "TDD", "test-driven"}},
+			wantOutput: `Merged 3 sessions of TDD, test-driven into "tdd"`,
+		},
+		{
+			name:       "explains rename usage",
+			input:      "rename tdd",
+			wantOutput: "usage is 'rename {subject} {new name}'",
+		},
+		{
+			name:       "explains merge usage",
+			input:      "merge tdd",
+			wantOutput: "usage is 'merge {subject} {subjects...}'",
+		},
+		{
+			name:        "reports a taken name",
+			input:       "rename TDD go",
+			err:         domain.ErrSubjectExists,
+			wantRenames: [][2]string{{"TDD", "go"}},
+			wantOutput:  "failed to rename: subject already exists",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &testhelpers.SpySession{StubMoved: 3, StubSubjectErr: tt.err}
+			out := &bytes.Buffer{}
+
+			trackerCLI := cli.NewCLI(strings.NewReader(tt.input), out, session)
+			assert.NoError(t, trackerCLI.Run(t.Context()))
+
+			assert.Equal(t, tt.wantRenames, session.RenameCalls)
+			assert.Equal(t, tt.wantMerges, session.MergeCalls)
+			assert.Contains(t, out.String(), tt.wantOutput)
+		})
+	}
+}
+
+func TestCLIGoals(t *testing.T) {
+	t.Run("prints the progress of each goal", func(t *testing.T) {
+		session := &testhelpers.SpySession{StubGoals: []domain.GoalProgress{
+			{Goal: domain.Goal{Subject: "go", Period: domain.GoalWeekly, Target: 10 * time.Hour}, Done: 6 * time.Hour},
+			{Goal: domain.Goal{Subject: "sql", Period: domain.GoalDaily, Target: time.Hour}, Done: 90 * time.Minute},
+		}}
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("goals"), out, session)
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Contains(t, out.String(), "go (weekly): 6h of 10h, 60%, 4h to go\nsql (daily): 1h30m of 1h, 150%, done\n")
+	})
+	t.Run("explains how to set a goal when there is none", func(t *testing.T) {
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("goals"), out, &testhelpers.SpySession{})
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Contains(t, out.String(), "No goals set yet")
+	})
+
+	tests := []struct {
+		name        string
+		input       string
+		wantGoals   []domain.Goal
+		wantRemoved []domain.Goal
+		wantOutput  string
+	}{
+		{
+			name:       "sets a weekly goal",
+			input:      "goals set go 10h",
+			wantGoals:  []domain.Goal{{Subject: "go", Period: domain.GoalWeekly, Target: 10 * time.Hour}},
+			wantOutput: `Goal set: 10h of "go" weekly`,
+		},
+		{
+			name:       "sets a daily goal",
+			input:      "goals set sql 1 daily",
+			wantGoals:  []domain.Goal{{Subject: "sql", Period: domain.GoalDaily, Target: time.Hour}},
+			wantOutput: `Goal set: 1h of "sql" daily`,
+		},
+		{
+			name:        "removes a monthly goal",
+			input:       "goals remove go monthly",
+			wantRemoved: []domain.Goal{{Subject: "go", Period: domain.GoalMonthly}},
+			wantOutput:  `Goal removed: "go" monthly`,
+		},
+		{
+			name:       "rejects an unknown period",
+			input:      "goals set go 10h yearly",
+			wantOutput: "failed to set goal: invalid goal period",
+		},
+		{
+			name:       "rejects an invalid target",
+			input:      "goals set go lots",
+			wantOutput: "failed to set goal: invalid duration",
+		},
+		{
+			name:       "explains usage",
+			input:      "goals add go",
+			wantOutput: "failed to change goals: usage is",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &testhelpers.SpySession{}
+			out := &bytes.Buffer{}
+
+			trackerCLI := cli.NewCLI(strings.NewReader(tt.input), out, session)
+			assert.NoError(t, trackerCLI.Run(t.Context()))
+
+			assert.Equal(t, tt.wantGoals, session.GoalCalls)
+			assert.Equal(t, tt.wantRemoved, session.DeleteGoalCalls)
+			assert.Contains(t, out.String(), tt.wantOutput)
+		})
+	}
+}
+
+func TestCLIStats(t *testing.T) {
+	t.Run("prints streaks, active days and session lengths", func(t *testing.T) {
+		session := &testhelpers.SpySession{StubStats: domain.Stats{
+			CurrentStreak:     1,
+			LongestStreak:     5,
+			ActiveDaysPerWeek: 3.5,
+			Subjects: []domain.SubjectStats{
+				{Subject: "go", Sessions: 4, Total: 5 * time.Hour},
+				{Subject: "sql", Sessions: 1, Total: 30 * time.Minute},
+			},
+		}}
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("stats\nstats 2"), out, session)
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Equal(t, []int{domain.DefaultStatsWeeks, 2}, session.StatsCalls)
+		assert.Contains(t, out.String(), "Current streak: 1 day, longest: 5 days\n"+
+			"Active days per week: 3.5 over the last 4 weeks\n"+
+			"go: 4 sessions, 5h in total, 1h15m on average\n"+
+			"sql: 1 session, 30m in total, 30m on average\n")
+	})
+
+	tests := []struct {
+		name       string
+		input      string
+		session    *testhelpers.SpySession
+		wantOutput string
+	}{
+		{"says when nothing was studied", "stats", &testhelpers.SpySession{}, "Nothing studied yet"},
+		{"rejects weeks that are not a number", "stats many", &testhelpers.SpySession{}, `failed to get stats: weeks should be a number, got "many"`},
+		{"reports errors", "stats 0", &testhelpers.SpySession{StubStatsErr: domain.ErrInvalidStats}, "failed to get stats: invalid stats request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+
+			trackerCLI := cli.NewCLI(strings.NewReader(tt.input), out, tt.session)
+			assert.NoError(t, trackerCLI.Run(t.Context()))
+
+			assert.Contains(t, out.String(), tt.wantOutput)
+		})
+	}
+}
+
+func TestCLIExport(t *testing.T) {
+	const csv = "id,subject,started_at,ended_at,hours,duration,source\n"
+
+	t.Run("writes sessions to the output", func(t *testing.T) {
+		session := &testhelpers.SpySession{StubExport: csv}
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("export\nexport jsonl"), out, session)
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Equal(t, []domain.ExportFormat{domain.ExportCSV, domain.ExportJSONLines}, session.ExportCalls)
+		assert.Contains(t, out.String(), csv)
+	})
+	t.Run("writes sessions to a file", func(t *testing.T) {
+		session := &testhelpers.SpySession{StubExport: csv, StubExported: 2}
+		path := filepath.Join(t.TempDir(), "sessions.csv")
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("export csv "+path), out, session)
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Contains(t, out.String(), "Exported 2 sessions to "+path)
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, csv, string(data))
+	})
+
+	tests := []struct {
+		name       string
+		input      string
+		wantOutput string
+	}{
+		{"rejects unknown formats", "export xml", `failed to export: invalid export format "xml"`},
+		{"rejects extra arguments", "export csv a.csv b.csv", "failed to export: usage is"},
+		{"reports files it cannot create", "export csv " + filepath.Join(t.TempDir(), "missing", "a.csv"), "failed to export: open"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+
+			trackerCLI := cli.NewCLI(strings.NewReader(tt.input), out, &testhelpers.SpySession{})
+			assert.NoError(t, trackerCLI.Run(t.Context()))
+
+			assert.Contains(t, out.String(), tt.wantOutput)
+		})
+	}
+}
+
+func TestCLIImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hours.csv")
+	assert.NoError(t, os.WriteFile(path, []byte("Day,Course,Hours\n2026-03-16,Go,1.5\n"), 0o600))
+	summary := domain.ImportSummary{
+		Format:     domain.ImportCSV,
+		Read:       3,
+		Imported:   2,
+		Duplicates: 1,
+		From:       time.Date(2026, 3, 16, 9, 0, 0, 0, time.Local),
+		To:         time.Date(2026, 3, 17, 11, 0, 0, 0, time.Local),
+		Subjects:   domain.Report{{Subject: "Go", Duration: 90 * time.Minute}, {Subject: "SQL", Duration: time.Hour}},
+	}
+
+	t.Run("imports once confirmed", func(t *testing.T) {
+		session := &testhelpers.SpySession{StubImport: summary}
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("import csv "+path+" subject=Course start=Day duration=Hours\nyes"), out, session)
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Contains(t, out.String(), "Read 3 sessions: 2 new, 1 already recorded\n"+
+			"New sessions from 2026-03-16 to 2026-03-17:\n"+
+			"  Go: 1h30m\n"+
+			"  SQL: 1h\n"+
+			"Import 2 sessions? Type 'yes' to confirm\n"+
+			"Imported 2 sessions\n")
+		if assert.Len(t, session.ImportCalls, 2) {
+			assert.True(t, session.ImportCalls[0].DryRun, "the first import should be a dry run")
+			assert.False(t, session.ImportCalls[1].DryRun)
+			assert.Equal(t, domain.ImportColumns{Subject: "Course", Start: "Day", Duration: "Hours"}, session.ImportCalls[1].Columns)
+		}
+	})
+	t.Run("cancels unless confirmed", func(t *testing.T) {
+		session := &testhelpers.SpySession{StubImport: summary}
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("import toggl "+path+"\nno"), out, session)
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Contains(t, out.String(), "Import cancelled")
+		assert.Len(t, session.ImportCalls, 1)
+	})
+	t.Run("stops when there is nothing new", func(t *testing.T) {
+		session := &testhelpers.SpySession{StubImport: domain.ImportSummary{Read: 1, Duplicates: 1}}
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("import csv "+path), out, session)
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Contains(t, out.String(), "Read 1 session: 0 new, 1 already recorded\nNothing new to import\n")
+		assert.Len(t, session.ImportCalls, 1)
+	})
+
+	tests := []struct {
+		name       string
+		input      string
+		wantOutput string
+	}{
+		{"needs a format and a file", "import " + path, "failed to import: usage is"},
+		{"rejects unknown formats", "import xml " + path, `failed to import: invalid import format "xml"`},
+		{"rejects unknown columns", "import csv " + path + " course=Course", `failed to import: invalid import column "course"`},
+		{"rejects columns without a name", "import csv " + path + " subject", "failed to import: usage is"},
+		{"reports files it cannot read", "import csv " + path + ".missing", "failed to import: open"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			session := &testhelpers.SpySession{}
+
+			trackerCLI := cli.NewCLI(strings.NewReader(tt.input), out, session)
+			assert.NoError(t, trackerCLI.Run(t.Context()))
+
+			assert.Contains(t, out.String(), tt.wantOutput)
+			assert.Empty(t, session.ImportCalls)
+		})
+	}
+}
+
+// blockingPomodoroSession runs a Pomodoro that only ends when it is cancelled.
+type blockingPomodoroSession struct {
+	testhelpers.SpySession
+}
+
+func (b *blockingPomodoroSession) RecordPomodoro(ctx context.Context, subject string, config domain.PomodoroConfig, out io.Writer, control *domain.PomodoroControl) (domain.LoggedSession, error) {
+	for {
+		changed := control.Changed()
+		if control.State() == domain.PomodoroCancelled {
+			return domain.LoggedSession{}, domain.ErrPomodoroCancelled
+		}
+		select {
+		case <-ctx.Done():
+			return domain.LoggedSession{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func TestCLIPomodoroControls(t *testing.T) {
+	t.Run("pauses, resumes and cancels the running pomodoro", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		in := "pomodoro go\npomodoro sql\npause\npause\nresume\ncancel\n"
+
+		trackerCLI := cli.NewCLI(strings.NewReader(in), out, &blockingPomodoroSession{})
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Contains(t, out.String(), "Pomodoro started...\n"+
+			"failed to record pomodoro: a pomodoro is already running\n"+
+			"Pomodoro paused\n"+
+			"failed to pause pomodoro: invalid pomodoro state change: cannot go from paused to paused\n"+
+			"Pomodoro resumed\n")
+		assert.Contains(t, out.String(), `Pomodoro for "go" cancelled`)
+	})
+	t.Run("reports when no pomodoro is running", func(t *testing.T) {
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("pause"), out, &blockingPomodoroSession{})
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.Contains(t, out.String(), "no pomodoro is running")
+	})
+	t.Run("quit cancels the running pomodoro before exiting", func(t *testing.T) {
+		out := &bytes.Buffer{}
+
+		trackerCLI := cli.NewCLI(strings.NewReader("pomodoro go\nquit"), out, &blockingPomodoroSession{})
+		assert.NoError(t, trackerCLI.Run(t.Context()))
+
+		assert.True(t, strings.HasSuffix(out.String(), "Pomodoro for \"go\" cancelled\nGoodbye!\n"), out.String())
+	})
+}
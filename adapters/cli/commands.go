@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+// Exit codes of RunCommand.
+const (
+	ExitOK    = 0
+	ExitError = 1 // the command failed
+	ExitUsage = 2 // the command line is wrong
+)
+
+// CommandUsage describes the commands RunCommand runs.
+const CommandUsage = `usage: study-cli [flags] COMMAND [--json] [ARGS]
+
+Commands:
+  record SUBJECT DURATION         record time studied, e.g. 'record math 2' or 'record math 1h30m'
+  pomodoro SUBJECT [FOCUS]        run a Pomodoro and record its focus time
+  report [today|week|month|all]   show the time studied per subject
+  hours SUBJECT                   show the total time studied on a subject
+
+With --json the result is written as JSON. A Pomodoro interrupted with Ctrl+C
+records the focus time spent so far and exits 0, or records nothing when it
+ran for less than a second. Without a command the interactive session starts.
+migrate, token, export and import also take flags after the command name,
+which default to the flags before it.`
+
+var errUsage = errors.New("wrong usage")
+
+// CommandSession is what RunCommand does with a study session.
+type CommandSession interface {
+	domain.SessionRecorder
+	domain.StatsReporter
+}
+
+// RunCommand runs a single command from the command line, like
+// 'record math 2', and returns the exit code for it. Results go to out and
+// errors and Pomodoro alerts in JSON mode to errOut, so that out can be parsed.
+func RunCommand(ctx context.Context, args []string, out, errOut io.Writer, session CommandSession) int {
+	args, asJSON := jsonFlag(args)
+	if len(args) == 0 {
+		fmt.Fprintln(errOut, CommandUsage)
+		return ExitUsage
+	}
+
+	command := args[0]
+	var err error
+	switch command {
+	case "help", "-h", "-help", "--help":
+		fmt.Fprintln(out, CommandUsage)
+		return ExitOK
+	case "record":
+		err = runRecord(ctx, args[1:], out, asJSON, session)
+	case PomodoroCommand:
+		err = runPomodoro(ctx, args[1:], out, errOut, asJSON, session)
+	case ReportCommand:
+		err = runReport(ctx, args[1:], out, asJSON, session)
+	case "hours":
+		err = runHours(ctx, args[1:], out, asJSON, session)
+	default:
+		err = fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(errOut, "study-cli %s: %v\n\n%s\n", command, err, CommandUsage)
+		return ExitUsage
+	case err != nil:
+		fmt.Fprintf(errOut, "study-cli %s: %v\n", command, err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+// jsonFlag removes -json or --json from args wherever it is, so that it may
+// follow the arguments as in 'hours math --json'.
+func jsonFlag(args []string) (rest []string, asJSON bool) {
+	rest = make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "-json" || arg == "--json" {
+			asJSON = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, asJSON
+}
+
+func runRecord(ctx context.Context, args []string, out io.Writer, asJSON bool, session domain.SessionRecorder) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%w: record needs a subject and a duration", errUsage)
+	}
+	d, err := domain.ParseDuration(args[1])
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	recorded, err := session.RecordManual(ctx, args[0], d)
+	if err != nil {
+		return fmt.Errorf("failed to record hours: %w", err)
+	}
+	return printRecorded(out, asJSON, recorded)
+}
+
+func runPomodoro(ctx context.Context, args []string, out, errOut io.Writer, asJSON bool, session domain.SessionRecorder) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("%w: pomodoro needs a subject and an optional focus length", errUsage)
+	}
+	var config domain.PomodoroConfig
+	if len(args) == 2 {
+		focus, err := domain.ParseFocus(args[1])
+		if err != nil {
+			return fmt.Errorf("%w: %w", errUsage, err)
+		}
+		config.Focus = focus
+	}
+
+	alerts := out
+	if asJSON {
+		alerts = errOut
+	}
+	recorded, err := session.RecordPomodoro(ctx, args[0], config, alerts, domain.NewPomodoroControl())
+	// An interrupted Pomodoro has logged its focus time, which is reported
+	// like that of a finished one.
+	if errors.Is(err, domain.ErrPomodoroCancelled) || errors.Is(err, context.Canceled) {
+		if recorded == (domain.LoggedSession{}) {
+			fmt.Fprintf(alerts, "Pomodoro for %q cancelled, nothing recorded\n", args[0])
+			return nil
+		}
+		fmt.Fprintf(alerts, "Pomodoro for %q cancelled\n", args[0])
+	} else if err != nil {
+		return fmt.Errorf("failed to record pomodoro: %w", err)
+	}
+	return printRecorded(out, asJSON, recorded)
+}
+
+// printRecorded prints the session just recorded, as an exported JSON line in
+// JSON mode.
+func printRecorded(out io.Writer, asJSON bool, recorded domain.LoggedSession) error {
+	if !asJSON {
+		fmt.Fprintf(out, "Recorded %s of %q\n", domain.FormatDuration(recorded.Duration), recorded.Subject)
+		return nil
+	}
+	exporter, err := domain.NewSessionExporter(out, domain.ExportJSONLines)
+	if err != nil {
+		return err
+	}
+	return exporter.Export(recorded)
+}
+
+func runReport(ctx context.Context, args []string, out io.Writer, asJSON bool, session domain.StatsReporter) error {
+	if len(args) > 1 {
+		return fmt.Errorf("%w: report takes at most a period", errUsage)
+	}
+	name := domain.PeriodAll
+	if len(args) == 1 {
+		name = args[0]
+	}
+	period, err := domain.ParsePeriod(name, session.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	report, err := session.Report(ctx, period)
+	if err != nil {
+		return fmt.Errorf("failed to get report: %w", err)
+	}
+	if asJSON {
+		if report == nil {
+			report = domain.Report{}
+		}
+		return json.NewEncoder(out).Encode(report)
+	}
+	for _, activity := range report {
+		fmt.Fprintf(out, "%s: %s\n", activity.Subject, domain.FormatDuration(activity.Duration))
+	}
+	return nil
+}
+
+func runHours(ctx context.Context, args []string, out io.Writer, asJSON bool, session domain.StatsReporter) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: hours needs a subject", errUsage)
+	}
+	d, err := session.Hours(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to get hours: %w", err)
+	}
+	activity := domain.StudyActivity{Subject: args[0], Duration: d}
+	if asJSON {
+		return json.NewEncoder(out).Encode(activity)
+	}
+	fmt.Fprintf(out, "%s: %s\n", activity.Subject, domain.FormatDuration(activity.Duration))
+	return nil
+}
@@ -0,0 +1,184 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/adapters/cli"
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommand(t *testing.T) {
+	start := time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)
+	recorded := domain.LoggedSession{ID: 7, Subject: "math", StartedAt: start, EndedAt: start.Add(2 * time.Hour), Duration: 2 * time.Hour, Source: domain.SourceManual}
+	report := domain.Report{{Subject: "math", Duration: 90 * time.Minute}, {Subject: "go", Duration: 30 * time.Minute}}
+
+	tests := []struct {
+		name     string
+		args     []string
+		session  *testhelpers.SpySession
+		wantCode int
+		wantOut  string
+		wantErr  string
+	}{
+		{
+			name:     "records hours",
+			args:     []string{"record", "math", "2"},
+			session:  &testhelpers.SpySession{StubSession: recorded},
+			wantCode: cli.ExitOK,
+			wantOut:  "Recorded 2h of \"math\"\n",
+		},
+		{
+			name:     "records hours as JSON",
+			args:     []string{"record", "math", "2h", "--json"},
+			session:  &testhelpers.SpySession{StubSession: recorded},
+			wantCode: cli.ExitOK,
+			wantOut:  `{"id":7,"subject":"math","started_at":"2026-03-16T09:00:00Z","ended_at":"2026-03-16T11:00:00Z","hours":2,"duration":"2h0m0s","source":"manual"}` + "\n",
+		},
+		{
+			name:     "rejects an invalid duration",
+			args:     []string{"record", "math", "two"},
+			session:  &testhelpers.SpySession{},
+			wantCode: cli.ExitUsage,
+			wantErr:  "study-cli record: wrong usage: invalid duration",
+		},
+		{
+			name:     "rejects a missing duration",
+			args:     []string{"record", "math"},
+			session:  &testhelpers.SpySession{},
+			wantCode: cli.ExitUsage,
+			wantErr:  "record needs a subject and a duration",
+		},
+		{
+			name:     "reports a failed record",
+			args:     []string{"record", "math", "2"},
+			session:  &testhelpers.SpySession{StubRecordErr: errors.New("disk full")},
+			wantCode: cli.ExitError,
+			wantErr:  "study-cli record: failed to record hours: disk full\n",
+		},
+		{
+			name:     "runs a pomodoro",
+			args:     []string{"pomodoro", "math", "50m"},
+			session:  &testhelpers.SpySession{StubSession: recorded, ScheduleAlert: []byte("Break time!\n")},
+			wantCode: cli.ExitOK,
+			wantOut:  "Break time!\nRecorded 2h of \"math\"\n",
+		},
+		{
+			name:     "keeps pomodoro alerts out of JSON",
+			args:     []string{"--json", "pomodoro", "math"},
+			session:  &testhelpers.SpySession{StubSession: recorded, ScheduleAlert: []byte("Break time!\n")},
+			wantCode: cli.ExitOK,
+			wantOut:  `{"id":7,"subject":"math","started_at":"2026-03-16T09:00:00Z","ended_at":"2026-03-16T11:00:00Z","hours":2,"duration":"2h0m0s","source":"manual"}` + "\n",
+			wantErr:  "Break time!\n",
+		},
+		{
+			name:     "reports the focus time of a cancelled pomodoro",
+			args:     []string{"pomodoro", "math"},
+			session:  &testhelpers.SpySession{StubSession: recorded, StubRecordErr: domain.ErrPomodoroCancelled},
+			wantCode: cli.ExitOK,
+			wantOut:  "Pomodoro for \"math\" cancelled\nRecorded 2h of \"math\"\n",
+		},
+		{
+			name:     "reports a pomodoro cancelled before any focus time",
+			args:     []string{"pomodoro", "math", "--json"},
+			session:  &testhelpers.SpySession{StubRecordErr: context.Canceled},
+			wantCode: cli.ExitOK,
+			wantErr:  "Pomodoro for \"math\" cancelled, nothing recorded\n",
+		},
+		{
+			name:     "prints the report",
+			args:     []string{"report", "week"},
+			session:  &testhelpers.SpySession{StubReport: report},
+			wantCode: cli.ExitOK,
+			wantOut:  "math: 1h30m\ngo: 30m\n",
+		},
+		{
+			name:     "prints the report as JSON",
+			args:     []string{"report", "--json"},
+			session:  &testhelpers.SpySession{StubReport: report},
+			wantCode: cli.ExitOK,
+			wantOut:  `[{"subject":"math","hours":1.5,"duration":"1h30m0s"},{"subject":"go","hours":0.5,"duration":"30m0s"}]` + "\n",
+		},
+		{
+			name:     "prints an empty report as an empty JSON array",
+			args:     []string{"report", "today", "-json"},
+			session:  &testhelpers.SpySession{},
+			wantCode: cli.ExitOK,
+			wantOut:  "[]\n",
+		},
+		{
+			name:     "rejects an unknown period",
+			args:     []string{"report", "year"},
+			session:  &testhelpers.SpySession{},
+			wantCode: cli.ExitUsage,
+			wantErr:  "study-cli report: wrong usage:",
+		},
+		{
+			name:     "prints the hours of a subject",
+			args:     []string{"hours", "math"},
+			session:  &testhelpers.SpySession{StubHours: map[string]time.Duration{"math": 90 * time.Minute}},
+			wantCode: cli.ExitOK,
+			wantOut:  "math: 1h30m\n",
+		},
+		{
+			name:     "prints the hours of a subject as JSON",
+			args:     []string{"hours", "math", "--json"},
+			session:  &testhelpers.SpySession{StubHours: map[string]time.Duration{"math": 90 * time.Minute}},
+			wantCode: cli.ExitOK,
+			wantOut:  `{"subject":"math","hours":1.5,"duration":"1h30m0s"}` + "\n",
+		},
+		{
+			name:     "fails on an unknown subject",
+			args:     []string{"hours", "history"},
+			session:  &testhelpers.SpySession{},
+			wantCode: cli.ExitError,
+			wantErr:  "study-cli hours: failed to get hours: subject not found\n",
+		},
+		{
+			name:     "prints the usage on request",
+			args:     []string{"help"},
+			session:  &testhelpers.SpySession{},
+			wantCode: cli.ExitOK,
+			wantOut:  cli.CommandUsage + "\n",
+		},
+		{
+			name:     "rejects an unknown command",
+			args:     []string{"stat"},
+			session:  &testhelpers.SpySession{},
+			wantCode: cli.ExitUsage,
+			wantErr:  "study-cli stat: wrong usage: unknown command \"stat\"\n\n" + cli.CommandUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.session.ManualCalls = map[string]time.Duration{}
+			out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+
+			code := cli.RunCommand(t.Context(), tt.args, out, errOut, tt.session)
+
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantOut, out.String())
+			if tt.wantErr == "" {
+				assert.Empty(t, errOut.String())
+			} else {
+				assert.Contains(t, errOut.String(), tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("passes the subject and duration on", func(t *testing.T) {
+		session := &testhelpers.SpySession{ManualCalls: map[string]time.Duration{}, StubSession: recorded}
+
+		assert.Equal(t, cli.ExitOK, cli.RunCommand(t.Context(), []string{"record", "math", "1h30m"}, &bytes.Buffer{}, &bytes.Buffer{}, session))
+		assert.Equal(t, map[string]time.Duration{"math": 90 * time.Minute}, session.ManualCalls)
+
+		assert.Equal(t, cli.ExitOK, cli.RunCommand(t.Context(), []string{"pomodoro", "go", "50m"}, &bytes.Buffer{}, &bytes.Buffer{}, session))
+		assert.Equal(t, []string{"go"}, session.PomodoroCalls)
+		assert.Equal(t, []domain.PomodoroConfig{{Focus: 50 * time.Minute}}, session.PomodoroConfigs)
+	})
+}
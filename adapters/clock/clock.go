@@ -0,0 +1,35 @@
+// Package clock provides the wall-clock implementation of domain.Clock.
+package clock
+
+import (
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+// Real is a domain.Clock backed by the time package.
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+func (Real) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (Real) AfterFunc(d time.Duration, f func()) domain.Timer {
+	return time.AfterFunc(d, f)
+}
+
+func (Real) NewTicker(d time.Duration) domain.Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
@@ -0,0 +1,371 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+const (
+	dataDirName     = "study_hours_tracker"
+	defaultDataFile = "sessions.json"
+	lockFileSuffix  = ".lock"
+)
+
+// FileSubjectStore persists the session log as a JSON document in a single
+// file. Writes replace the file atomically and every access holds a lock on a
+// sibling lock file, so several processes can share the same data file.
+type FileSubjectStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSubjectStore opens the store at path, creating its directory if needed.
+func NewFileSubjectStore(ctx context.Context, path string) (*FileSubjectStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create data directory for %q: %w", path, err)
+	}
+	store := &FileSubjectStore{path: path}
+	if err := store.view(ctx, func(*sessionLog) error { return nil }); err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", path, err)
+	}
+	return store, nil
+}
+
+// DefaultDataFile returns the data file under $XDG_DATA_HOME, which defaults
+// to ~/.local/share.
+func DefaultDataFile() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, dataDirName, defaultDataFile), nil
+}
+
+func (fs *FileSubjectStore) GetHours(ctx context.Context, subject string) (time.Duration, error) {
+	var hours time.Duration
+	err := fs.view(ctx, func(l *sessionLog) error {
+		var err error
+		hours, err = l.getHours(domain.UserFromContext(ctx), subject)
+		return err
+	})
+	return hours, err
+}
+
+func (fs *FileSubjectStore) LogSession(ctx context.Context, session domain.LoggedSession) (domain.LoggedSession, error) {
+	session.UserID = domain.UserFromContext(ctx)
+	err := fs.update(ctx, func(l *sessionLog) error {
+		session = l.logSession(session)
+		return nil
+	})
+	return session, err
+}
+
+// LogSessions writes the data file once, so that it holds either all of
+// sessions or none.
+func (fs *FileSubjectStore) LogSessions(ctx context.Context, sessions []domain.LoggedSession) error {
+	user := domain.UserFromContext(ctx)
+	return fs.update(ctx, func(l *sessionLog) error {
+		for _, session := range sessions {
+			session.UserID = user
+			l.logSession(session)
+		}
+		return nil
+	})
+}
+
+func (fs *FileSubjectStore) LogNewSessions(ctx context.Context, sessions []domain.LoggedSession) ([]domain.LoggedSession, error) {
+	var fresh []domain.LoggedSession
+	err := fs.update(ctx, func(l *sessionLog) error {
+		fresh = l.logNewSessions(domain.UserFromContext(ctx), sessions)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fresh, nil
+}
+
+func (fs *FileSubjectStore) GetSessions(ctx context.Context, subject string) ([]domain.LoggedSession, error) {
+	var sessions []domain.LoggedSession
+	err := fs.view(ctx, func(l *sessionLog) error {
+		sessions = l.getSessions(domain.UserFromContext(ctx), subject)
+		return nil
+	})
+	return sessions, err
+}
+
+func (fs *FileSubjectStore) GetAllSessions(ctx context.Context, period domain.TimeRange) ([]domain.LoggedSession, error) {
+	var sessions []domain.LoggedSession
+	err := fs.view(ctx, func(l *sessionLog) error {
+		sessions = l.getAllSessions(domain.UserFromContext(ctx), period)
+		return nil
+	})
+	return sessions, err
+}
+
+// WalkSessions calls fn on a copy of the sessions, so that a slow fn does not
+// keep the file locked.
+func (fs *FileSubjectStore) WalkSessions(ctx context.Context, period domain.TimeRange, fn func(domain.LoggedSession) error) error {
+	sessions, err := fs.GetAllSessions(ctx, period)
+	if err != nil {
+		return err
+	}
+	return walkSessions(ctx, sessions, fn)
+}
+
+func (fs *FileSubjectStore) GetSession(ctx context.Context, id int64) (domain.LoggedSession, error) {
+	var session domain.LoggedSession
+	err := fs.view(ctx, func(l *sessionLog) error {
+		var err error
+		session, err = l.getSession(domain.UserFromContext(ctx), id)
+		return err
+	})
+	return session, err
+}
+
+func (fs *FileSubjectStore) GetLastSession(ctx context.Context) (domain.LoggedSession, error) {
+	var session domain.LoggedSession
+	err := fs.view(ctx, func(l *sessionLog) error {
+		var err error
+		session, err = l.getLastSession(domain.UserFromContext(ctx))
+		return err
+	})
+	return session, err
+}
+
+func (fs *FileSubjectStore) UpdateSession(ctx context.Context, session domain.LoggedSession) error {
+	return fs.update(ctx, func(l *sessionLog) error {
+		return l.updateSession(domain.UserFromContext(ctx), session)
+	})
+}
+
+func (fs *FileSubjectStore) DeleteSession(ctx context.Context, id int64) error {
+	return fs.update(ctx, func(l *sessionLog) error {
+		return l.deleteSession(domain.UserFromContext(ctx), id)
+	})
+}
+
+func (fs *FileSubjectStore) DeleteLastSession(ctx context.Context) (domain.LoggedSession, error) {
+	var session domain.LoggedSession
+	err := fs.update(ctx, func(l *sessionLog) error {
+		var err error
+		session, err = l.deleteLastSession(domain.UserFromContext(ctx))
+		return err
+	})
+	return session, err
+}
+
+func (fs *FileSubjectStore) GetReport(ctx context.Context, period domain.TimeRange) (domain.Report, error) {
+	var report domain.Report
+	err := fs.view(ctx, func(l *sessionLog) error {
+		report = l.getReport(domain.UserFromContext(ctx), period)
+		return nil
+	})
+	return report, err
+}
+
+func (fs *FileSubjectStore) RenameSubject(ctx context.Context, from, to string) (int, error) {
+	var moved int
+	err := fs.update(ctx, func(l *sessionLog) error {
+		var err error
+		moved, err = l.renameSubject(domain.UserFromContext(ctx), from, to)
+		return err
+	})
+	return moved, err
+}
+
+func (fs *FileSubjectStore) MergeSubjects(ctx context.Context, into string, from []string) (int, error) {
+	var moved int
+	err := fs.update(ctx, func(l *sessionLog) error {
+		var err error
+		moved, err = l.mergeSubjects(domain.UserFromContext(ctx), into, from)
+		return err
+	})
+	return moved, err
+}
+
+func (fs *FileSubjectStore) SaveActivePomodoro(ctx context.Context, active domain.ActivePomodoro) error {
+	active.UserID = domain.UserFromContext(ctx)
+	return fs.update(ctx, func(l *sessionLog) error {
+		l.saveActive(active)
+		return nil
+	})
+}
+
+func (fs *FileSubjectStore) DeleteActivePomodoro(ctx context.Context, id string) error {
+	return fs.update(ctx, func(l *sessionLog) error {
+		l.deleteActive(domain.UserFromContext(ctx), id)
+		return nil
+	})
+}
+
+func (fs *FileSubjectStore) GetActivePomodoros(ctx context.Context) ([]domain.ActivePomodoro, error) {
+	var active []domain.ActivePomodoro
+	err := fs.view(ctx, func(l *sessionLog) error {
+		active = l.getActive()
+		return nil
+	})
+	return active, err
+}
+
+func (fs *FileSubjectStore) SaveToken(ctx context.Context, token domain.APIToken) error {
+	return fs.update(ctx, func(l *sessionLog) error {
+		l.saveToken(token)
+		return nil
+	})
+}
+
+func (fs *FileSubjectStore) GetTokenByHash(ctx context.Context, hash string) (domain.APIToken, error) {
+	var token domain.APIToken
+	err := fs.view(ctx, func(l *sessionLog) error {
+		var err error
+		token, err = l.getTokenByHash(hash)
+		return err
+	})
+	return token, err
+}
+
+func (fs *FileSubjectStore) GetTokens(ctx context.Context) ([]domain.APIToken, error) {
+	var tokens []domain.APIToken
+	err := fs.view(ctx, func(l *sessionLog) error {
+		tokens = l.getTokens(domain.UserFromContext(ctx))
+		return nil
+	})
+	return tokens, err
+}
+
+func (fs *FileSubjectStore) DeleteToken(ctx context.Context, id string) error {
+	return fs.update(ctx, func(l *sessionLog) error {
+		return l.deleteToken(domain.UserFromContext(ctx), id)
+	})
+}
+
+func (fs *FileSubjectStore) SaveGoal(ctx context.Context, goal domain.Goal) error {
+	goal.UserID = domain.UserFromContext(ctx)
+	return fs.update(ctx, func(l *sessionLog) error {
+		l.saveGoal(goal)
+		return nil
+	})
+}
+
+func (fs *FileSubjectStore) GetGoals(ctx context.Context) ([]domain.Goal, error) {
+	var goals []domain.Goal
+	err := fs.view(ctx, func(l *sessionLog) error {
+		goals = l.getGoals(domain.UserFromContext(ctx))
+		return nil
+	})
+	return goals, err
+}
+
+func (fs *FileSubjectStore) DeleteGoal(ctx context.Context, subject string, period domain.GoalPeriod) error {
+	return fs.update(ctx, func(l *sessionLog) error {
+		return l.deleteGoal(domain.UserFromContext(ctx), subject, period)
+	})
+}
+
+// view runs fn on the current file contents under a shared lock.
+func (fs *FileSubjectStore) view(ctx context.Context, fn func(*sessionLog) error) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(fs.path+lockFileSuffix, false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	l, err := fs.load()
+	if err != nil {
+		return err
+	}
+	return fn(l)
+}
+
+// update runs fn on the current file contents under an exclusive lock and
+// saves the result if fn succeeds.
+func (fs *FileSubjectStore) update(ctx context.Context, fn func(*sessionLog) error) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(fs.path+lockFileSuffix, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	l, err := fs.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(l); err != nil {
+		return err
+	}
+	return fs.save(l)
+}
+
+func (fs *FileSubjectStore) load() (*sessionLog, error) {
+	l := &sessionLog{}
+	data, err := os.ReadFile(fs.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", fs.path, err)
+	}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("failed to decode %q: %w", fs.path, err)
+	}
+	l.assignDefaultUser()
+	return l, nil
+}
+
+// save writes to a temporary file in the same directory and renames it over
+// the data file, so readers never observe a partially written document.
+func (fs *FileSubjectStore) save(l *sessionLog) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session log: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fs.path), filepath.Base(fs.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %q: %w", tmp.Name(), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync %q: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %q: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), fs.path); err != nil {
+		return fmt.Errorf("failed to replace %q: %w", fs.path, err)
+	}
+	return nil
+}
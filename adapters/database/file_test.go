@@ -0,0 +1,120 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/bryack/study_hours_tracker/testhelpers/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSubjectStore(t *testing.T) {
+	ctx := t.Context()
+	storetest.SubjectStoreContract{
+		NewStore: func(t testing.TB) domain.SubjectStore {
+			store, err := NewFileSubjectStore(t.Context(), filepath.Join(t.TempDir(), "sessions.json"))
+			require.NoError(t, err)
+			return store
+		},
+	}.Test(t)
+
+	t.Run("persists sessions across reopening", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "data", "sessions.json")
+		store, err := NewFileSubjectStore(ctx, path)
+		require.NoError(t, err)
+
+		storetest.RecordHours(t, ctx, store, "tdd", 2*time.Hour)
+		storetest.RecordHours(t, ctx, store, "go", 45*time.Minute)
+
+		reopened, err := NewFileSubjectStore(ctx, path)
+		require.NoError(t, err)
+
+		h, err := reopened.GetHours(ctx, "tdd")
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Hour, h)
+
+		report, err := reopened.GetReport(ctx, domain.AllTime())
+		assert.NoError(t, err)
+		assert.Equal(t, domain.Report{
+			{Subject: "tdd", Duration: 2 * time.Hour},
+			{Subject: "go", Duration: 45 * time.Minute},
+		}, report)
+	})
+
+	t.Run("leaves no temporary files behind", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := NewFileSubjectStore(ctx, filepath.Join(dir, "sessions.json"))
+		require.NoError(t, err)
+
+		storetest.RecordHours(t, ctx, store, "tdd", time.Hour)
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		assert.ElementsMatch(t, []string{"sessions.json", "sessions.json.lock"}, names)
+	})
+
+	t.Run("rejects a corrupted data file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "sessions.json")
+		require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+
+		_, err := NewFileSubjectStore(ctx, path)
+		assert.Error(t, err)
+	})
+
+	t.Run("gives sessions without a user to the default user", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "sessions.json")
+		legacy := `{"next_id":1,"sessions":[{"id":1,"subject":"go","started_at":"2026-01-05T09:00:00Z","ended_at":"2026-01-05T10:00:00Z","duration":3600000000000,"source":"manual"}]}`
+		require.NoError(t, os.WriteFile(path, []byte(legacy), 0o600))
+
+		store, err := NewFileSubjectStore(ctx, path)
+		require.NoError(t, err)
+
+		h, err := store.GetHours(ctx, "go")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Hour, h)
+
+		_, err = store.GetHours(domain.WithUser(ctx, "alice"), "go")
+		assert.ErrorIs(t, err, domain.ErrSubjectNotFound)
+	})
+
+	t.Run("stores sharing a file do not lose recordings", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "sessions.json")
+		first, err := NewFileSubjectStore(ctx, path)
+		require.NoError(t, err)
+		second, err := NewFileSubjectStore(ctx, path)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := range 50 {
+			store := first
+			if i%2 == 0 {
+				store = second
+			}
+			wg.Go(func() {
+				_, err := store.LogSession(ctx, domain.LoggedSession{Subject: "tdd", Duration: time.Hour, Source: domain.SourceManual})
+				assert.NoError(t, err)
+			})
+		}
+		wg.Wait()
+
+		h, err := first.GetHours(ctx, "tdd")
+		assert.NoError(t, err)
+		assert.Equal(t, 50*time.Hour, h)
+	})
+}
+
+func TestDefaultDataFile(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/tmp/xdg")
+	path, err := DefaultDataFile()
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/xdg/study_hours_tracker/sessions.json", path)
+}
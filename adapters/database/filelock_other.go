@@ -0,0 +1,9 @@
+//go:build !unix
+
+package database
+
+// lockFile is a no-op where flock is unavailable; the store then only
+// serializes access within a single process.
+func lockFile(path string, exclusive bool) (func(), error) {
+	return func() {}, nil
+}
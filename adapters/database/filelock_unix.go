@@ -0,0 +1,32 @@
+//go:build unix
+
+package database
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an advisory flock on path, shared or exclusive, and returns
+// a function that releases it.
+func lockFile(path string, exclusive bool) (func(), error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %q: %w", path, err)
+	}
+
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %q: %w", path, err)
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,572 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+// sessionLog is the session history kept by the stores that do not use SQL.
+// Entries carry the user they belong to and every lookup is scoped to one
+// user. It is not safe for concurrent use; callers guard it.
+type sessionLog struct {
+	NextID   int64                   `json:"next_id"`
+	Sessions []domain.LoggedSession  `json:"sessions"`
+	Active   []domain.ActivePomodoro `json:"active,omitempty"`
+	Tokens   []domain.APIToken       `json:"tokens,omitempty"`
+	Goals    []domain.Goal           `json:"goals,omitempty"`
+}
+
+// storedDuration is a session duration as every store keeps it: in whole
+// seconds, like the duration_seconds column of the SQL stores.
+func storedDuration(d time.Duration) time.Duration {
+	return d.Truncate(time.Second)
+}
+
+func (l *sessionLog) logSession(session domain.LoggedSession) domain.LoggedSession {
+	l.NextID++
+	session.ID = l.NextID
+	session.Duration = storedDuration(session.Duration)
+	l.Sessions = append(l.Sessions, session)
+	return session
+}
+
+// logNewSessions logs the sessions of user that domain.NewSessions keeps and
+// returns them.
+func (l *sessionLog) logNewSessions(user domain.UserID, sessions []domain.LoggedSession) []domain.LoggedSession {
+	if len(sessions) == 0 {
+		return nil
+	}
+	fresh := domain.NewSessions(sessions, l.getAllSessions(user, domain.DuplicatesWithin(sessions)))
+	for i, session := range fresh {
+		session.UserID = user
+		fresh[i] = l.logSession(session)
+	}
+	return fresh
+}
+
+func (l *sessionLog) getHours(user domain.UserID, subject string) (time.Duration, error) {
+	var total time.Duration
+	found := false
+	for _, session := range l.Sessions {
+		if session.UserID == user && session.Subject == subject {
+			total += session.Duration
+			found = true
+		}
+	}
+	if !found {
+		return 0, domain.ErrSubjectNotFound
+	}
+	return total, nil
+}
+
+func (l *sessionLog) getSessions(user domain.UserID, subject string) []domain.LoggedSession {
+	sessions := make([]domain.LoggedSession, 0)
+	for _, session := range l.Sessions {
+		if session.UserID == user && session.Subject == subject {
+			sessions = append(sessions, session)
+		}
+	}
+	slices.SortStableFunc(sessions, func(a, b domain.LoggedSession) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+	return sessions
+}
+
+func (l *sessionLog) getAllSessions(user domain.UserID, period domain.TimeRange) []domain.LoggedSession {
+	sessions := make([]domain.LoggedSession, 0)
+	for _, session := range l.Sessions {
+		if session.UserID == user && period.Contains(session.StartedAt) {
+			sessions = append(sessions, session)
+		}
+	}
+	slices.SortStableFunc(sessions, func(a, b domain.LoggedSession) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+	return sessions
+}
+
+func (l *sessionLog) sessionIndex(user domain.UserID, id int64) int {
+	return slices.IndexFunc(l.Sessions, func(s domain.LoggedSession) bool { return s.UserID == user && s.ID == id })
+}
+
+func (l *sessionLog) getSession(user domain.UserID, id int64) (domain.LoggedSession, error) {
+	i := l.sessionIndex(user, id)
+	if i < 0 {
+		return domain.LoggedSession{}, fmt.Errorf("%w: %d", domain.ErrSessionNotFound, id)
+	}
+	return l.Sessions[i], nil
+}
+
+// getLastSession returns the session of user with the highest id, which is
+// the one logged last.
+func (l *sessionLog) getLastSession(user domain.UserID) (domain.LoggedSession, error) {
+	var last domain.LoggedSession
+	for _, session := range l.Sessions {
+		if session.UserID == user && session.ID > last.ID {
+			last = session
+		}
+	}
+	if last.ID == 0 {
+		return domain.LoggedSession{}, domain.ErrSessionNotFound
+	}
+	return last, nil
+}
+
+func (l *sessionLog) updateSession(user domain.UserID, session domain.LoggedSession) error {
+	i := l.sessionIndex(user, session.ID)
+	if i < 0 {
+		return fmt.Errorf("%w: %d", domain.ErrSessionNotFound, session.ID)
+	}
+	stored := &l.Sessions[i]
+	stored.Subject = session.Subject
+	stored.StartedAt = session.StartedAt
+	stored.EndedAt = session.EndedAt
+	stored.Duration = storedDuration(session.Duration)
+	return nil
+}
+
+func (l *sessionLog) deleteSession(user domain.UserID, id int64) error {
+	i := l.sessionIndex(user, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %d", domain.ErrSessionNotFound, id)
+	}
+	l.Sessions = slices.Delete(l.Sessions, i, i+1)
+	return nil
+}
+
+// deleteLastSession removes the session of user that getLastSession returns.
+func (l *sessionLog) deleteLastSession(user domain.UserID) (domain.LoggedSession, error) {
+	last, err := l.getLastSession(user)
+	if err != nil {
+		return domain.LoggedSession{}, err
+	}
+	return last, l.deleteSession(user, last.ID)
+}
+
+func (l *sessionLog) hasSubject(user domain.UserID, subject string) bool {
+	return slices.ContainsFunc(l.Sessions, func(s domain.LoggedSession) bool { return s.UserID == user && s.Subject == subject })
+}
+
+func (l *sessionLog) renameSubject(user domain.UserID, from, to string) (int, error) {
+	if !l.hasSubject(user, from) {
+		return 0, fmt.Errorf("%w: %s", domain.ErrSubjectNotFound, from)
+	}
+	if l.hasSubject(user, to) {
+		return 0, fmt.Errorf("%w: %s", domain.ErrSubjectExists, to)
+	}
+	return l.moveSubjects(user, to, []string{from}), nil
+}
+
+func (l *sessionLog) mergeSubjects(user domain.UserID, into string, from []string) (int, error) {
+	for _, subject := range from {
+		if !l.hasSubject(user, subject) {
+			return 0, fmt.Errorf("%w: %s", domain.ErrSubjectNotFound, subject)
+		}
+	}
+	return l.moveSubjects(user, into, from), nil
+}
+
+// moveSubjects gives the sessions of user in the subjects from the subject into.
+func (l *sessionLog) moveSubjects(user domain.UserID, into string, from []string) int {
+	moved := 0
+	for i, session := range l.Sessions {
+		if session.UserID == user && slices.Contains(from, session.Subject) {
+			l.Sessions[i].Subject = into
+			moved++
+		}
+	}
+	return moved
+}
+
+func (l *sessionLog) getReport(user domain.UserID, period domain.TimeRange) domain.Report {
+	totals := make(map[string]time.Duration)
+	for _, session := range l.Sessions {
+		if session.UserID == user && period.Contains(session.StartedAt) {
+			totals[session.Subject] += session.Duration
+		}
+	}
+
+	report := make(domain.Report, 0, len(totals))
+	for subject, duration := range totals {
+		report = append(report, domain.StudyActivity{Subject: subject, Duration: duration})
+	}
+	slices.SortFunc(report, func(a, b domain.StudyActivity) int {
+		if c := cmp.Compare(b.Duration, a.Duration); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Subject, b.Subject)
+	})
+	return report
+}
+
+func (l *sessionLog) saveActive(active domain.ActivePomodoro) {
+	i := slices.IndexFunc(l.Active, func(a domain.ActivePomodoro) bool { return a.UserID == active.UserID && a.ID == active.ID })
+	if i < 0 {
+		l.Active = append(l.Active, active)
+		return
+	}
+	l.Active[i] = active
+}
+
+func (l *sessionLog) deleteActive(user domain.UserID, id string) {
+	l.Active = slices.DeleteFunc(l.Active, func(a domain.ActivePomodoro) bool { return a.UserID == user && a.ID == id })
+}
+
+func (l *sessionLog) saveToken(token domain.APIToken) {
+	l.Tokens = append(l.Tokens, token)
+}
+
+func (l *sessionLog) getTokenByHash(hash string) (domain.APIToken, error) {
+	i := slices.IndexFunc(l.Tokens, func(t domain.APIToken) bool { return t.Hash == hash })
+	if i < 0 {
+		return domain.APIToken{}, domain.ErrTokenNotFound
+	}
+	return l.Tokens[i], nil
+}
+
+func (l *sessionLog) getTokens(user domain.UserID) []domain.APIToken {
+	tokens := make([]domain.APIToken, 0)
+	for _, token := range l.Tokens {
+		if token.UserID == user {
+			tokens = append(tokens, token)
+		}
+	}
+	slices.SortStableFunc(tokens, func(a, b domain.APIToken) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return tokens
+}
+
+func (l *sessionLog) deleteToken(user domain.UserID, id string) error {
+	n := len(l.Tokens)
+	l.Tokens = slices.DeleteFunc(l.Tokens, func(t domain.APIToken) bool { return t.UserID == user && t.ID == id })
+	if len(l.Tokens) == n {
+		return fmt.Errorf("%w: %s", domain.ErrTokenNotFound, id)
+	}
+	return nil
+}
+
+func (l *sessionLog) saveGoal(goal domain.Goal) {
+	i := slices.IndexFunc(l.Goals, func(g domain.Goal) bool {
+		return g.UserID == goal.UserID && g.Subject == goal.Subject && g.Period == goal.Period
+	})
+	if i < 0 {
+		l.Goals = append(l.Goals, goal)
+		return
+	}
+	l.Goals[i] = goal
+}
+
+func (l *sessionLog) getGoals(user domain.UserID) []domain.Goal {
+	goals := make([]domain.Goal, 0)
+	for _, goal := range l.Goals {
+		if goal.UserID == user {
+			goals = append(goals, goal)
+		}
+	}
+	slices.SortFunc(goals, func(a, b domain.Goal) int {
+		return cmp.Or(cmp.Compare(a.Subject, b.Subject), cmp.Compare(a.Period, b.Period))
+	})
+	return goals
+}
+
+func (l *sessionLog) deleteGoal(user domain.UserID, subject string, period domain.GoalPeriod) error {
+	n := len(l.Goals)
+	l.Goals = slices.DeleteFunc(l.Goals, func(g domain.Goal) bool {
+		return g.UserID == user && g.Subject == subject && g.Period == period
+	})
+	if len(l.Goals) == n {
+		return fmt.Errorf("%w: %s %s", domain.ErrGoalNotFound, period, subject)
+	}
+	return nil
+}
+
+// assignDefaultUser gives the entries written before there were users to
+// domain.DefaultUser.
+func (l *sessionLog) assignDefaultUser() {
+	for i := range l.Sessions {
+		l.Sessions[i].UserID = cmp.Or(l.Sessions[i].UserID, domain.DefaultUser)
+	}
+	for i := range l.Active {
+		l.Active[i].UserID = cmp.Or(l.Active[i].UserID, domain.DefaultUser)
+	}
+}
+
+func (l *sessionLog) getActive() []domain.ActivePomodoro {
+	active := slices.Clone(l.Active)
+	if active == nil {
+		active = make([]domain.ActivePomodoro, 0)
+	}
+	slices.SortStableFunc(active, func(a, b domain.ActivePomodoro) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+	return active
+}
+
+// InMemorySubjectStore keeps the session log in process memory. It is safe
+// for concurrent use and loses all data when the process exits.
+type InMemorySubjectStore struct {
+	mu  sync.RWMutex
+	log sessionLog
+}
+
+func NewInMemorySubjectStore() *InMemorySubjectStore {
+	return &InMemorySubjectStore{}
+}
+
+func (ms *InMemorySubjectStore) GetHours(ctx context.Context, subject string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.log.getHours(domain.UserFromContext(ctx), subject)
+}
+
+func (ms *InMemorySubjectStore) LogSession(ctx context.Context, session domain.LoggedSession) (domain.LoggedSession, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.LoggedSession{}, err
+	}
+	session.UserID = domain.UserFromContext(ctx)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.log.logSession(session), nil
+}
+
+func (ms *InMemorySubjectStore) LogSessions(ctx context.Context, sessions []domain.LoggedSession) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	user := domain.UserFromContext(ctx)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for _, session := range sessions {
+		session.UserID = user
+		ms.log.logSession(session)
+	}
+	return nil
+}
+
+func (ms *InMemorySubjectStore) LogNewSessions(ctx context.Context, sessions []domain.LoggedSession) ([]domain.LoggedSession, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.log.logNewSessions(domain.UserFromContext(ctx), sessions), nil
+}
+
+func (ms *InMemorySubjectStore) GetSessions(ctx context.Context, subject string) ([]domain.LoggedSession, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.log.getSessions(domain.UserFromContext(ctx), subject), nil
+}
+
+func (ms *InMemorySubjectStore) GetAllSessions(ctx context.Context, period domain.TimeRange) ([]domain.LoggedSession, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.log.getAllSessions(domain.UserFromContext(ctx), period), nil
+}
+
+// WalkSessions calls fn on a copy of the sessions, so that a slow fn does not
+// hold up recording.
+func (ms *InMemorySubjectStore) WalkSessions(ctx context.Context, period domain.TimeRange, fn func(domain.LoggedSession) error) error {
+	sessions, err := ms.GetAllSessions(ctx, period)
+	if err != nil {
+		return err
+	}
+	return walkSessions(ctx, sessions, fn)
+}
+
+// walkSessions calls fn with each of sessions until fn fails or ctx is done.
+func walkSessions(ctx context.Context, sessions []domain.LoggedSession, fn func(domain.LoggedSession) error) error {
+	for _, session := range sessions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *InMemorySubjectStore) GetSession(ctx context.Context, id int64) (domain.LoggedSession, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.LoggedSession{}, err
+	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.log.getSession(domain.UserFromContext(ctx), id)
+}
+
+func (ms *InMemorySubjectStore) GetLastSession(ctx context.Context) (domain.LoggedSession, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.LoggedSession{}, err
+	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.log.getLastSession(domain.UserFromContext(ctx))
+}
+
+func (ms *InMemorySubjectStore) UpdateSession(ctx context.Context, session domain.LoggedSession) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.log.updateSession(domain.UserFromContext(ctx), session)
+}
+
+func (ms *InMemorySubjectStore) DeleteSession(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.log.deleteSession(domain.UserFromContext(ctx), id)
+}
+
+func (ms *InMemorySubjectStore) DeleteLastSession(ctx context.Context) (domain.LoggedSession, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.LoggedSession{}, err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.log.deleteLastSession(domain.UserFromContext(ctx))
+}
+
+func (ms *InMemorySubjectStore) GetReport(ctx context.Context, period domain.TimeRange) (domain.Report, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.log.getReport(domain.UserFromContext(ctx), period), nil
+}
+
+func (ms *InMemorySubjectStore) RenameSubject(ctx context.Context, from, to string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.log.renameSubject(domain.UserFromContext(ctx), from, to)
+}
+
+func (ms *InMemorySubjectStore) MergeSubjects(ctx context.Context, into string, from []string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.log.mergeSubjects(domain.UserFromContext(ctx), into, from)
+}
+
+func (ms *InMemorySubjectStore) SaveActivePomodoro(ctx context.Context, active domain.ActivePomodoro) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	active.UserID = domain.UserFromContext(ctx)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.log.saveActive(active)
+	return nil
+}
+
+func (ms *InMemorySubjectStore) DeleteActivePomodoro(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.log.deleteActive(domain.UserFromContext(ctx), id)
+	return nil
+}
+
+func (ms *InMemorySubjectStore) GetActivePomodoros(ctx context.Context) ([]domain.ActivePomodoro, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.log.getActive(), nil
+}
+
+func (ms *InMemorySubjectStore) SaveToken(ctx context.Context, token domain.APIToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.log.saveToken(token)
+	return nil
+}
+
+func (ms *InMemorySubjectStore) GetTokenByHash(ctx context.Context, hash string) (domain.APIToken, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.APIToken{}, err
+	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.log.getTokenByHash(hash)
+}
+
+func (ms *InMemorySubjectStore) GetTokens(ctx context.Context) ([]domain.APIToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.log.getTokens(domain.UserFromContext(ctx)), nil
+}
+
+func (ms *InMemorySubjectStore) DeleteToken(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.log.deleteToken(domain.UserFromContext(ctx), id)
+}
+
+func (ms *InMemorySubjectStore) SaveGoal(ctx context.Context, goal domain.Goal) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	goal.UserID = domain.UserFromContext(ctx)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.log.saveGoal(goal)
+	return nil
+}
+
+func (ms *InMemorySubjectStore) GetGoals(ctx context.Context) ([]domain.Goal, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.log.getGoals(domain.UserFromContext(ctx)), nil
+}
+
+func (ms *InMemorySubjectStore) DeleteGoal(ctx context.Context, subject string, period domain.GoalPeriod) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.log.deleteGoal(domain.UserFromContext(ctx), subject, period)
+}
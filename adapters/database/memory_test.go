@@ -0,0 +1,16 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/bryack/study_hours_tracker/testhelpers/storetest"
+)
+
+func TestInMemorySubjectStore(t *testing.T) {
+	storetest.SubjectStoreContract{
+		NewStore: func(t testing.TB) domain.SubjectStore {
+			return NewInMemorySubjectStore()
+		},
+	}.Test(t)
+}
@@ -0,0 +1,253 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+const (
+	createMigrationsTableQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);`
+	selectAppliedVersionsQuery = "SELECT version FROM schema_migrations ORDER BY version"
+	insertMigrationQuery       = "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)"
+	deleteMigrationQuery       = "DELETE FROM schema_migrations WHERE version = $1"
+	// migrationLockID keys the advisory lock that keeps concurrently starting
+	// processes from applying the same migration twice.
+	migrationLockID = 7_406_231
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+var ErrNoMigration = errors.New("no migration to roll back")
+
+// migration is one versioned schema change together with its rollback.
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus reports whether a known migration has been applied.
+type MigrationStatus struct {
+	Version int
+	Name    string
+	Applied bool
+}
+
+// Migrator applies the embedded SQL migrations in version order and records
+// them in the schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	migrations []migration
+}
+
+// NewMigrator creates a migrator for the embedded migrations.
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// OpenMigrator connects to connStr without touching the schema.
+func OpenMigrator(ctx context.Context, connStr string) (*Migrator, error) {
+	db, err := openDatabase(ctx, connStr)
+	if err != nil {
+		return nil, err
+	}
+	m, err := NewMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// Close closes the underlying database connection.
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// Up applies every pending migration and returns the resulting schema version.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	version := 0
+	err := m.withLock(ctx, func(conn *sql.Conn, applied map[int]bool) error {
+		for _, mg := range m.migrations {
+			if applied[mg.version] {
+				version = mg.version
+				continue
+			}
+			if err := runMigration(ctx, conn, mg.up, insertMigrationQuery, mg.version, mg.name); err != nil {
+				return fmt.Errorf("failed to apply migration %04d_%s: %w", mg.version, mg.name, err)
+			}
+			version = mg.version
+		}
+		return nil
+	})
+	return version, err
+}
+
+// Down rolls back the given number of most recently applied migrations and
+// returns the resulting schema version.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	version := 0
+	err := m.withLock(ctx, func(conn *sql.Conn, applied map[int]bool) error {
+		if len(applied) == 0 {
+			return ErrNoMigration
+		}
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			mg := m.migrations[i]
+			if !applied[mg.version] {
+				continue
+			}
+			if err := runMigration(ctx, conn, mg.down, deleteMigrationQuery, mg.version); err != nil {
+				return fmt.Errorf("failed to roll back migration %04d_%s: %w", mg.version, mg.name, err)
+			}
+			delete(applied, mg.version)
+			steps--
+		}
+		for v := range applied {
+			version = max(version, v)
+		}
+		return nil
+	})
+	return version, err
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *sql.Conn, applied map[int]bool) error {
+		for _, mg := range m.migrations {
+			statuses = append(statuses, MigrationStatus{Version: mg.version, Name: mg.name, Applied: applied[mg.version]})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn, applied map[int]bool) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	if _, err := conn.ExecContext(ctx, createMigrationsTableQuery); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]bool, error) {
+	rows, err := conn.QueryContext(ctx, selectAppliedVersionsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+	return applied, nil
+}
+
+// runMigration executes the migration script and its bookkeeping statement
+// in one transaction.
+func runMigration(ctx context.Context, conn *sql.Conn, script, bookkeeping string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+	return tx.Commit()
+}
+
+// loadMigrations reads NNNN_name.up.sql / NNNN_name.down.sql pairs from fsys.
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	paths, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, p := range paths {
+		match := migrationFileName.FindStringSubmatch(path.Base(p))
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", p)
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", p, err)
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", p, err)
+		}
+
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &migration{version: version, name: match[2]}
+			byVersion[version] = mg
+		}
+		if mg.name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, mg.name, match[2])
+		}
+		if match[3] == "up" {
+			mg.up = string(content)
+		} else {
+			mg.down = string(content)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.up == "" || mg.down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down files", mg.version, mg.name)
+		}
+		migrations = append(migrations, *mg)
+	}
+	slices.SortFunc(migrations, func(a, b migration) int { return a.version - b.version })
+	return migrations, nil
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("orders migrations by version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"migrations/0010_add_index.up.sql":       {Data: []byte("CREATE INDEX")},
+			"migrations/0010_add_index.down.sql":     {Data: []byte("DROP INDEX")},
+			"migrations/0002_create_table.up.sql":    {Data: []byte("CREATE TABLE")},
+			"migrations/0002_create_table.down.sql":  {Data: []byte("DROP TABLE")},
+			"migrations/0001_create_schema.up.sql":   {Data: []byte("CREATE SCHEMA")},
+			"migrations/0001_create_schema.down.sql": {Data: []byte("DROP SCHEMA")},
+		}
+
+		migrations, err := loadMigrations(fsys)
+		require.NoError(t, err)
+
+		assert.Equal(t, []migration{
+			{version: 1, name: "create_schema", up: "CREATE SCHEMA", down: "DROP SCHEMA"},
+			{version: 2, name: "create_table", up: "CREATE TABLE", down: "DROP TABLE"},
+			{version: 10, name: "add_index", up: "CREATE INDEX", down: "DROP INDEX"},
+		}, migrations)
+	})
+
+	t.Run("requires a down file for every up file", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"migrations/0001_create_table.up.sql": {Data: []byte("CREATE TABLE")},
+		}
+
+		_, err := loadMigrations(fsys)
+		assert.ErrorContains(t, err, "needs both up and down")
+	})
+
+	t.Run("rejects invalid file names", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"migrations/create_table.sql": {Data: []byte("CREATE TABLE")},
+		}
+
+		_, err := loadMigrations(fsys)
+		assert.ErrorContains(t, err, "invalid migration file name")
+	})
+
+	t.Run("rejects conflicting names for one version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"migrations/0001_create_table.up.sql": {Data: []byte("CREATE TABLE")},
+			"migrations/0001_drop_table.down.sql": {Data: []byte("DROP TABLE")},
+		}
+
+		_, err := loadMigrations(fsys)
+		assert.ErrorContains(t, err, "conflicting names")
+	})
+
+	t.Run("embedded migrations are valid", func(t *testing.T) {
+		migrations, err := loadMigrations(migrationFiles)
+		require.NoError(t, err)
+		assert.NotEmpty(t, migrations)
+		for i, mg := range migrations {
+			assert.Equal(t, i+1, mg.version, "versions should be consecutive")
+		}
+	})
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := t.Context()
+	connStr := testhelpers.SetupTestContainer(t)
+	m, err := OpenMigrator(ctx, connStr)
+	require.NoError(t, err)
+	t.Cleanup(func() { m.Close() })
+
+	latest := m.migrations[len(m.migrations)-1].version
+
+	version, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+
+	version, err = m.Up(ctx)
+	require.NoError(t, err, "applying twice should be a no-op")
+	assert.Equal(t, latest, version)
+
+	version, err = m.Down(ctx, latest)
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	var sessionsTable *string
+	require.NoError(t, m.db.QueryRow("SELECT to_regclass('sessions')::text").Scan(&sessionsTable))
+	assert.Nil(t, sessionsTable, "sessions table should be dropped")
+
+	_, err = m.Down(ctx, 1)
+	assert.ErrorIs(t, err, ErrNoMigration)
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.False(t, s.Applied)
+	}
+
+	version, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+}
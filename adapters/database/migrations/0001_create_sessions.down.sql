@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS sessions;
+
+DO $$
+BEGIN
+	IF to_regclass('subjects_legacy') IS NOT NULL THEN
+		ALTER TABLE subjects_legacy RENAME TO subjects;
+	END IF;
+END $$;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS sessions (
+	id BIGSERIAL PRIMARY KEY,
+	subject TEXT NOT NULL,
+	started_at TIMESTAMPTZ NOT NULL,
+	ended_at TIMESTAMPTZ NOT NULL,
+	duration_seconds BIGINT NOT NULL CHECK (duration_seconds > 0),
+	source TEXT NOT NULL CHECK (source IN ('manual', 'pomodoro'))
+);
+
+CREATE INDEX IF NOT EXISTS sessions_subject_idx ON sessions (subject);
+
+-- Move the cumulative hours of the old subjects table into the session log
+-- once and keep the old table around as subjects_legacy.
+DO $$
+BEGIN
+	IF to_regclass('subjects') IS NOT NULL THEN
+		INSERT INTO sessions (subject, started_at, ended_at, duration_seconds, source)
+		SELECT subject, NOW() - make_interval(hours => hours), NOW(), hours * 3600, 'manual'
+		FROM subjects
+		WHERE hours > 0;
+		ALTER TABLE subjects RENAME TO subjects_legacy;
+	END IF;
+END $$;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS sessions_started_at_idx;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS sessions_started_at_idx ON sessions (started_at);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS active_pomodoros (
+	id TEXT PRIMARY KEY,
+	subject TEXT NOT NULL,
+	started_at TIMESTAMPTZ NOT NULL,
+	ends_at TIMESTAMPTZ NOT NULL,
+	paused_at TIMESTAMPTZ,
+	focus_seconds BIGINT NOT NULL CHECK (focus_seconds > 0)
+);
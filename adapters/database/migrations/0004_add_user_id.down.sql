@@ -0,0 +1,13 @@
+-- Sessions of other users than the default one are dropped.
+DELETE FROM sessions WHERE user_id <> 'default';
+DELETE FROM active_pomodoros WHERE user_id <> 'default';
+
+ALTER TABLE active_pomodoros DROP CONSTRAINT IF EXISTS active_pomodoros_pkey;
+ALTER TABLE active_pomodoros DROP COLUMN IF EXISTS user_id;
+ALTER TABLE active_pomodoros ADD PRIMARY KEY (id);
+
+DROP INDEX IF EXISTS sessions_user_subject_idx;
+DROP INDEX IF EXISTS sessions_user_started_at_idx;
+ALTER TABLE sessions DROP COLUMN IF EXISTS user_id;
+CREATE INDEX IF NOT EXISTS sessions_subject_idx ON sessions (subject);
+CREATE INDEX IF NOT EXISTS sessions_started_at_idx ON sessions (started_at);
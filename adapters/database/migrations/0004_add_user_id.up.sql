@@ -0,0 +1,11 @@
+-- Sessions recorded before there were users belong to the default user.
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS user_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE active_pomodoros ADD COLUMN IF NOT EXISTS user_id TEXT NOT NULL DEFAULT 'default';
+
+DROP INDEX IF EXISTS sessions_subject_idx;
+DROP INDEX IF EXISTS sessions_started_at_idx;
+CREATE INDEX IF NOT EXISTS sessions_user_subject_idx ON sessions (user_id, subject);
+CREATE INDEX IF NOT EXISTS sessions_user_started_at_idx ON sessions (user_id, started_at);
+
+ALTER TABLE active_pomodoros DROP CONSTRAINT IF EXISTS active_pomodoros_pkey;
+ALTER TABLE active_pomodoros ADD PRIMARY KEY (user_id, id);
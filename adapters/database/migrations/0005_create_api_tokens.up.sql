@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS api_tokens (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	name TEXT NOT NULL DEFAULT '',
+	scope TEXT NOT NULL CHECK (scope IN ('read', 'record')),
+	hash TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS api_tokens_user_idx ON api_tokens (user_id);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS goals (
+	user_id TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	period TEXT NOT NULL CHECK (period IN ('daily', 'weekly', 'monthly')),
+	target_seconds BIGINT NOT NULL CHECK (target_seconds > 0),
+	PRIMARY KEY (user_id, subject, period)
+);
@@ -0,0 +1,4 @@
+-- Imported sessions are kept as manual ones.
+UPDATE sessions SET source = 'manual' WHERE source = 'import';
+ALTER TABLE sessions DROP CONSTRAINT IF EXISTS sessions_source_check;
+ALTER TABLE sessions ADD CONSTRAINT sessions_source_check CHECK (source IN ('manual', 'pomodoro'));
@@ -0,0 +1,3 @@
+-- Sessions can be imported from other trackers.
+ALTER TABLE sessions DROP CONSTRAINT IF EXISTS sessions_source_check;
+ALTER TABLE sessions ADD CONSTRAINT sessions_source_check CHECK (source IN ('manual', 'pomodoro', 'import'));
@@ -0,0 +1,4 @@
+-- Cycles in progress cannot be kept without their round.
+DELETE FROM active_pomodoros WHERE rounds > 0;
+ALTER TABLE active_pomodoros DROP COLUMN IF EXISTS rounds;
+ALTER TABLE active_pomodoros DROP COLUMN IF EXISTS round;
@@ -0,0 +1,3 @@
+-- Cycles in progress are kept with the round they are in.
+ALTER TABLE active_pomodoros ADD COLUMN IF NOT EXISTS round INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE active_pomodoros ADD COLUMN IF NOT EXISTS rounds INTEGER NOT NULL DEFAULT 0;
@@ -1,99 +1,346 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
 
 	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
 const (
-	createTableQuery = `CREATE TABLE IF NOT EXISTS subjects (
-	id SERIAL PRIMARY KEY,
-	subject TEXT NOT NULL UNIQUE,
-	hours INTEGER NOT NULL DEFAULT 0
-	);`
-	selectHoursQuery = "SELECT hours FROM subjects WHERE subject = $1"
-	insertHoursQuery = `INSERT INTO subjects (subject, hours) 
-	VALUES ($1, $2)
-	ON CONFLICT (subject)
-	DO UPDATE SET hours = subjects.hours + EXCLUDED.hours`
-	selectReportQuery = "SELECT subject, hours FROM subjects ORDER BY hours DESC"
-	driverName        = "pgx"
+	selectHoursQuery = `SELECT SUM(duration_seconds) FROM sessions
+	WHERE user_id = $1 AND subject = $2
+	GROUP BY subject`
+	insertSessionQuery = `INSERT INTO sessions (user_id, subject, started_at, ended_at, duration_seconds, source)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+	insertSessionReturningIDQuery = insertSessionQuery + `
+	RETURNING id`
+	selectSessionsQuery = `SELECT id, user_id, subject, started_at, ended_at, duration_seconds, source FROM sessions
+	WHERE user_id = $1 AND subject = $2
+	ORDER BY started_at, id`
+	selectAllSessionsQuery = `SELECT id, user_id, subject, started_at, ended_at, duration_seconds, source FROM sessions
+	WHERE user_id = $1
+	AND ($2::timestamptz IS NULL OR started_at >= $2)
+	AND ($3::timestamptz IS NULL OR started_at < $3)
+	ORDER BY started_at, id`
+	selectSessionQuery = `SELECT id, user_id, subject, started_at, ended_at, duration_seconds, source FROM sessions
+	WHERE user_id = $1 AND id = $2`
+	selectLastSessionQuery = `SELECT id, user_id, subject, started_at, ended_at, duration_seconds, source FROM sessions
+	WHERE user_id = $1
+	ORDER BY id DESC
+	LIMIT 1`
+	updateSessionQuery = `UPDATE sessions SET subject = $3, started_at = $4, ended_at = $5, duration_seconds = $6
+	WHERE user_id = $1 AND id = $2`
+	deleteSessionQuery     = `DELETE FROM sessions WHERE user_id = $1 AND id = $2`
+	deleteLastSessionQuery = `DELETE FROM sessions
+	WHERE id = (SELECT id FROM sessions WHERE user_id = $1 ORDER BY id DESC LIMIT 1)
+	RETURNING id, user_id, subject, started_at, ended_at, duration_seconds, source`
+	selectSubjectsInQuery = `SELECT DISTINCT subject FROM sessions
+	WHERE user_id = $1 AND subject = ANY($2)`
+	moveSubjectsQuery = `UPDATE sessions SET subject = $2
+	WHERE user_id = $1 AND subject = ANY($3)`
+	selectReportQuery = `SELECT subject, SUM(duration_seconds) AS total FROM sessions
+	WHERE user_id = $1
+	AND ($2::timestamptz IS NULL OR started_at >= $2)
+	AND ($3::timestamptz IS NULL OR started_at < $3)
+	GROUP BY subject
+	ORDER BY total DESC, subject`
+	upsertActivePomodoroQuery = `INSERT INTO active_pomodoros (user_id, id, subject, started_at, ends_at, paused_at, focus_seconds, round, rounds)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (user_id, id) DO UPDATE SET subject = $3, started_at = $4, ends_at = $5, paused_at = $6, focus_seconds = $7, round = $8, rounds = $9`
+	deleteActivePomodoroQuery  = `DELETE FROM active_pomodoros WHERE user_id = $1 AND id = $2`
+	selectActivePomodorosQuery = `SELECT id, user_id, subject, started_at, ends_at, paused_at, focus_seconds, round, rounds FROM active_pomodoros
+	ORDER BY started_at, user_id, id`
+	insertTokenQuery = `INSERT INTO api_tokens (id, user_id, name, scope, hash, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+	selectTokenByHashQuery = `SELECT id, user_id, name, scope, hash, created_at FROM api_tokens
+	WHERE hash = $1`
+	selectTokensQuery = `SELECT id, user_id, name, scope, hash, created_at FROM api_tokens
+	WHERE user_id = $1
+	ORDER BY created_at, id`
+	deleteTokenQuery = `DELETE FROM api_tokens WHERE user_id = $1 AND id = $2`
+	upsertGoalQuery  = `INSERT INTO goals (user_id, subject, period, target_seconds)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (user_id, subject, period) DO UPDATE SET target_seconds = $4`
+	selectGoalsQuery = `SELECT user_id, subject, period, target_seconds FROM goals
+	WHERE user_id = $1
+	ORDER BY subject, period`
+	deleteGoalQuery = `DELETE FROM goals WHERE user_id = $1 AND subject = $2 AND period = $3`
+	driverName      = "pgx"
 )
 
 type PostgresSubjectStore struct {
 	db *sql.DB
 }
 
-func NewPostgresSubjectStore(connStr string) (*PostgresSubjectStore, error) {
+func NewPostgresSubjectStore(ctx context.Context, connStr string) (*PostgresSubjectStore, error) {
 	store := &PostgresSubjectStore{}
-	if err := store.initDatabase(connStr); err != nil {
+	if err := store.initDatabase(ctx, connStr); err != nil {
 		return nil, fmt.Errorf("failed to init DB: %w", err)
 	}
-	if err := store.createTable(); err != nil {
+	if err := store.migrate(ctx); err != nil {
 		store.db.Close()
-		return nil, fmt.Errorf("failed to create DB: %w", err)
+		return nil, fmt.Errorf("failed to migrate DB: %w", err)
 	}
 	return store, nil
 }
 
-func (ps *PostgresSubjectStore) initDatabase(connStr string) error {
-	db, err := sql.Open(driverName, connStr)
+func (ps *PostgresSubjectStore) initDatabase(ctx context.Context, connStr string) error {
+	db, err := openDatabase(ctx, connStr)
 	if err != nil {
-		return fmt.Errorf("failed to open DB with %q: %w", connStr, err)
+		return err
 	}
 	ps.db = db
+	return nil
+}
 
-	if err := db.Ping(); err != nil {
-		return fmt.Errorf("failed to ping db: %w", err)
+// migrate brings the schema up to the latest embedded migration.
+func (ps *PostgresSubjectStore) migrate(ctx context.Context) error {
+	m, err := NewMigrator(ps.db)
+	if err != nil {
+		return err
+	}
+	if _, err := m.Up(ctx); err != nil {
+		return err
 	}
-
 	return nil
 }
 
-func (ps *PostgresSubjectStore) createTable() error {
-	if _, err := ps.db.Exec(createTableQuery); err != nil {
-		return fmt.Errorf("failed to create table: %w", err)
+func openDatabase(ctx context.Context, connStr string) (*sql.DB, error) {
+	db, err := sql.Open(driverName, connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DB with %q: %w", connStr, err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping db: %w", err)
 	}
-	return nil
+
+	return db, nil
 }
 
-func (ps *PostgresSubjectStore) GetHours(subject string) (int, error) {
-	var hours int
-	err := ps.db.QueryRow(selectHoursQuery, subject).Scan(&hours)
+func (ps *PostgresSubjectStore) GetHours(ctx context.Context, subject string) (time.Duration, error) {
+	var seconds int64
+	err := ps.db.QueryRowContext(ctx, selectHoursQuery, domain.UserFromContext(ctx), subject).Scan(&seconds)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return 0, domain.ErrSubjectNotFound
 		}
 		return 0, fmt.Errorf("failed to make DB query for subject %s: %w", subject, err)
 	}
-	return hours, nil
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func (ps *PostgresSubjectStore) LogSession(ctx context.Context, session domain.LoggedSession) (domain.LoggedSession, error) {
+	session.UserID = domain.UserFromContext(ctx)
+	session.Duration = storedDuration(session.Duration)
+	seconds := int64(session.Duration / time.Second)
+	if err := ps.db.QueryRowContext(ctx, insertSessionReturningIDQuery, session.UserID, session.Subject, session.StartedAt, session.EndedAt, seconds, string(session.Source)).Scan(&session.ID); err != nil {
+		return domain.LoggedSession{}, fmt.Errorf("failed to insert session for %s: %w", session.Subject, err)
+	}
+	return session, nil
+}
+
+func (ps *PostgresSubjectStore) LogSessions(ctx context.Context, sessions []domain.LoggedSession) error {
+	user := domain.UserFromContext(ctx)
+	return ps.inTx(ctx, func(tx *sql.Tx) error {
+		return insertSessions(ctx, tx, user, sessions)
+	})
+}
+
+// LogNewSessions reads the sessions that may be duplicated and inserts the
+// rest in one serializable transaction. A concurrent import of the same
+// sessions makes it fail to serialize, and inTx then retries it against the
+// sessions the other one logged.
+func (ps *PostgresSubjectStore) LogNewSessions(ctx context.Context, sessions []domain.LoggedSession) ([]domain.LoggedSession, error) {
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	user := domain.UserFromContext(ctx)
+	period := domain.DuplicatesWithin(sessions)
+	var fresh []domain.LoggedSession
+	err := ps.inTx(ctx, func(tx *sql.Tx) error {
+		logged, err := querySessions(ctx, tx, selectAllSessionsQuery, user, nullableTime(period.From), nullableTime(period.To))
+		if err != nil {
+			return err
+		}
+		fresh = domain.NewSessions(sessions, logged)
+		return insertSessions(ctx, tx, user, fresh)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range fresh {
+		fresh[i].Duration = storedDuration(fresh[i].Duration)
+	}
+	return fresh, nil
+}
+
+// insertSessions inserts sessions for user within tx.
+func insertSessions(ctx context.Context, tx *sql.Tx, user domain.UserID, sessions []domain.LoggedSession) error {
+	stmt, err := tx.PrepareContext(ctx, insertSessionQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prepare session insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, session := range sessions {
+		seconds := int64(session.Duration / time.Second)
+		if _, err := stmt.ExecContext(ctx, user, session.Subject, session.StartedAt, session.EndedAt, seconds, string(session.Source)); err != nil {
+			return fmt.Errorf("failed to insert session for %s: %w", session.Subject, err)
+		}
+	}
+	return nil
+}
+
+func (ps *PostgresSubjectStore) GetSessions(ctx context.Context, subject string) ([]domain.LoggedSession, error) {
+	return querySessions(ctx, ps.db, selectSessionsQuery, domain.UserFromContext(ctx), subject)
+}
+
+func (ps *PostgresSubjectStore) GetAllSessions(ctx context.Context, period domain.TimeRange) ([]domain.LoggedSession, error) {
+	return querySessions(ctx, ps.db, selectAllSessionsQuery, domain.UserFromContext(ctx), nullableTime(period.From), nullableTime(period.To))
+}
+
+// WalkSessions reads the sessions row by row, so that large histories stream
+// instead of being loaded at once.
+func (ps *PostgresSubjectStore) WalkSessions(ctx context.Context, period domain.TimeRange, fn func(domain.LoggedSession) error) error {
+	return walkSessionRows(ctx, ps.db, fn, selectAllSessionsQuery, domain.UserFromContext(ctx), nullableTime(period.From), nullableTime(period.To))
+}
+
+// queryer runs queries on the database or within a transaction.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// querySessions runs a query selecting the columns of selectSessionsQuery.
+func querySessions(ctx context.Context, q queryer, query string, args ...any) ([]domain.LoggedSession, error) {
+	sessions := make([]domain.LoggedSession, 0)
+	err := walkSessionRows(ctx, q, func(ls domain.LoggedSession) error {
+		sessions = append(sessions, ls)
+		return nil
+	}, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// walkSessionRows runs a query selecting the columns of selectSessionsQuery and
+// calls fn with each session as it is scanned.
+func walkSessionRows(ctx context.Context, q queryer, fn func(domain.LoggedSession) error, query string, args ...any) error {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to make query from sessions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		ls, err := scanSession(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := fn(ls); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return nil
+}
+
+func (ps *PostgresSubjectStore) GetSession(ctx context.Context, id int64) (domain.LoggedSession, error) {
+	session, err := scanSession(ps.db.QueryRowContext(ctx, selectSessionQuery, domain.UserFromContext(ctx), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.LoggedSession{}, fmt.Errorf("%w: %d", domain.ErrSessionNotFound, id)
+	}
+	if err != nil {
+		return domain.LoggedSession{}, fmt.Errorf("failed to make query from sessions: %w", err)
+	}
+	return session, nil
+}
+
+// GetLastSession returns the session with the highest id, which is the one logged last.
+func (ps *PostgresSubjectStore) GetLastSession(ctx context.Context) (domain.LoggedSession, error) {
+	session, err := scanSession(ps.db.QueryRowContext(ctx, selectLastSessionQuery, domain.UserFromContext(ctx)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.LoggedSession{}, domain.ErrSessionNotFound
+	}
+	if err != nil {
+		return domain.LoggedSession{}, fmt.Errorf("failed to make query from sessions: %w", err)
+	}
+	return session, nil
+}
+
+func (ps *PostgresSubjectStore) UpdateSession(ctx context.Context, session domain.LoggedSession) error {
+	seconds := int64(session.Duration / time.Second)
+	result, err := ps.db.ExecContext(ctx, updateSessionQuery, domain.UserFromContext(ctx), session.ID, session.Subject, session.StartedAt, session.EndedAt, seconds)
+	if err != nil {
+		return fmt.Errorf("failed to update session %d: %w", session.ID, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %d", domain.ErrSessionNotFound, session.ID)
+	}
+	return nil
 }
 
-func (ps *PostgresSubjectStore) RecordHour(subject string, numHours int) error {
-	if _, err := ps.db.Exec(insertHoursQuery, subject, numHours); err != nil {
-		return fmt.Errorf("failed to insert %s: %w", subject, err)
+func (ps *PostgresSubjectStore) DeleteSession(ctx context.Context, id int64) error {
+	result, err := ps.db.ExecContext(ctx, deleteSessionQuery, domain.UserFromContext(ctx), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete session %d: %w", id, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %d", domain.ErrSessionNotFound, id)
 	}
 	return nil
 }
 
-func (ps *PostgresSubjectStore) GetReport() (domain.Report, error) {
-	rows, err := ps.db.Query(selectReportQuery)
+// DeleteLastSession finds and deletes the session with the highest id in one
+// statement. A concurrent undo that took the same session makes the
+// transaction fail to serialize, and inTx then takes the next one.
+func (ps *PostgresSubjectStore) DeleteLastSession(ctx context.Context) (domain.LoggedSession, error) {
+	var session domain.LoggedSession
+	err := ps.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		session, err = scanSession(tx.QueryRowContext(ctx, deleteLastSessionQuery, domain.UserFromContext(ctx)))
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrSessionNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete last session: %w", err)
+		}
+		return nil
+	})
+	return session, err
+}
+
+// GetReport sums up the sessions started within the period per subject.
+func (ps *PostgresSubjectStore) GetReport(ctx context.Context, period domain.TimeRange) (domain.Report, error) {
+	rows, err := ps.db.QueryContext(ctx, selectReportQuery, domain.UserFromContext(ctx), nullableTime(period.From), nullableTime(period.To))
 	if err != nil {
-		return nil, fmt.Errorf("failed to make query from subjects: %w", err)
+		return nil, fmt.Errorf("failed to make query from sessions: %w", err)
 	}
 	defer rows.Close()
 
 	report := make(domain.Report, 0)
 	for rows.Next() {
 		var sa domain.StudyActivity
-		err = rows.Scan(&sa.Subject, &sa.Hours)
+		var seconds int64
+		err = rows.Scan(&sa.Subject, &seconds)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
+		sa.Duration = time.Duration(seconds) * time.Second
 		report = append(report, sa)
 	}
 
@@ -103,3 +350,302 @@ func (ps *PostgresSubjectStore) GetReport() (domain.Report, error) {
 
 	return report, nil
 }
+
+// RenameSubject checks and moves in one serializable transaction, so that no
+// session of the new name can slip in between.
+func (ps *PostgresSubjectStore) RenameSubject(ctx context.Context, from, to string) (int, error) {
+	var moved int
+	err := ps.inTx(ctx, func(tx *sql.Tx) error {
+		existing, err := existingSubjects(ctx, tx, []string{from, to})
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(existing, from) {
+			return fmt.Errorf("%w: %s", domain.ErrSubjectNotFound, from)
+		}
+		if slices.Contains(existing, to) {
+			return fmt.Errorf("%w: %s", domain.ErrSubjectExists, to)
+		}
+		moved, err = moveSubjects(ctx, tx, to, []string{from})
+		return err
+	})
+	return moved, err
+}
+
+func (ps *PostgresSubjectStore) MergeSubjects(ctx context.Context, into string, from []string) (int, error) {
+	var moved int
+	err := ps.inTx(ctx, func(tx *sql.Tx) error {
+		existing, err := existingSubjects(ctx, tx, from)
+		if err != nil {
+			return err
+		}
+		for _, subject := range from {
+			if !slices.Contains(existing, subject) {
+				return fmt.Errorf("%w: %s", domain.ErrSubjectNotFound, subject)
+			}
+		}
+		moved, err = moveSubjects(ctx, tx, into, from)
+		return err
+	})
+	return moved, err
+}
+
+const (
+	// maxTxAttempts bounds how often inTx runs a transaction that conflicted
+	// with a concurrent one.
+	maxTxAttempts = 5
+	// txRetryDelay is the wait before the second attempt; later attempts wait longer.
+	txRetryDelay = 10 * time.Millisecond
+
+	// SQLSTATE codes of transactions that lost against a concurrent one.
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+)
+
+// inTx runs fn in a serializable transaction and commits it if fn succeeds.
+// When the transaction fails to serialize or deadlocks against a concurrent
+// one, it is run again from the start, so fn must not have effects outside it.
+func (ps *PostgresSubjectStore) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	for attempt := 1; ; attempt++ {
+		err := ps.tryTx(ctx, fn)
+		if attempt == maxTxAttempts || !isTxConflict(err) {
+			return err
+		}
+		delay := time.Duration(attempt)*txRetryDelay + rand.N(txRetryDelay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (ps *PostgresSubjectStore) tryTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := ps.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isTxConflict reports whether err is a serialization failure or a deadlock,
+// after which the transaction can succeed when run again.
+func isTxConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == serializationFailureCode || pgErr.Code == deadlockDetectedCode
+}
+
+// existingSubjects returns which of subjects the user of ctx has sessions in.
+func existingSubjects(ctx context.Context, tx *sql.Tx, subjects []string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, selectSubjectsInQuery, domain.UserFromContext(ctx), subjects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make query from sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var existing []string
+	for rows.Next() {
+		var subject string
+		if err := rows.Scan(&subject); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		existing = append(existing, subject)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+	return existing, nil
+}
+
+func moveSubjects(ctx context.Context, tx *sql.Tx, into string, from []string) (int, error) {
+	result, err := tx.ExecContext(ctx, moveSubjectsQuery, domain.UserFromContext(ctx), into, from)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move sessions to %s: %w", into, err)
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count moved sessions: %w", err)
+	}
+	return int(moved), nil
+}
+
+func (ps *PostgresSubjectStore) SaveActivePomodoro(ctx context.Context, active domain.ActivePomodoro) error {
+	seconds := int64(active.Focus / time.Second)
+	_, err := ps.db.ExecContext(ctx, upsertActivePomodoroQuery, domain.UserFromContext(ctx), active.ID, active.Subject, active.StartedAt, active.EndsAt, nullableTime(active.PausedAt), seconds, active.Round, active.Rounds)
+	if err != nil {
+		return fmt.Errorf("failed to save active pomodoro %s: %w", active.ID, err)
+	}
+	return nil
+}
+
+func (ps *PostgresSubjectStore) DeleteActivePomodoro(ctx context.Context, id string) error {
+	if _, err := ps.db.ExecContext(ctx, deleteActivePomodoroQuery, domain.UserFromContext(ctx), id); err != nil {
+		return fmt.Errorf("failed to delete active pomodoro %s: %w", id, err)
+	}
+	return nil
+}
+
+func (ps *PostgresSubjectStore) GetActivePomodoros(ctx context.Context) ([]domain.ActivePomodoro, error) {
+	rows, err := ps.db.QueryContext(ctx, selectActivePomodorosQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make query from active_pomodoros: %w", err)
+	}
+	defer rows.Close()
+
+	active := make([]domain.ActivePomodoro, 0)
+	for rows.Next() {
+		var a domain.ActivePomodoro
+		var pausedAt sql.NullTime
+		var seconds int64
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Subject, &a.StartedAt, &a.EndsAt, &pausedAt, &seconds, &a.Round, &a.Rounds); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		a.PausedAt = pausedAt.Time
+		a.Focus = time.Duration(seconds) * time.Second
+		active = append(active, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return active, nil
+}
+
+func (ps *PostgresSubjectStore) SaveToken(ctx context.Context, token domain.APIToken) error {
+	_, err := ps.db.ExecContext(ctx, insertTokenQuery, token.ID, token.UserID, token.Name, string(token.Scope), token.Hash, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert token %s: %w", token.ID, err)
+	}
+	return nil
+}
+
+func (ps *PostgresSubjectStore) GetTokenByHash(ctx context.Context, hash string) (domain.APIToken, error) {
+	token, err := scanToken(ps.db.QueryRowContext(ctx, selectTokenByHashQuery, hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.APIToken{}, domain.ErrTokenNotFound
+	}
+	if err != nil {
+		return domain.APIToken{}, fmt.Errorf("failed to make query from api_tokens: %w", err)
+	}
+	return token, nil
+}
+
+func (ps *PostgresSubjectStore) GetTokens(ctx context.Context) ([]domain.APIToken, error) {
+	rows, err := ps.db.QueryContext(ctx, selectTokensQuery, domain.UserFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to make query from api_tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make([]domain.APIToken, 0)
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (ps *PostgresSubjectStore) DeleteToken(ctx context.Context, id string) error {
+	result, err := ps.db.ExecContext(ctx, deleteTokenQuery, domain.UserFromContext(ctx), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete token %s: %w", id, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrTokenNotFound, id)
+	}
+	return nil
+}
+
+func (ps *PostgresSubjectStore) SaveGoal(ctx context.Context, goal domain.Goal) error {
+	seconds := int64(goal.Target / time.Second)
+	if _, err := ps.db.ExecContext(ctx, upsertGoalQuery, domain.UserFromContext(ctx), goal.Subject, string(goal.Period), seconds); err != nil {
+		return fmt.Errorf("failed to save %s goal for %s: %w", goal.Period, goal.Subject, err)
+	}
+	return nil
+}
+
+func (ps *PostgresSubjectStore) GetGoals(ctx context.Context) ([]domain.Goal, error) {
+	rows, err := ps.db.QueryContext(ctx, selectGoalsQuery, domain.UserFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to make query from goals: %w", err)
+	}
+	defer rows.Close()
+
+	goals := make([]domain.Goal, 0)
+	for rows.Next() {
+		var goal domain.Goal
+		var period string
+		var seconds int64
+		if err := rows.Scan(&goal.UserID, &goal.Subject, &period, &seconds); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		goal.Period = domain.GoalPeriod(period)
+		goal.Target = time.Duration(seconds) * time.Second
+		goals = append(goals, goal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return goals, nil
+}
+
+func (ps *PostgresSubjectStore) DeleteGoal(ctx context.Context, subject string, period domain.GoalPeriod) error {
+	result, err := ps.db.ExecContext(ctx, deleteGoalQuery, domain.UserFromContext(ctx), subject, string(period))
+	if err != nil {
+		return fmt.Errorf("failed to delete %s goal for %s: %w", period, subject, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s %s", domain.ErrGoalNotFound, period, subject)
+	}
+	return nil
+}
+
+// scanSession reads a row selected with the columns of selectSessionsQuery.
+func scanSession(row interface{ Scan(dest ...any) error }) (domain.LoggedSession, error) {
+	var session domain.LoggedSession
+	var seconds int64
+	var source string
+	err := row.Scan(&session.ID, &session.UserID, &session.Subject, &session.StartedAt, &session.EndedAt, &seconds, &source)
+	session.Duration = time.Duration(seconds) * time.Second
+	session.Source = domain.SessionSource(source)
+	return session, err
+}
+
+// scanToken reads a row selected with the columns of selectTokensQuery.
+func scanToken(row interface{ Scan(dest ...any) error }) (domain.APIToken, error) {
+	var token domain.APIToken
+	var scope string
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &scope, &token.Hash, &token.CreatedAt)
+	token.Scope = domain.Scope(scope)
+	return token, err
+}
+
+// nullableTime maps a zero time to SQL NULL so that an open range bound matches everything.
+func nullableTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
@@ -1,22 +1,29 @@
 package database
 
 import (
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/bryack/study_hours_tracker/domain"
 	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/bryack/study_hours_tracker/testhelpers/storetest"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRecordAndGetHours(t *testing.T) {
+	ctx := t.Context()
 	connStr := testhelpers.SetupTestContainer(t)
-	store, err := NewPostgresSubjectStore(connStr)
+	store, err := NewPostgresSubjectStore(ctx, connStr)
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Run("should_successfully_connect_and_create_table", func(t *testing.T) {
+	t.Run("should_successfully_connect_and_migrate", func(t *testing.T) {
 		store := &PostgresSubjectStore{}
-		err := store.initDatabase(connStr)
+		err := store.initDatabase(ctx, connStr)
 
 		t.Cleanup(func() {
 			if store.db != nil {
@@ -25,72 +32,147 @@ func TestRecordAndGetHours(t *testing.T) {
 		})
 
 		assert.NoError(t, err)
-		err = store.db.Ping()
+		err = store.db.PingContext(ctx)
 		assert.NoError(t, err, "Database must be reachable via Ping")
 
-		err = store.createTable()
+		err = store.migrate(ctx)
+		assert.NoError(t, err)
 		var tableName string
-		query := `SELECT table_name FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'subjects'`
+		query := `SELECT table_name FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'sessions'`
 
 		err = store.db.QueryRow(query).Scan(&tableName)
-		assert.NoError(t, err, "Table 'subjects' should exist")
-		assert.Equal(t, "subjects", tableName)
+		assert.NoError(t, err, "Table 'sessions' should exist")
+		assert.Equal(t, "sessions", tableName)
 	})
 
 	t.Run("record hours for tdd", func(t *testing.T) {
-		err := store.RecordHour("tdd", 2)
-		assert.NoError(t, err)
-
-		err = store.RecordHour("tdd", 3)
-		assert.NoError(t, err)
+		storetest.RecordHours(t, ctx, store, "tdd", 2*time.Hour)
+		storetest.RecordHours(t, ctx, store, "tdd", 3*time.Hour)
 
 		var count int
-		err = store.db.QueryRow("SELECT COUNT(*) FROM subjects").Scan(&count)
+		err := store.db.QueryRow("SELECT COUNT(*) FROM sessions WHERE subject = 'tdd'").Scan(&count)
 		assert.NoError(t, err)
-		assert.True(t, count == 1, "Rows count should still be 1")
+		assert.Equal(t, 2, count, "Every recording should be logged as its own session")
 
-		var hours int
-		err = store.db.QueryRow("SELECT hours FROM subjects WHERE subject = 'tdd'").Scan(&hours)
+		var seconds int64
+		err = store.db.QueryRow("SELECT SUM(duration_seconds) FROM sessions WHERE subject = 'tdd'").Scan(&seconds)
 		assert.NoError(t, err)
-		assert.Equal(t, 5, hours, "Hours should be summed up (2 + 3)")
+		assert.Equal(t, int64(5*3600), seconds, "Durations should be summed up (2h + 3h)")
 	})
 
 	t.Run("get hours for tdd", func(t *testing.T) {
-		h, err := store.GetHours("tdd")
+		h, err := store.GetHours(ctx, "tdd")
 		assert.NoError(t, err)
+		assert.Equal(t, 5*time.Hour, h)
+	})
 
-		var hours int
-		err = store.db.QueryRow("SELECT hours FROM subjects WHERE subject = 'tdd'").Scan(&hours)
+	t.Run("log pomodoro session and get it back", func(t *testing.T) {
+		startedAt := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+		logged, err := store.LogSession(ctx, domain.LoggedSession{
+			Subject:   "go",
+			StartedAt: startedAt,
+			EndedAt:   startedAt.Add(25 * time.Minute),
+			Duration:  25 * time.Minute,
+			Source:    domain.SourcePomodoro,
+		})
 		assert.NoError(t, err)
-		assert.Equal(t, h, hours)
+
+		sessions, err := store.GetSessions(ctx, "go")
+		assert.NoError(t, err)
+		assert.Len(t, sessions, 1)
+		assert.Equal(t, logged.ID, sessions[0].ID)
+		assert.Equal(t, "go", sessions[0].Subject)
+		assert.Equal(t, domain.SourcePomodoro, sessions[0].Source)
+		assert.Equal(t, 25*time.Minute, sessions[0].Duration)
+		assert.True(t, startedAt.Equal(sessions[0].StartedAt))
+		assert.True(t, startedAt.Add(25*time.Minute).Equal(sessions[0].EndedAt))
 	})
 
 	t.Run("get hours for nonexistent subject", func(t *testing.T) {
-		h, err := store.GetHours("nonexistent")
+		h, err := store.GetHours(ctx, "nonexistent")
 		assert.ErrorIs(t, err, domain.ErrSubjectNotFound)
-		assert.Equal(t, 0, h)
+		assert.Equal(t, time.Duration(0), h)
 	})
 
 	t.Run("get report of all subjects and hours from DB", func(t *testing.T) {
-		_, err := store.db.Exec("TRUNCATE TABLE subjects")
+		_, err := store.db.Exec("TRUNCATE TABLE sessions")
 		if err != nil {
-			t.Fatalf("failed to truncate table 'subjects': %v", err)
+			t.Fatalf("failed to truncate table 'sessions': %v", err)
 		}
 
 		testData := domain.Report{
-			{Subject: "TDD", Hours: 6},
-			{Subject: "Docker", Hours: 4},
+			{Subject: "TDD", Duration: 6 * time.Hour},
+			{Subject: "Docker", Duration: 4*time.Hour + 30*time.Minute},
 		}
 
 		for _, v := range testData {
-			err = store.RecordHour(v.Subject, v.Hours)
-			assert.NoError(t, err)
+			storetest.RecordHours(t, ctx, store, v.Subject, v.Duration)
 		}
 
-		report, err := store.GetReport()
+		report, err := store.GetReport(ctx, domain.AllTime())
 		assert.NoError(t, err)
 
 		assert.True(t, len(report) > 0, "report slice should contain smth")
 		assert.Equal(t, testData, report)
 	})
+
+	t.Run("get report filtered by time range", func(t *testing.T) {
+		_, err := store.db.Exec("TRUNCATE TABLE sessions")
+		if err != nil {
+			t.Fatalf("failed to truncate table 'sessions': %v", err)
+		}
+
+		monday := time.Date(2026, 3, 16, 10, 0, 0, 0, time.UTC)
+		sessions := []domain.LoggedSession{
+			{Subject: "go", StartedAt: monday.AddDate(0, 0, -1), Duration: time.Hour},
+			{Subject: "go", StartedAt: monday, Duration: 2 * time.Hour},
+			{Subject: "sql", StartedAt: monday.AddDate(0, 0, 2), Duration: 30 * time.Minute},
+			{Subject: "sql", StartedAt: monday.AddDate(0, 0, 7), Duration: time.Hour},
+		}
+		for _, ls := range sessions {
+			ls.EndedAt = ls.StartedAt.Add(ls.Duration)
+			ls.Source = domain.SourceManual
+			_, err := store.LogSession(ctx, ls)
+			assert.NoError(t, err)
+		}
+
+		report, err := store.GetReport(ctx, domain.ThisWeek(monday))
+		assert.NoError(t, err)
+		assert.Equal(t, domain.Report{
+			{Subject: "go", Duration: 2 * time.Hour},
+			{Subject: "sql", Duration: 30 * time.Minute},
+		}, report)
+	})
+}
+
+func TestPostgresSubjectStoreContract(t *testing.T) {
+	ctx := t.Context()
+	connStr := testhelpers.SetupTestContainer(t)
+	store, err := NewPostgresSubjectStore(ctx, connStr)
+	require.NoError(t, err)
+
+	storetest.SubjectStoreContract{
+		NewStore: func(t testing.TB) domain.SubjectStore {
+			_, err := store.db.Exec("TRUNCATE TABLE sessions")
+			require.NoError(t, err, "failed to truncate table 'sessions'")
+			return store
+		},
+	}.Test(t)
+}
+
+func TestIsTxConflict(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"wrapped deadlock", fmt.Errorf("failed to commit transaction: %w", &pgconn.PgError{Code: "40P01"}), true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"other error", errors.New("connection refused"), false},
+		{"no error", nil, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isTxConflict(tt.err), tt.name)
+	}
 }
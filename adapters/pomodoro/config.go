@@ -0,0 +1,58 @@
+package pomodoro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+const (
+	configDirName     = "study_hours_tracker"
+	defaultConfigFile = "pomodoro.json"
+
+	configFileEnv = "STUDY_POMODORO_CONFIG"
+)
+
+// ConfigFileFromEnv returns the config file set in STUDY_POMODORO_CONFIG, or
+// the default config file when unset.
+func ConfigFileFromEnv() (string, error) {
+	if path := os.Getenv(configFileEnv); path != "" {
+		return path, nil
+	}
+	return DefaultConfigFile()
+}
+
+// DefaultConfigFile returns the Pomodoro config file under $XDG_CONFIG_HOME,
+// which defaults to ~/.config.
+func DefaultConfigFile() (string, error) {
+	configHome, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config directory: %w", err)
+	}
+	return filepath.Join(configHome, configDirName, defaultConfigFile), nil
+}
+
+// LoadConfig reads the Pomodoro focus length and alert schedule from the JSON
+// file at path. A missing file yields the default config.
+func LoadConfig(path string) (domain.PomodoroConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return domain.PomodoroConfig{}, nil
+	}
+	if err != nil {
+		return domain.PomodoroConfig{}, fmt.Errorf("failed to read pomodoro config %q: %w", path, err)
+	}
+
+	var config domain.PomodoroConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return domain.PomodoroConfig{}, fmt.Errorf("failed to parse pomodoro config %q: %w", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return domain.PomodoroConfig{}, fmt.Errorf("failed to load pomodoro config %q: %w", path, err)
+	}
+	return config, nil
+}
@@ -0,0 +1,64 @@
+package pomodoro_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/adapters/pomodoro"
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("reads focus and alerts", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "pomodoro.json")
+		data := `{"focus":"50m","alerts":[{"at":"0s","message":"Go!"},{"before":"5m","message":"5 minutes left"}]}`
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+		config, err := pomodoro.LoadConfig(path)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PomodoroConfig{
+			Focus: 50 * time.Minute,
+			Alerts: []domain.PomodoroAlert{
+				{At: 0, Message: "Go!"},
+				{At: 5 * time.Minute, FromEnd: true, Message: "5 minutes left"},
+			},
+		}, config)
+	})
+	t.Run("missing file gives the defaults", func(t *testing.T) {
+		config, err := pomodoro.LoadConfig(filepath.Join(t.TempDir(), "missing.json"))
+		require.NoError(t, err)
+		assert.Equal(t, domain.PomodoroConfig{}, config)
+	})
+	t.Run("rejects alerts outside the focus", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "pomodoro.json")
+		data := `{"focus":"10m","alerts":[{"at":"20m","message":"late"}]}`
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+		_, err := pomodoro.LoadConfig(path)
+		assert.ErrorIs(t, err, domain.ErrInvalidPomodoroConfig)
+	})
+	t.Run("rejects malformed JSON", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "pomodoro.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"focus":`), 0o600))
+
+		_, err := pomodoro.LoadConfig(path)
+		assert.Error(t, err)
+	})
+}
+
+func TestConfigFileFromEnv(t *testing.T) {
+	t.Setenv("STUDY_POMODORO_CONFIG", "/tmp/custom.json")
+	path, err := pomodoro.ConfigFileFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/custom.json", path)
+
+	t.Setenv("STUDY_POMODORO_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/config")
+	path, err = pomodoro.ConfigFileFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/config/study_hours_tracker/pomodoro.json", path)
+}
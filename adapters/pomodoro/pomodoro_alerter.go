@@ -4,28 +4,76 @@ package pomodoro
 import (
 	"fmt"
 	"io"
+	"os"
 	"time"
 )
 
+// clearLine returns the cursor to the start of the line and erases it, so the
+// countdown is redrawn in place.
+const clearLine = "\r\033[K"
+
+// TickWriter is implemented by outputs that render the countdown themselves,
+// such as the WebSocket connection of the web UI.
+type TickWriter interface {
+	WriteTick(remaining time.Duration) error
+}
+
 type Alerter struct {
-	ScheduleFunc func(duration time.Duration, message string, out io.Writer)
-	WaitFunc     func(duration time.Duration)
+	AlertFunc func(message string, out io.Writer)
+	TickFunc  func(remaining time.Duration, out io.Writer)
+}
+
+func (a Alerter) Alert(message string, out io.Writer) {
+	a.AlertFunc(message, out)
 }
 
-func (a Alerter) ScheduleAlert(duration time.Duration, message string, out io.Writer) {
-	a.ScheduleFunc(duration, message, out)
+func (a Alerter) Tick(remaining time.Duration, out io.Writer) {
+	a.TickFunc(remaining, out)
 }
 
-func (a Alerter) Wait(duration time.Duration) {
-	a.WaitFunc(duration)
+// RealAlert prints message on its own line, replacing the countdown on a terminal.
+func RealAlert(message string, out io.Writer) {
+	if _, ok := out.(TickWriter); !ok {
+		message = clearLine + message
+	}
+	fmt.Fprintln(out, message)
 }
 
-func RealScheduleAlert(duration time.Duration, message string, out io.Writer) {
-	time.AfterFunc(duration, func() {
-		fmt.Fprintln(out, message)
-	})
+// RealTick hands the remaining time to a TickWriter, or redraws a countdown
+// line in place on a terminal.
+func RealTick(remaining time.Duration, out io.Writer) {
+	if tw, ok := out.(TickWriter); ok {
+		tw.WriteTick(remaining)
+		return
+	}
+	fmt.Fprintf(out, "%s%s remaining", clearLine, FormatCountdown(remaining))
+}
+
+// PlainAlert prints message on its own line, for output without a countdown.
+func PlainAlert(message string, out io.Writer) {
+	fmt.Fprintln(out, message)
+}
+
+// NoTick drops the countdown, so that output which is not a terminal or is
+// read by a script only gets the alerts.
+func NoTick(time.Duration, io.Writer) {}
+
+// IsTerminal reports whether out is a terminal the countdown can be redrawn on.
+func IsTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
 
-func RealWait(duration time.Duration) {
-	time.Sleep(duration)
+// FormatCountdown renders a duration as a clock countdown, e.g. "24:59" or "1:05:00".
+func FormatCountdown(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, s := int(d/time.Hour), int(d/time.Minute)%60, int(d/time.Second)%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
 }
@@ -0,0 +1,73 @@
+package pomodoro_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/adapters/pomodoro"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type spyTickWriter struct {
+	bytes.Buffer
+	ticks []time.Duration
+}
+
+func (s *spyTickWriter) WriteTick(remaining time.Duration) error {
+	s.ticks = append(s.ticks, remaining)
+	return nil
+}
+
+func TestRealTick(t *testing.T) {
+	t.Run("redraws the countdown line on a terminal", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		pomodoro.RealTick(24*time.Minute+59*time.Second, out)
+		pomodoro.RealAlert("Halfway there! Keep it up.", out)
+
+		assert.Equal(t, "\r\033[K24:59 remaining\r\033[KHalfway there! Keep it up.\n", out.String())
+	})
+	t.Run("hands ticks to a tick writer", func(t *testing.T) {
+		out := &spyTickWriter{}
+		pomodoro.RealTick(90*time.Second, out)
+		pomodoro.RealAlert("Time's up!", out)
+
+		assert.Equal(t, []time.Duration{90 * time.Second}, out.ticks)
+		assert.Equal(t, "Time's up!\n", out.String())
+	})
+}
+
+func TestPlainAlert(t *testing.T) {
+	out := &bytes.Buffer{}
+	pomodoro.NoTick(24*time.Minute+59*time.Second, out)
+	pomodoro.PlainAlert("Halfway there! Keep it up.", out)
+
+	assert.Equal(t, "Halfway there! Keep it up.\n", out.String())
+}
+
+func TestIsTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.txt"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	assert.False(t, pomodoro.IsTerminal(f))
+	assert.False(t, pomodoro.IsTerminal(&bytes.Buffer{}))
+}
+
+func TestFormatCountdown(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{25 * time.Minute, "25:00"},
+		{59 * time.Second, "00:59"},
+		{1500 * time.Millisecond, "00:02"},
+		{time.Hour + 5*time.Minute, "1:05:00"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, pomodoro.FormatCountdown(tt.in), tt.in.String())
+	}
+}
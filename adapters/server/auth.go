@@ -0,0 +1,239 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+const (
+	loginPath  = "/login"
+	logoutPath = "/logout"
+
+	loginCookie = "study_session"
+	// loginTTL is how long the study page stays logged in.
+	loginTTL = 12 * time.Hour
+)
+
+var errUnauthenticated = errors.New("missing or invalid credentials")
+
+//go:embed login.html
+var loginHTML string
+
+var loginTemplate = template.Must(template.New("login").Parse(loginHTML))
+
+// principal is who a request acts for and what it may do.
+type principal struct {
+	user  domain.UserID
+	scope domain.Scope
+}
+
+// loginSession is a cookie session of the study page. Sessions are kept in
+// memory, so a server restart logs everyone out.
+type loginSession struct {
+	principal
+	expires time.Time
+}
+
+type loginSessions struct {
+	mu       sync.Mutex
+	sessions map[string]loginSession
+}
+
+func newLoginSessions() *loginSessions {
+	return &loginSessions{sessions: make(map[string]loginSession)}
+}
+
+// create starts a session for p. It also drops the expired sessions, since get
+// only drops those whose cookie comes back.
+func (l *loginSessions) create(p principal, now time.Time) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id, session := range l.sessions {
+		if now.After(session.expires) {
+			delete(l.sessions, id)
+		}
+	}
+	id := rand.Text()
+	l.sessions[id] = loginSession{principal: p, expires: now.Add(loginTTL)}
+	return id
+}
+
+func (l *loginSessions) get(id string, now time.Time) (principal, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	session, ok := l.sessions[id]
+	if !ok {
+		return principal{}, false
+	}
+	if now.After(session.expires) {
+		delete(l.sessions, id)
+		return principal{}, false
+	}
+	return session.principal, true
+}
+
+func (l *loginSessions) delete(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.sessions, id)
+}
+
+type scopeKey struct{}
+
+// scopeFromContext returns the scope of the request's credentials. Without
+// authentication every request may do everything.
+func scopeFromContext(ctx context.Context) domain.Scope {
+	if scope, ok := ctx.Value(scopeKey{}).(domain.Scope); ok {
+		return scope
+	}
+	return domain.ScopeRecord
+}
+
+// RequireAuth makes every endpoint but the login page require an API token
+// in an "Authorization: Bearer" header or the session cookie set by logging
+// in. Requests then act for the user the credentials belong to.
+func (s *StudyServer) RequireAuth() {
+	s.requireAuth = true
+}
+
+// withAuth authenticates requests when RequireAuth was called.
+func (s *StudyServer) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.requireAuth || r.URL.Path == loginPath {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p, err := s.authenticate(r)
+		switch {
+		case errors.Is(err, errUnauthenticated) && r.URL.Path == studyPath:
+			http.Redirect(w, r, loginPath, http.StatusSeeOther)
+			return
+		case errors.Is(err, errUnauthenticated):
+			w.Header().Set("WWW-Authenticate", `Bearer realm="study_hours_tracker"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		case err != nil:
+			writeStoreError(w, err)
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") && !safeMethod(r.Method) && !sameOrigin(r) {
+			http.Error(w, "cross-site request with the session cookie", http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(domain.WithUser(r.Context(), p.user), scopeKey{}, p.scope)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// safeMethod reports whether method only reads.
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// sameOrigin reports whether r was sent by a page of this server, judged by
+// its Origin header or, without one, its Referer. Browsers send the session
+// cookie along with requests other sites make, so requests authenticated by
+// the cookie may only change something when they come from the study page.
+func sameOrigin(r *http.Request) bool {
+	source := cmp.Or(r.Header.Get("Origin"), r.Header.Get("Referer"))
+	u, err := url.Parse(source)
+	return source != "" && err == nil && u.Host == r.Host
+}
+
+// authenticate finds who the bearer token or the session cookie of r belongs to.
+func (s *StudyServer) authenticate(r *http.Request) (principal, error) {
+	if secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return s.authenticateToken(r.Context(), secret)
+	}
+	if cookie, err := r.Cookie(loginCookie); err == nil {
+		if p, ok := s.logins.get(cookie.Value, time.Now()); ok {
+			return p, nil
+		}
+	}
+	return principal{}, errUnauthenticated
+}
+
+func (s *StudyServer) authenticateToken(ctx context.Context, secret string) (principal, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	token, err := s.store.GetTokenByHash(ctx, domain.HashToken(strings.TrimSpace(secret)))
+	if errors.Is(err, domain.ErrTokenNotFound) {
+		return principal{}, errUnauthenticated
+	}
+	if err != nil {
+		return principal{}, err
+	}
+	return principal{user: token.UserID, scope: token.Scope}, nil
+}
+
+// allowed answers 403 unless the request's credentials grant scope.
+func allowed(w http.ResponseWriter, r *http.Request, scope domain.Scope) bool {
+	if scopeFromContext(r.Context()).Allows(scope) {
+		return true
+	}
+	http.Error(w, fmt.Sprintf("token scope does not allow %s", scope), http.StatusForbidden)
+	return false
+}
+
+// loginHandler shows the login form and trades a valid API token for a
+// session cookie of the study page.
+func (s *StudyServer) loginHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		loginTemplate.Execute(w, nil)
+	case http.MethodPost:
+		p, err := s.authenticateToken(r.Context(), r.PostFormValue("token"))
+		if errors.Is(err, errUnauthenticated) {
+			w.WriteHeader(http.StatusUnauthorized)
+			loginTemplate.Execute(w, "Unknown or revoked token")
+			return
+		}
+		if err != nil {
+			log.Printf("failed to log in: %v", err)
+			writeStoreError(w, err)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     loginCookie,
+			Value:    s.logins.create(p, time.Now()),
+			Path:     "/",
+			MaxAge:   int(loginTTL.Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		})
+		http.Redirect(w, r, studyPath, http.StatusSeeOther)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// logoutHandler ends the cookie session of the study page.
+func (s *StudyServer) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if cookie, err := r.Cookie(loginCookie); err == nil {
+		s.logins.delete(cookie.Value)
+	}
+	http.SetCookie(w, &http.Cookie{Name: loginCookie, Path: "/", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteStrictMode})
+	http.Redirect(w, r, loginPath, http.StatusSeeOther)
+}
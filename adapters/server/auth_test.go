@@ -0,0 +1,222 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/adapters/database"
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// issueToken saves a new token of user in store and returns its secret.
+func issueToken(t *testing.T, store domain.TokenStore, user domain.UserID, scope domain.Scope) string {
+	t.Helper()
+	token, secret := domain.NewAPIToken(user, scope, "", time.Now())
+	require.NoError(t, store.SaveToken(domain.WithUser(t.Context(), user), token))
+	return secret
+}
+
+func TestAuth(t *testing.T) {
+	store := database.NewInMemorySubjectStore()
+	studyServer := mustMakeStudyServer(t, store, domain.NewStudySession(store, nil, testhelpers.NewFakeClock(time.Now())))
+	studyServer.RequireAuth()
+
+	readToken := issueToken(t, store, "alice", domain.ScopeRead)
+	recordToken := issueToken(t, store, "alice", domain.ScopeRecord)
+
+	serve := func(method, target, token string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		request.Header.Set(userHeader, "mallory")
+		response := httptest.NewRecorder()
+		studyServer.ServeHTTP(response, request)
+		return response
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		token  string
+		want   int
+	}{
+		{"rejects requests without a token", http.MethodGet, "/report", "", http.StatusUnauthorized},
+		{"rejects unknown tokens", http.MethodGet, "/report", "sht_forged", http.StatusUnauthorized},
+		{"lets a read token read", http.MethodGet, "/report", readToken, http.StatusOK},
+		{"keeps a read token from recording", http.MethodPost, "/tracker/tdd?hours=1", readToken, http.StatusForbidden},
+		{"lets a record token record", http.MethodPost, "/tracker/tdd?hours=1", recordToken, http.StatusAccepted},
+		{"lets a record token read", http.MethodGet, "/tracker/tdd", recordToken, http.StatusOK},
+		{"keeps a read token from deleting sessions", http.MethodDelete, "/sessions/1", readToken, http.StatusForbidden},
+		{"keeps a read token from setting goals", http.MethodPost, "/goals", readToken, http.StatusForbidden},
+		{"lets a read token see goals", http.MethodGet, "/goals", readToken, http.StatusOK},
+		{"lets a read token see stats", http.MethodGet, "/stats", readToken, http.StatusOK},
+		{"lets a read token export", http.MethodGet, "/export", readToken, http.StatusOK},
+		{"keeps a read token from importing", http.MethodPost, "/import", readToken, http.StatusForbidden},
+		{"lets a read token try an import", http.MethodPost, "/import?dry_run=true&format=jsonl", readToken, http.StatusOK},
+		{"sends the study page to the login page", http.MethodGet, "/study", "", http.StatusSeeOther},
+		{"shows the login page to anyone", http.MethodGet, "/login", "", http.StatusOK},
+		{"ignores a malformed user parameter", http.MethodGet, "/report?user=not+a+user!", readToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := serve(tt.method, tt.target, tt.token)
+			assert.Equal(t, tt.want, response.Code)
+		})
+	}
+
+	t.Run("acts for the user of the token", func(t *testing.T) {
+		h, err := store.GetHours(domain.WithUser(t.Context(), "alice"), "tdd")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Hour, h)
+
+		_, err = store.GetHours(domain.WithUser(t.Context(), "mallory"), "tdd")
+		assert.ErrorIs(t, err, domain.ErrSubjectNotFound, "the user header should not override the token")
+	})
+	t.Run("rejects revoked tokens", func(t *testing.T) {
+		token, secret := domain.NewAPIToken("alice", domain.ScopeRead, "", time.Now())
+		alice := domain.WithUser(t.Context(), "alice")
+		require.NoError(t, store.SaveToken(alice, token))
+		require.NoError(t, store.DeleteToken(alice, token.ID))
+
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/report", secret).Code)
+	})
+}
+
+func TestLogin(t *testing.T) {
+	store := database.NewInMemorySubjectStore()
+	studyServer := mustMakeStudyServer(t, store, &testhelpers.SpySession{})
+	studyServer.RequireAuth()
+	token := issueToken(t, store, "alice", domain.ScopeRecord)
+
+	login := func(token string) *httptest.ResponseRecorder {
+		form := url.Values{"token": {token}}
+		request := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		response := httptest.NewRecorder()
+		studyServer.ServeHTTP(response, request)
+		return response
+	}
+	withCookie := func(method, target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, nil)
+		request.Header.Set("Origin", "http://"+request.Host)
+		request.AddCookie(cookie)
+		response := httptest.NewRecorder()
+		studyServer.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("rejects an unknown token", func(t *testing.T) {
+		response := login("sht_forged")
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+		assert.Contains(t, response.Body.String(), "Unknown or revoked token")
+	})
+	t.Run("opens the study page with the session cookie until logout", func(t *testing.T) {
+		response := login(token)
+		assert.Equal(t, http.StatusSeeOther, response.Code)
+		assert.Equal(t, studyPath, response.Header().Get("Location"))
+
+		cookies := response.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, loginCookie, cookies[0].Name)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+
+		assert.Equal(t, http.StatusOK, withCookie(http.MethodGet, studyPath, cookies[0]).Code)
+		assert.Equal(t, http.StatusOK, withCookie(http.MethodGet, "/report", cookies[0]).Code)
+
+		assert.Equal(t, http.StatusSeeOther, withCookie(http.MethodPost, logoutPath, cookies[0]).Code)
+		assert.Equal(t, http.StatusSeeOther, withCookie(http.MethodGet, studyPath, cookies[0]).Code, "should be logged out")
+	})
+	t.Run("accepts changes with the session cookie only from the study page", func(t *testing.T) {
+		cookie := login(token).Result().Cookies()[0]
+		post := func(header, value string) int {
+			request := httptest.NewRequest(http.MethodPost, "/tracker/tdd?hours=1", nil)
+			if header != "" {
+				request.Header.Set(header, value)
+			}
+			request.AddCookie(cookie)
+			response := httptest.NewRecorder()
+			studyServer.ServeHTTP(response, request)
+			return response.Code
+		}
+
+		assert.Equal(t, http.StatusAccepted, post("Origin", "http://example.com"))
+		assert.Equal(t, http.StatusAccepted, post("Referer", "http://example.com/study"))
+		assert.Equal(t, http.StatusForbidden, post("Origin", "https://evil.example"))
+		assert.Equal(t, http.StatusForbidden, post("Origin", "null"))
+		assert.Equal(t, http.StatusForbidden, post("", ""), "a request without its origin should be rejected")
+		assert.Equal(t, http.StatusOK, withCookie(http.MethodGet, "/report", cookie).Code)
+	})
+}
+
+func TestLoginSessions(t *testing.T) {
+	now := time.Date(2026, 3, 18, 15, 0, 0, 0, time.UTC)
+	alice := principal{user: "alice", scope: domain.ScopeRecord}
+
+	t.Run("expires a login", func(t *testing.T) {
+		logins := newLoginSessions()
+		id := logins.create(alice, now)
+
+		_, ok := logins.get(id, now.Add(loginTTL))
+		assert.True(t, ok)
+		_, ok = logins.get(id, now.Add(loginTTL+time.Second))
+		assert.False(t, ok)
+		assert.Empty(t, logins.sessions, "the expired login should be removed")
+	})
+	t.Run("removes expired logins that never come back", func(t *testing.T) {
+		logins := newLoginSessions()
+		logins.create(alice, now)
+		logins.create(alice, now)
+		fresh := logins.create(alice, now.Add(time.Hour))
+
+		latest := logins.create(alice, now.Add(loginTTL+time.Minute))
+
+		assert.Len(t, logins.sessions, 2)
+		assert.Contains(t, logins.sessions, fresh)
+		assert.Contains(t, logins.sessions, latest)
+	})
+}
+
+func TestWebSocketAuth(t *testing.T) {
+	store := database.NewInMemorySubjectStore()
+	session := &testhelpers.SpySession{ManualCalls: map[string]time.Duration{}}
+	studyServer := mustMakeStudyServer(t, store, session)
+	studyServer.RequireAuth()
+	readToken := issueToken(t, store, "alice", domain.ScopeRead)
+
+	server := httptest.NewServer(studyServer)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	_, response, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	assert.Error(t, err)
+	if assert.NotNil(t, response) {
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Authorization": {"Bearer " + readToken}})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	writeWSMessage(t, `{"command":"record_manual","subject":"tdd","duration":"1h"}`, conn)
+	within(t, 500*time.Millisecond, func() {
+		assertWebsocketGotEvent(t, conn, EventError, "token scope does not allow record")
+	})
+	assert.Empty(t, session.ManualCalls)
+
+	writeWSMessage(t, `{"command":"status"}`, conn)
+	within(t, 500*time.Millisecond, func() {
+		status := readEvent(t, conn)
+		assert.Equal(t, EventStatus, status.Type)
+	})
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"crypto/rand"
+	"time"
+)
+
+// ProtocolVersion is the version of the WebSocket event envelope. It changes
+// whenever an event type or payload changes incompatibly.
+const ProtocolVersion = 1
+
+// EventType tells a client how to read the payload of an Event.
+type EventType string
+
+const (
+	EventAck      EventType = "ack"      // a command was carried out; AckPayload
+	EventError    EventType = "error"    // a command failed; ErrorPayload
+	EventAlert    EventType = "alert"    // a Pomodoro alert; AlertPayload
+	EventTick     EventType = "tick"     // time left in the running phase; TickPayload
+	EventComplete EventType = "complete" // a Pomodoro is over; CompletePayload
+	EventStatus   EventType = "status"   // answer to the status command; StatusPayload
+)
+
+// Event is the envelope of every message the server sends over the WebSocket.
+type Event struct {
+	Version   int       `json:"version"`
+	Type      EventType `json:"type"`
+	SessionID string    `json:"session_id,omitempty"` // set for events about a Pomodoro
+	Subject   string    `json:"subject,omitempty"`
+	Payload   any       `json:"payload"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type AckPayload struct {
+	Command string `json:"command"`
+	Message string `json:"message"`
+}
+
+type ErrorPayload struct {
+	Command string `json:"command,omitempty"` // empty when the message could not be parsed
+	Message string `json:"message"`
+}
+
+type AlertPayload struct {
+	Message string `json:"message"`
+}
+
+type TickPayload struct {
+	RemainingSeconds int `json:"remaining_seconds"`
+}
+
+// Pomodoro completion statuses.
+const (
+	StatusFinished  = "finished"
+	StatusCancelled = "cancelled"
+	StatusFailed    = "failed"
+)
+
+type CompletePayload struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// StateIdle is the status of a connection without a running Pomodoro. A
+// running one reports its domain.PomodoroState instead.
+const StateIdle = "idle"
+
+type StatusPayload struct {
+	State string `json:"state"`
+}
+
+// newSessionID returns a random identifier for a Pomodoro run.
+func newSessionID() string {
+	return rand.Text()
+}
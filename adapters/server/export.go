@@ -0,0 +1,74 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+const exportPath = "/export"
+
+var exportContentTypes = map[domain.ExportFormat]string{
+	domain.ExportCSV:       "text/csv; charset=utf-8",
+	domain.ExportJSONLines: "application/x-ndjson",
+}
+
+// exportHandler streams every session of the user as CSV or JSON lines,
+// chosen by the "format" query parameter. The "period", "from" and "to"
+// parameters narrow the sessions down like they do for the report.
+//
+// It is not bound by the request timeout, since large histories take a while
+// to send; the export stops when the client goes away.
+func (s *StudyServer) exportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !allowed(w, r, domain.ScopeRead) {
+		return
+	}
+
+	query := r.URL.Query()
+	format, err := domain.ParseExportFormat(query.Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	period, err := parseReportRange(query, s.session.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("content-type", exportContentTypes[format])
+	w.Header().Set("content-disposition", fmt.Sprintf(`attachment; filename="study-sessions.%s"`, format))
+	out := &startedWriter{Writer: w}
+	if _, err := s.session.Export(r.Context(), out, format, period); err != nil {
+		if !out.started {
+			w.Header().Del("content-type")
+			w.Header().Del("content-disposition")
+			writeStoreError(w, err)
+			return
+		}
+		// The status is sent already; cutting the body short is all that is left.
+		if !errors.Is(err, r.Context().Err()) {
+			log.Printf("failed to export sessions: %v", err)
+		}
+	}
+}
+
+// startedWriter remembers whether anything was written, after which the
+// status can no longer change.
+type startedWriter struct {
+	io.Writer
+	started bool
+}
+
+func (w *startedWriter) Write(p []byte) (int, error) {
+	w.started = true
+	return w.Writer.Write(p)
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	server := newTestServer(t, testhelpers.NewFakeClock(testNow))
+	_, err := server.session.RecordManual(t.Context(), "go", 90*time.Minute)
+	require.NoError(t, err)
+	_, err = server.session.RecordManual(t.Context(), "sql", time.Hour)
+	require.NoError(t, err)
+
+	t.Run("exports csv by default", func(t *testing.T) {
+		response := server.serve(http.MethodGet, "/export", "")
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", response.Header().Get("content-type"))
+		assert.Contains(t, response.Header().Get("content-disposition"), `filename="study-sessions.csv"`)
+
+		lines := strings.Split(strings.TrimSpace(response.Body.String()), "\n")
+		require.Len(t, lines, 3)
+		assert.Equal(t, "id,subject,started_at,ended_at,hours,duration,source", lines[0])
+		assert.Contains(t, lines[1], ",go,")
+		assert.Contains(t, lines[1], ",1.5,1h30m0s,manual")
+	})
+	t.Run("exports json lines", func(t *testing.T) {
+		response := server.serve(http.MethodGet, "/export?format=jsonl", "")
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "application/x-ndjson", response.Header().Get("content-type"))
+
+		var subjects []string
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			var line struct {
+				Subject string  `json:"subject"`
+				Hours   float64 `json:"hours"`
+			}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			subjects = append(subjects, line.Subject)
+		}
+		assert.Equal(t, []string{"go", "sql"}, subjects)
+	})
+	t.Run("exports only the user's sessions", func(t *testing.T) {
+		response := server.serve(http.MethodGet, "/export?user=alice", "")
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "id,subject,started_at,ended_at,hours,duration,source\n", response.Body.String())
+	})
+
+	tests := []struct {
+		name   string
+		target string
+		want   int
+	}{
+		{"narrows sessions down to a period", "/export?period=week", http.StatusOK},
+		{"rejects unknown formats", "/export?format=xml", http.StatusBadRequest},
+		{"rejects unknown periods", "/export?period=year", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, server.serve(http.MethodGet, tt.target, "").Code)
+		})
+	}
+	t.Run("answers 500 when the export fails before it starts", func(t *testing.T) {
+		spy := &testhelpers.SpySession{StubExportErr: errors.New("store is down")}
+		response := httptest.NewRecorder()
+		mustMakeStudyServer(t, server.store, spy).ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/export", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, response.Code)
+		assert.Empty(t, response.Header().Get("content-disposition"))
+	})
+}
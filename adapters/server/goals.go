@@ -0,0 +1,97 @@
+package server
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+const goalsPath = "/goals"
+
+// goalRequest is the body of POST /goals.
+type goalRequest struct {
+	Subject string  `json:"subject"`
+	Period  string  `json:"period,omitempty"` // daily, weekly or monthly; weekly when left out
+	Hours   float64 `json:"hours,omitempty"`
+	Target  string  `json:"target,omitempty"` // e.g. "10h"; takes precedence over Hours
+}
+
+func (g goalRequest) goal() (domain.Goal, error) {
+	period, err := domain.ParseGoalPeriod(cmp.Or(g.Period, string(domain.GoalWeekly)))
+	if err != nil {
+		return domain.Goal{}, err
+	}
+	target, err := domain.ParseDuration(cmp.Or(g.Target, strconv.FormatFloat(g.Hours, 'f', -1, 64)))
+	if err != nil {
+		return domain.Goal{}, err
+	}
+	return domain.Goal{Subject: g.Subject, Period: period, Target: target}, nil
+}
+
+// goalsHandler shows the progress of the user's goals, sets a goal with POST
+// and removes one with DELETE /goals?subject=NAME&period=PERIOD.
+func (s *StudyServer) goalsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if !allowed(w, r, domain.ScopeRead) {
+			return
+		}
+		progress, err := s.session.Goals(r.Context())
+		if err != nil {
+			writeGoalError(w, err)
+			return
+		}
+		writeJSON(w, progress)
+	case http.MethodPost:
+		if !allowed(w, r, domain.ScopeRecord) {
+			return
+		}
+		var req goalRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+			return
+		}
+		goal, err := req.goal()
+		if err == nil {
+			err = s.session.SetGoal(r.Context(), goal)
+		}
+		if err != nil {
+			writeGoalError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		if !allowed(w, r, domain.ScopeRecord) {
+			return
+		}
+		query := r.URL.Query()
+		period, err := domain.ParseGoalPeriod(cmp.Or(query.Get("period"), string(domain.GoalWeekly)))
+		if err == nil {
+			err = s.session.DeleteGoal(r.Context(), query.Get("subject"), period)
+		}
+		if err != nil {
+			writeGoalError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// writeGoalError answers 404 for unknown goals and 400 for invalid ones.
+func writeGoalError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrGoalNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidGoal), errors.Is(err, domain.ErrInvalidDuration):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeStoreError(w, err)
+	}
+}
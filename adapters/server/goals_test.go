@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoals(t *testing.T) {
+	server := newTestServer(t, testhelpers.NewFakeClock(testNow))
+	_, err := server.session.RecordManual(t.Context(), "go", 6*time.Hour)
+	require.NoError(t, err)
+
+	t.Run("sets goals and shows their progress", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, server.serve(http.MethodPost, "/goals", `{"subject":"go","target":"10h"}`).Code)
+		assert.Equal(t, http.StatusNoContent, server.serve(http.MethodPost, "/goals", `{"subject":"go","period":"daily","hours":2}`).Code)
+
+		response := server.serve(http.MethodGet, "/goals", "")
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, jsonContentType, response.Header().Get("content-type"))
+
+		var got []map[string]any
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&got))
+		require.Len(t, got, 2)
+		assert.Equal(t, "daily", got[0]["period"])
+		assert.Equal(t, true, got[0]["met"])
+		assert.Equal(t, "weekly", got[1]["period"])
+		assert.Equal(t, 60.0, got[1]["percent"])
+		assert.Equal(t, "4h0m0s", got[1]["remaining"])
+	})
+	t.Run("removes a goal", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, server.serve(http.MethodDelete, "/goals?subject=go&period=daily", "").Code)
+		assert.Equal(t, http.StatusNotFound, server.serve(http.MethodDelete, "/goals?subject=go&period=daily", "").Code)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   int
+	}{
+		{"rejects an unknown period", http.MethodPost, `{"subject":"go","period":"yearly","hours":1}`, http.StatusBadRequest},
+		{"rejects a missing target", http.MethodPost, `{"subject":"go"}`, http.StatusBadRequest},
+		{"rejects an empty subject", http.MethodPost, `{"subject":"","hours":1}`, http.StatusBadRequest},
+		{"rejects an invalid body", http.MethodPost, `subject=go`, http.StatusBadRequest},
+		{"rejects other methods", http.MethodPut, `{}`, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, server.serve(tt.method, "/goals", tt.body).Code)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+const (
+	importPath = "/import"
+
+	// maxImportSize bounds the file a single import may send.
+	maxImportSize = 32 << 20
+)
+
+// importHandler imports the sessions of the file sent as the body, in the
+// format named by the "format" query parameter. Columns that differ from
+// those of the format are named by parameters such as "subject=Description",
+// see domain.ImportColumnKeys. With "dry_run=true"
+// it only answers what the import would do, which a read token may ask for.
+//
+// Like the export it is not bound by the request timeout, since years of
+// history take a while to store.
+func (s *StudyServer) importHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	dryRun := false
+	if value := query.Get("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, fmt.Sprintf("invalid dry_run %q", value), http.StatusBadRequest)
+			return
+		}
+	}
+	scope := domain.ScopeRecord
+	if dryRun {
+		scope = domain.ScopeRead
+	}
+	if !allowed(w, r, scope) {
+		return
+	}
+
+	format, err := domain.ParseImportFormat(query.Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	options := domain.ImportOptions{Format: format, Location: time.Local, DryRun: dryRun}
+	for _, key := range domain.ImportColumnKeys {
+		if column := query.Get(key); column != "" {
+			options.Columns.Set(key, column)
+		}
+	}
+
+	summary, err := s.session.Import(r.Context(), http.MaxBytesReader(w, r.Body, maxImportSize), options)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		http.Error(w, fmt.Sprintf("import is larger than %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, domain.ErrInvalidImport):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		writeStoreError(w, err)
+	default:
+		writeJSON(w, summary)
+	}
+}
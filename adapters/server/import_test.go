@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	server := newTestServer(t, testhelpers.NewFakeClock(testNow))
+
+	const spreadsheet = "Day,Course,Hours\n2026-03-16,Go,1.5\n2026-03-17,SQL,2\n2026-03-17,SQL,2\n"
+	summary := func(t *testing.T, response *httptest.ResponseRecorder) domain.ImportSummary {
+		t.Helper()
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		var got struct {
+			domain.ImportSummary
+			Subjects []map[string]any `json:"subjects"`
+		}
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&got))
+		return got.ImportSummary
+	}
+
+	t.Run("sums up a dry run", func(t *testing.T) {
+		got := summary(t, server.serve(http.MethodPost, "/import?format=csv&subject=Course&start=Day&duration=Hours&dry_run=true", spreadsheet))
+		assert.True(t, got.DryRun)
+		assert.Equal(t, 3, got.Read)
+		assert.Equal(t, 2, got.Imported)
+		assert.Equal(t, 1, got.Duplicates)
+
+		report, err := server.store.GetReport(t.Context(), domain.AllTime())
+		require.NoError(t, err)
+		assert.Empty(t, report, "a dry run should import nothing")
+	})
+	t.Run("imports new sessions", func(t *testing.T) {
+		got := summary(t, server.serve(http.MethodPost, "/import?subject=Course&start=Day&duration=Hours", spreadsheet))
+		assert.False(t, got.DryRun)
+		assert.Equal(t, 2, got.Imported)
+
+		got = summary(t, server.serve(http.MethodPost, "/import?subject=Course&start=Day&duration=Hours", spreadsheet))
+		assert.Zero(t, got.Imported, "importing again should skip what was imported")
+
+		report, err := server.store.GetReport(t.Context(), domain.AllTime())
+		require.NoError(t, err)
+		assert.Equal(t, domain.Report{
+			{Subject: "SQL", Duration: 2 * time.Hour},
+			{Subject: "Go", Duration: 90 * time.Minute},
+		}, report)
+	})
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		want   int
+	}{
+		{"rejects unknown formats", "/import?format=xml", spreadsheet, http.StatusBadRequest},
+		{"rejects missing columns", "/import", spreadsheet, http.StatusBadRequest},
+		{"rejects invalid rows", "/import?subject=Course&start=Day&duration=Hours", "Day,Course,Hours\nsoon,Go,1\n", http.StatusBadRequest},
+		{"rejects invalid dry_run", "/import?dry_run=maybe", spreadsheet, http.StatusBadRequest},
+		{"rejects too large files", "/import?format=jsonl", strings.Repeat(" ", maxImportSize+1), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, server.serve(http.MethodPost, tt.target, tt.body).Code)
+		})
+	}
+	t.Run("rejects other methods", func(t *testing.T) {
+		assert.Equal(t, http.StatusMethodNotAllowed, server.serve(http.MethodGet, "/import", "").Code)
+	})
+}
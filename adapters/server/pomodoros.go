@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+// wsPomodoro is a Pomodoro run by the server. It keeps running when the
+// connection that started it goes away and reports to whichever connection
+// is attached to it at the time.
+type wsPomodoro struct {
+	id      string
+	user    domain.UserID // the Pomodoro records for this user
+	subject string
+	control *domain.PomodoroControl
+
+	mu       sync.Mutex
+	conn     *studyServerWs // nil while no connection is attached
+	finished bool
+}
+
+func newWsPomodoro(id string, user domain.UserID, subject string) *wsPomodoro {
+	return &wsPomodoro{id: id, user: user, subject: subject, control: domain.NewPomodoroControl()}
+}
+
+// attach sends the events of the Pomodoro to ws from now on.
+func (p *wsPomodoro) attach(ws *studyServerWs) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conn = ws
+}
+
+// detach stops sending events to ws, unless another connection took over.
+func (p *wsPomodoro) detach(ws *studyServerWs) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == ws {
+		p.conn = nil
+	}
+}
+
+// finish marks the Pomodoro as over and returns the connection attached to it.
+func (p *wsPomodoro) finish() *studyServerWs {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finished = true
+	return p.conn
+}
+
+func (p *wsPomodoro) done() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.finished
+}
+
+// event returns an event tagged with the session of the Pomodoro.
+func (p *wsPomodoro) event(eventType EventType, payload any) Event {
+	return Event{Type: eventType, SessionID: p.id, Subject: p.subject, Payload: payload}
+}
+
+// writeEvent sends an event to the attached connection. Events of a detached
+// Pomodoro are dropped.
+func (p *wsPomodoro) writeEvent(eventType EventType, payload any) error {
+	p.mu.Lock()
+	conn := p.conn
+	p.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	return conn.writeEvent(p.event(eventType, payload))
+}
+
+// pomodoroWriter turns what a running Pomodoro writes into alert and tick
+// events tagged with its session.
+type pomodoroWriter struct {
+	pomodoro *wsPomodoro
+}
+
+// Write sends each alert line as an alert event.
+func (pw pomodoroWriter) Write(p []byte) (int, error) {
+	message := strings.TrimSuffix(string(p), "\n")
+	if err := pw.pomodoro.writeEvent(EventAlert, AlertPayload{Message: message}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteTick sends the time remaining in the running Pomodoro phase as a tick
+// event, which the study page shows as a live timer.
+func (pw pomodoroWriter) WriteTick(remaining time.Duration) error {
+	return pw.pomodoro.writeEvent(EventTick, TickPayload{RemainingSeconds: int(remaining.Round(time.Second).Seconds())})
+}
+
+// pomodoroFunc runs the Pomodoro id until it is over.
+type pomodoroFunc func(ctx context.Context, id string, out io.Writer, control *domain.PomodoroControl) error
+
+// pomodoroRegistry runs the Pomodoros of the server in the background and
+// finds them by session id.
+type pomodoroRegistry struct {
+	ctx    context.Context // cancelled by close
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[string]*wsPomodoro
+}
+
+func newPomodoroRegistry() *pomodoroRegistry {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &pomodoroRegistry{ctx: ctx, cancel: cancel, running: make(map[string]*wsPomodoro)}
+}
+
+// run starts record for p and sends a complete event to the connection
+// attached to p once it is over.
+func (r *pomodoroRegistry) run(p *wsPomodoro, record pomodoroFunc) {
+	r.mu.Lock()
+	r.running[p.id] = p
+	r.mu.Unlock()
+
+	r.wg.Go(func() {
+		ctx := domain.WithUser(r.ctx, p.user)
+		err := record(ctx, p.id, pomodoroWriter{pomodoro: p}, p.control)
+		conn := p.finish()
+		r.mu.Lock()
+		delete(r.running, p.id)
+		r.mu.Unlock()
+
+		if conn == nil {
+			return
+		}
+		switch {
+		case errors.Is(err, domain.ErrPomodoroCancelled):
+			conn.writeEvent(p.event(EventComplete, CompletePayload{Status: StatusCancelled}))
+		case err != nil:
+			conn.writeEvent(p.event(EventComplete, CompletePayload{Status: StatusFailed, Message: err.Error()}))
+		default:
+			conn.writeEvent(p.event(EventComplete, CompletePayload{Status: StatusFinished}))
+		}
+	})
+}
+
+// get returns the running Pomodoro with the session id, or nil.
+func (r *pomodoroRegistry) get(id string) *wsPomodoro {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.running[id]
+}
+
+// close stops every running Pomodoro and waits for them.
+func (r *pomodoroRegistry) close() {
+	r.cancel()
+	r.wg.Wait()
+}
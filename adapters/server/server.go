@@ -1,15 +1,20 @@
 package server
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bryack/study_hours_tracker/domain"
 	"github.com/gorilla/websocket"
@@ -21,6 +26,25 @@ const (
 	trackerPath     = "/tracker/"
 	studyPath       = "/study"
 	websocketPath   = "/ws"
+
+	// userHeader and userParam name the user a request acts for; requests
+	// without either act for domain.DefaultUser. They are trusted only
+	// without RequireAuth.
+	userHeader = "X-Study-User"
+	userParam  = "user"
+
+	// defaultRequestTimeout bounds how long an HTTP API request may spend in the store.
+	defaultRequestTimeout = 5 * time.Second
+)
+
+// Limits on the Pomodoros a client may start, so that one message cannot
+// flood the socket with ticks or keep a run going for days.
+const (
+	minPomodoroTick   = time.Second
+	maxPomodoroFocus  = 4 * time.Hour
+	maxPomodoroBreak  = time.Hour
+	maxPomodoroRounds = 12
+	maxPomodoroCycle  = 12 * time.Hour
 )
 
 var wsUpgrader = websocket.Upgrader{
@@ -32,15 +56,74 @@ var wsUpgrader = websocket.Upgrader{
 var studyHTML string
 
 type wsMessage struct {
-	Command string `json:"command"`
-	Subject string `json:"subject"`
-	Hours   int    `json:"hours,omitempty"` // Optional, only for record_manual
+	Version   int                   `json:"version,omitempty"` // Optional, ProtocolVersion when set
+	Command   string                `json:"command"`
+	Subject   string                `json:"subject"`
+	Hours     float64               `json:"hours,omitempty"`      // Optional, only for record_manual
+	Duration  string                `json:"duration,omitempty"`   // Optional, e.g. "1h30m"; for record_manual takes precedence over Hours, for start_pomodoro(_cycle) sets the focus
+	Pomodoro  domain.PomodoroConfig `json:"pomodoro,omitzero"`    // Optional, only for start_pomodoro and start_pomodoro_cycle: focus, alerts and breaks
+	SessionID string                `json:"session_id,omitempty"` // Only for attach
+}
+
+// pomodoroConfig returns the Pomodoro settings carried by the message.
+func (m wsMessage) pomodoroConfig() (domain.PomodoroConfig, error) {
+	config := m.Pomodoro
+	if m.Duration != "" {
+		focus, err := domain.ParseFocus(m.Duration)
+		if err != nil {
+			return domain.PomodoroConfig{}, err
+		}
+		config.Focus = focus
+	}
+	if err := checkPomodoroLimits(config); err != nil {
+		return domain.PomodoroConfig{}, err
+	}
+	return config, nil
+}
+
+// checkPomodoroLimits reports whether config stays within the limits on
+// Pomodoros started over the socket.
+func checkPomodoroLimits(config domain.PomodoroConfig) error {
+	switch {
+	case config.Tick != 0 && config.Tick < minPomodoroTick:
+		return fmt.Errorf("%w: tick %s should be at least %s", domain.ErrInvalidPomodoroConfig, domain.FormatDuration(config.Tick), domain.FormatDuration(minPomodoroTick))
+	case config.FocusDuration() > maxPomodoroFocus:
+		return fmt.Errorf("%w: focus %s should be at most %s", domain.ErrInvalidPomodoroConfig, domain.FormatDuration(config.FocusDuration()), domain.FormatDuration(maxPomodoroFocus))
+	case config.ShortBreakDuration() > maxPomodoroBreak || config.LongBreakDuration() > maxPomodoroBreak:
+		return fmt.Errorf("%w: breaks should be at most %s", domain.ErrInvalidPomodoroConfig, domain.FormatDuration(maxPomodoroBreak))
+	case config.CycleRounds() > maxPomodoroRounds:
+		return fmt.Errorf("%w: rounds %d should be at most %d", domain.ErrInvalidPomodoroConfig, config.Rounds, maxPomodoroRounds)
+	}
+	return nil
+}
+
+// checkCycleLimits reports whether a cycle of config, with its breaks, stays
+// within the limit on cycles started over the socket.
+func checkCycleLimits(config domain.PomodoroConfig) error {
+	rounds := time.Duration(config.CycleRounds())
+	length := rounds*config.FocusDuration() + (rounds-1)*config.ShortBreakDuration() + config.LongBreakDuration()
+	if length > maxPomodoroCycle {
+		return fmt.Errorf("%w: a cycle of %s should be at most %s", domain.ErrInvalidPomodoroConfig, domain.FormatDuration(length), domain.FormatDuration(maxPomodoroCycle))
+	}
+	return nil
+}
+
+// duration returns the manual study time carried by the message.
+func (m wsMessage) duration() (time.Duration, error) {
+	if m.Duration != "" {
+		return domain.ParseDuration(m.Duration)
+	}
+	return domain.ParseDuration(strconv.FormatFloat(m.Hours, 'f', -1, 64))
 }
 
 type StudyServer struct {
-	store    domain.SubjectStore
-	template *template.Template
-	session  domain.SessionRunner
+	store          domain.SubjectStore
+	template       *template.Template
+	session        domain.SessionRunner
+	requestTimeout time.Duration
+	pomodoros      *pomodoroRegistry
+	requireAuth    bool
+	logins         *loginSessions
 	http.Handler
 }
 
@@ -55,28 +138,158 @@ func NewStudyServer(store domain.SubjectStore, session domain.SessionRunner) (*S
 	s.store = store
 	s.template = tmpl
 	s.session = session
+	s.requestTimeout = defaultRequestTimeout
+	s.pomodoros = newPomodoroRegistry()
+	s.logins = newLoginSessions()
 
 	router := http.NewServeMux()
-	router.Handle(reportPath, http.HandlerFunc(s.reportHandler))
-	router.Handle(trackerPath, http.HandlerFunc(s.trackerHandler))
+	router.Handle(reportPath, s.withTimeout(http.HandlerFunc(s.reportHandler)))
+	router.Handle(trackerPath, s.withTimeout(http.HandlerFunc(s.trackerHandler)))
+	router.Handle(sessionsPath, s.withTimeout(http.HandlerFunc(s.sessionsHandler)))
+	router.Handle(subjectsPath, s.withTimeout(http.HandlerFunc(s.subjectsHandler)))
+	router.Handle(goalsPath, s.withTimeout(http.HandlerFunc(s.goalsHandler)))
+	router.Handle(statsPath, s.withTimeout(http.HandlerFunc(s.statsHandler)))
+	router.Handle(exportPath, http.HandlerFunc(s.exportHandler))
+	router.Handle(importPath, http.HandlerFunc(s.importHandler))
 	router.Handle(studyPath, http.HandlerFunc(s.studyHandler))
 	router.Handle(websocketPath, http.HandlerFunc(s.webSocketHandler))
+	router.Handle(loginPath, http.HandlerFunc(s.loginHandler))
+	router.Handle(logoutPath, http.HandlerFunc(s.logoutHandler))
 
-	s.Handler = router
+	s.Handler = s.withAuth(s.withUser(router))
 
 	return s, nil
 }
 
+// ResumePomodoros carries on the Pomodoros that were running when the server
+// last stopped, or logs those whose focus time ran out in the meantime.
+// Clients reattach to them by session id.
+func (s *StudyServer) ResumePomodoros(ctx context.Context) error {
+	active, err := s.store.GetActivePomodoros(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load active pomodoros: %w", err)
+	}
+	for _, a := range active {
+		s.pomodoros.run(newWsPomodoro(a.ID, a.UserID, a.Subject), func(ctx context.Context, id string, out io.Writer, control *domain.PomodoroControl) error {
+			return s.session.ResumeActivePomodoro(ctx, a, out, control)
+		})
+	}
+	return nil
+}
+
+// Close stops the Pomodoros running on the server and waits for them. Those
+// started with start_pomodoro stay in the store for ResumePomodoros.
+func (s *StudyServer) Close() {
+	s.pomodoros.close()
+}
+
+// withUser puts the user named by the request into its context, so that the
+// store only sees that user's sessions. With RequireAuth anyone could name
+// any user, so the header and parameter are ignored and the user of the
+// credentials, set by withAuth, is kept.
+func (s *StudyServer) withUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.requireAuth {
+			next.ServeHTTP(w, r)
+			return
+		}
+		name := r.Header.Get(userHeader)
+		if name == "" {
+			name = r.URL.Query().Get(userParam)
+		}
+		if name == "" {
+			next.ServeHTTP(w, r.WithContext(domain.WithUser(r.Context(), domain.DefaultUser)))
+			return
+		}
+
+		user, err := domain.ParseUserID(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(domain.WithUser(r.Context(), user)))
+	})
+}
+
+// withTimeout enforces the request deadline on the context handed to the store.
+func (s *StudyServer) withTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), s.requestTimeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// writeStoreError answers 503 when the store gave up because the request
+// deadline passed and 500 for any other failure.
+func writeStoreError(w http.ResponseWriter, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusInternalServerError)
+}
+
 func (s *StudyServer) reportHandler(w http.ResponseWriter, r *http.Request) {
-	studyActivities, err := s.store.GetReport()
+	if !allowed(w, r, domain.ScopeRead) {
+		return
+	}
+	period, err := parseReportRange(r.URL.Query(), s.session.Now())
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	studyActivities, err := s.store.GetReport(r.Context(), period)
+	if err != nil {
+		writeStoreError(w, err)
 		return
 	}
-	w.Header().Set("content-type", jsonContentType)
-	if err := json.NewEncoder(w).Encode(studyActivities); err != nil {
-		log.Println("failed to encode:", err)
+	writeJSON(w, studyActivities)
+}
+
+// parseReportRange builds the report window from the optional "period",
+// "from" and "to" query parameters. Bounds are RFC 3339 timestamps or
+// YYYY-MM-DD dates; a date given as "to" includes that whole day.
+func parseReportRange(query url.Values, now time.Time) (domain.TimeRange, error) {
+	period, err := domain.ParsePeriod(query.Get("period"), now)
+	if err != nil {
+		return domain.TimeRange{}, err
+	}
+
+	if from := query.Get("from"); from != "" {
+		t, _, err := parseReportTime(from)
+		if err != nil {
+			return domain.TimeRange{}, err
+		}
+		period.From = t
+	}
+	if to := query.Get("to"); to != "" {
+		t, isDate, err := parseReportTime(to)
+		if err != nil {
+			return domain.TimeRange{}, err
+		}
+		if isDate {
+			t = t.AddDate(0, 0, 1)
+		}
+		period.To = t
+	}
+
+	if !period.From.IsZero() && !period.To.IsZero() && !period.From.Before(period.To) {
+		return domain.TimeRange{}, fmt.Errorf("%w: from %s is not before to %s", domain.ErrInvalidPeriod, period.From, period.To)
+	}
+	return period, nil
+}
+
+func parseReportTime(value string) (t time.Time, isDate bool, err error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w %q: %v", domain.ErrInvalidPeriod, value, err)
 	}
+	return t, false, nil
 }
 
 func (s *StudyServer) trackerHandler(w http.ResponseWriter, r *http.Request) {
@@ -89,20 +302,28 @@ func (s *StudyServer) trackerHandler(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case http.MethodPost:
-		s.processPostRequest(w, r, subject)
+		if allowed(w, r, domain.ScopeRecord) {
+			s.processPostRequest(w, r, subject)
+		}
 	case http.MethodGet:
-		s.processGetRequest(w, subject)
+		if allowed(w, r, domain.ScopeRead) {
+			s.processGetRequest(w, r, subject)
+		}
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
 
 func (s *StudyServer) studyHandler(w http.ResponseWriter, r *http.Request) {
-	s.template.Execute(w, nil)
+	s.template.Execute(w, struct{ Auth bool }{Auth: s.requireAuth})
 }
 
 type studyServerWs struct {
 	*websocket.Conn
+	writeMu sync.Mutex // serialises writes from the command loop and running Pomodoros
+
+	pomodoroMu sync.Mutex
+	pomodoro   *wsPomodoro // the Pomodoro started on or attached to the connection
 }
 
 func newStudyServerWs(w http.ResponseWriter, r *http.Request) *studyServerWs {
@@ -110,79 +331,225 @@ func newStudyServerWs(w http.ResponseWriter, r *http.Request) *studyServerWs {
 	if err != nil {
 		log.Printf("failed to upgrade connection to websocket: %v\n", err)
 	}
-	return &studyServerWs{conn}
+	return &studyServerWs{Conn: conn}
 }
 
-func (ws *studyServerWs) Write(p []byte) (n int, err error) {
-	err = ws.WriteMessage(websocket.TextMessage, p)
-	if err != nil {
-		return 0, err
+// writeEvent sends one event in the versioned envelope.
+func (ws *studyServerWs) writeEvent(event Event) error {
+	event.Version = ProtocolVersion
+	event.Timestamp = time.Now().UTC()
+
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	return ws.WriteJSON(event)
+}
+
+func (ws *studyServerWs) writeAck(command, subject, message string) {
+	ws.writeEvent(Event{Type: EventAck, Subject: subject, Payload: AckPayload{Command: command, Message: message}})
+}
+
+func (ws *studyServerWs) writeError(command, subject, message string) {
+	ws.writeEvent(Event{Type: EventError, Subject: subject, Payload: ErrorPayload{Command: command, Message: message}})
+}
+
+// claimPomodoro makes p the Pomodoro of the connection and attaches to it.
+// It reports false when another Pomodoro is running on the connection.
+func (ws *studyServerWs) claimPomodoro(p *wsPomodoro) bool {
+	ws.pomodoroMu.Lock()
+	defer ws.pomodoroMu.Unlock()
+	if ws.pomodoro != nil && ws.pomodoro != p && !ws.pomodoro.done() {
+		return false
+	}
+	ws.pomodoro = p
+	p.attach(ws)
+	return true
+}
+
+// runningPomodoro returns the Pomodoro of the connection unless it is over.
+func (ws *studyServerWs) runningPomodoro() *wsPomodoro {
+	ws.pomodoroMu.Lock()
+	defer ws.pomodoroMu.Unlock()
+	if ws.pomodoro == nil || ws.pomodoro.done() {
+		return nil
 	}
-	return len(p), nil
+	return ws.pomodoro
 }
 
 func (s *StudyServer) webSocketHandler(w http.ResponseWriter, r *http.Request) {
 	ws := newStudyServerWs(w, r)
 	defer ws.Close()
 
+	// Pomodoros run in the background, beyond the connection: one that is
+	// left behind keeps running until a client attaches to it again.
+	defer func() {
+		if p := ws.runningPomodoro(); p != nil {
+			p.detach(ws)
+		}
+	}()
+
+	// The connection context is cancelled as soon as the client goes away,
+	// which aborts any store call made for it.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
 	for {
 		_, msgBytes, err := ws.ReadMessage()
 		if err != nil {
 			log.Printf("websocket read error: %v", err)
-			break
+			return
 		}
 
 		var msg wsMessage
 		if err := json.Unmarshal(msgBytes, &msg); err != nil {
 			log.Printf("failed to parse websocket message: %v", err)
-			ws.WriteMessage(websocket.TextMessage, []byte("invalid message format"))
+			ws.writeError("", "", "invalid message format")
+			continue
+		}
+		if msg.Version != 0 && msg.Version != ProtocolVersion {
+			ws.writeError(msg.Command, msg.Subject, fmt.Sprintf("unsupported protocol version %d, should be %d", msg.Version, ProtocolVersion))
 			continue
 		}
 
-		s.routeCommands(msg, ws)
+		s.routeCommands(ctx, msg, ws)
 	}
 }
 
-func (s *StudyServer) routeCommands(msg wsMessage, ws *studyServerWs) {
+// controlPomodoro applies a pause, resume or cancel command to the running Pomodoro.
+func controlPomodoro(command string, ws *studyServerWs) {
+	pomodoro := ws.runningPomodoro()
+	if pomodoro == nil {
+		ws.writeError(command, "", "no pomodoro is running")
+		return
+	}
+
+	var err error
+	var message string
+	switch command {
+	case "pause_pomodoro":
+		message = "Pomodoro paused"
+		err = pomodoro.control.Pause()
+	case "resume_pomodoro":
+		message = "Pomodoro resumed"
+		err = pomodoro.control.Resume()
+	case "cancel_pomodoro":
+		// Acknowledged by the complete event once the Pomodoro has stopped.
+		err = pomodoro.control.Cancel()
+	}
+
+	event := Event{SessionID: pomodoro.id, Subject: pomodoro.subject}
+	switch {
+	case err != nil:
+		event.Type, event.Payload = EventError, ErrorPayload{Command: command, Message: err.Error()}
+	case message != "":
+		event.Type, event.Payload = EventAck, AckPayload{Command: command, Message: message}
+	default:
+		return
+	}
+	ws.writeEvent(event)
+}
+
+// writeStatus reports whether a Pomodoro is running on the connection.
+func writeStatus(ws *studyServerWs) {
+	pomodoro := ws.runningPomodoro()
+	if pomodoro == nil {
+		ws.writeEvent(Event{Type: EventStatus, Payload: StatusPayload{State: StateIdle}})
+		return
+	}
+	ws.writeEvent(Event{Type: EventStatus, SessionID: pomodoro.id, Subject: pomodoro.subject, Payload: StatusPayload{State: string(pomodoro.control.State())}})
+}
+
+// routeCommands handles one message. It never blocks for the length of a
+// Pomodoro: those run in the background and report through events.
+func (s *StudyServer) routeCommands(ctx context.Context, msg wsMessage, ws *studyServerWs) {
+	// Watching a Pomodoro only needs read access, everything else records.
+	required := domain.ScopeRecord
+	if msg.Command == "status" || msg.Command == "attach" {
+		required = domain.ScopeRead
+	}
+	if !scopeFromContext(ctx).Allows(required) {
+		ws.writeError(msg.Command, msg.Subject, fmt.Sprintf("token scope does not allow %s", required))
+		return
+	}
+
 	switch msg.Command {
-	case "start_pomodoro":
-		if err := s.session.RecordPomodoro(msg.Subject, ws); err != nil {
-			ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("failed to start pomodoro session for %q: %v", msg.Subject, err)))
+	case "start_pomodoro", "start_pomodoro_cycle":
+		config, err := msg.pomodoroConfig()
+		if err == nil && msg.Command == "start_pomodoro_cycle" {
+			err = checkCycleLimits(config)
 		}
+		if err != nil {
+			ws.writeError(msg.Command, msg.Subject, err.Error())
+			return
+		}
+		pomodoro := newWsPomodoro(newSessionID(), domain.UserFromContext(ctx), msg.Subject)
+		if !ws.claimPomodoro(pomodoro) {
+			ws.writeError(msg.Command, msg.Subject, "a pomodoro is already running")
+			return
+		}
+		pomodoro.writeEvent(EventAck, AckPayload{Command: msg.Command, Message: "Pomodoro started"})
+
+		s.pomodoros.run(pomodoro, func(ctx context.Context, id string, out io.Writer, control *domain.PomodoroControl) error {
+			if msg.Command == "start_pomodoro_cycle" {
+				return s.session.RecordActivePomodoroCycle(ctx, id, msg.Subject, config, out, control)
+			}
+			return s.session.RecordActivePomodoro(ctx, id, msg.Subject, config, out, control)
+		})
+	case "attach":
+		pomodoro := s.pomodoros.get(msg.SessionID)
+		if pomodoro == nil || pomodoro.done() || pomodoro.user != domain.UserFromContext(ctx) {
+			ws.writeError(msg.Command, "", fmt.Sprintf("no pomodoro %q is running", msg.SessionID))
+			return
+		}
+		if !ws.claimPomodoro(pomodoro) {
+			ws.writeError(msg.Command, pomodoro.subject, "a pomodoro is already running")
+			return
+		}
+		ws.writeEvent(pomodoro.event(EventAck, AckPayload{Command: msg.Command, Message: "Attached to pomodoro"}))
+	case "pause_pomodoro", "resume_pomodoro", "cancel_pomodoro":
+		controlPomodoro(msg.Command, ws)
+	case "status":
+		writeStatus(ws)
 	case "record_manual":
-		if err := s.session.RecordManual(msg.Subject, msg.Hours); err != nil {
-			ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("failed to record hours for %q: %v", msg.Subject, err)))
+		d, err := msg.duration()
+		if err != nil {
+			ws.writeError(msg.Command, msg.Subject, err.Error())
+			return
+		}
+		// Bounded like an HTTP request so that a slow store cannot stall the connection.
+		ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+		defer cancel()
+		if _, err := s.session.RecordManual(ctx, msg.Subject, d); err != nil {
+			ws.writeError(msg.Command, msg.Subject, err.Error())
 		} else {
-			ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("Recorded %d hours for %q", msg.Hours, msg.Subject)))
+			ws.writeAck(msg.Command, msg.Subject, fmt.Sprintf("Recorded %s for %q", domain.FormatDuration(d), msg.Subject))
 		}
 	default:
-		ws.WriteMessage(websocket.TextMessage, []byte("invalid command"))
+		ws.writeError(msg.Command, msg.Subject, "invalid command")
 	}
 }
 
-func (s *StudyServer) processGetRequest(w http.ResponseWriter, subject string) {
-	hours, err := s.store.GetHours(subject)
+func (s *StudyServer) processGetRequest(w http.ResponseWriter, r *http.Request, subject string) {
+	duration, err := s.store.GetHours(r.Context(), subject)
 	if err != nil {
 		if errors.Is(err, domain.ErrSubjectNotFound) {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
-		w.WriteHeader(http.StatusInternalServerError)
+		writeStoreError(w, err)
 		return
 	}
-	fmt.Fprint(w, hours)
+	fmt.Fprint(w, domain.FormatHours(duration))
 }
 
 func (s *StudyServer) processPostRequest(w http.ResponseWriter, r *http.Request, subject string) {
-	h, err := strconv.Atoi(r.URL.Query().Get("hours"))
-	if err != nil || h <= 0 {
+	d, err := domain.ParseDuration(r.URL.Query().Get("hours"))
+	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	err = s.store.RecordHour(subject, h)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	if _, err := s.session.RecordManual(r.Context(), subject, d); err != nil {
+		writeStoreError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusAccepted)
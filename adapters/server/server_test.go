@@ -1,12 +1,12 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -15,18 +15,18 @@ import (
 	"github.com/bryack/study_hours_tracker/adapters/database"
 	"github.com/bryack/study_hours_tracker/domain"
 	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/bryack/study_hours_tracker/testhelpers/storetest"
 	"github.com/gorilla/websocket"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGETSubjects(t *testing.T) {
-	store := &testhelpers.StubSubjectStore{
-		Hours: map[string]int{
-			"tdd":  20,
-			"http": 10,
-		},
-	}
+	store := database.NewInMemorySubjectStore()
+	storetest.RecordHours(t, t.Context(), store, "tdd", 20*time.Hour)
+	storetest.RecordHours(t, t.Context(), store, "http", 10*time.Hour)
+	storetest.RecordHours(t, t.Context(), store, "go", 90*time.Minute)
 	server := mustMakeStudyServer(t, store, &testhelpers.SpySession{})
 
 	t.Run("returns TDD hours", func(t *testing.T) {
@@ -56,6 +56,19 @@ func TestGETSubjects(t *testing.T) {
 		assert.Equal(t, response.Body.String(), httpHours)
 	})
 
+	t.Run("returns fractional go hours", func(t *testing.T) {
+		request, err := http.NewRequest(http.MethodGet, "/tracker/go", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "1.5", response.Body.String())
+	})
+
 	t.Run("handle 404", func(t *testing.T) {
 		request, err := http.NewRequest(http.MethodGet, "/tracker/java", nil)
 		if err != nil {
@@ -70,7 +83,8 @@ func TestGETSubjects(t *testing.T) {
 
 	t.Run("returns 500 when store fails", func(t *testing.T) {
 		failedStore := &testhelpers.StubSubjectStore{
-			GetHoursErr: errors.New("database connection lost"),
+			SubjectStore: database.NewInMemorySubjectStore(),
+			GetHoursErr:  errors.New("database connection lost"),
 		}
 		failedServer := mustMakeStudyServer(t, failedStore, &testhelpers.SpySession{})
 		request, err := http.NewRequest(http.MethodGet, "/tracker/tdd", nil)
@@ -85,63 +99,81 @@ func TestGETSubjects(t *testing.T) {
 
 func TestPostHoursToSubject(t *testing.T) {
 	tests := []struct {
-		name          string
-		path          string
-		subjectsSlice []string
-		numHours      int
-		expectedCode  int
-		recordHourErr error
+		name         string
+		path         string
+		recorded     map[string]time.Duration
+		expectedCode int
+		recordErr    error
 	}{
 		{
-			name:          "record TDD hours as positive number",
-			path:          "/tracker/tdd?hours=5",
-			subjectsSlice: []string{"tdd"},
-			numHours:      5,
-			expectedCode:  202,
-			recordHourErr: nil,
+			name:         "record TDD hours as positive number",
+			path:         "/tracker/tdd?hours=5",
+			recorded:     map[string]time.Duration{"tdd": 5 * time.Hour},
+			expectedCode: 202,
+			recordErr:    nil,
 		},
 		{
-			name:          "record http hours as string",
-			path:          "/tracker/tdd?hours=aaa",
-			subjectsSlice: []string{},
-			numHours:      0,
-			expectedCode:  400,
-			recordHourErr: nil,
+			name:         "record TDD hours and minutes",
+			path:         "/tracker/tdd?hours=1h30m",
+			recorded:     map[string]time.Duration{"tdd": 90 * time.Minute},
+			expectedCode: 202,
+			recordErr:    nil,
 		},
 		{
-			name:          "record http hours as negative number",
-			path:          "/tracker/tdd?hours=-1",
-			subjectsSlice: []string{},
-			numHours:      0,
-			expectedCode:  400,
-			recordHourErr: nil,
+			name:         "record TDD minutes",
+			path:         "/tracker/tdd?hours=45m",
+			recorded:     map[string]time.Duration{"tdd": 45 * time.Minute},
+			expectedCode: 202,
+			recordErr:    nil,
 		},
 		{
-			name:          "expected 500",
-			path:          "/tracker/db?hours=2",
-			subjectsSlice: []string{},
-			numHours:      0,
-			expectedCode:  500,
-			recordHourErr: errors.New("persistent storage failure"),
+			name:         "record http hours as string",
+			path:         "/tracker/tdd?hours=aaa",
+			recorded:     map[string]time.Duration{},
+			expectedCode: 400,
+			recordErr:    nil,
 		},
 		{
-			name:          "empty subject",
-			path:          "/tracker/?hours=2",
-			subjectsSlice: []string{},
-			numHours:      0,
-			expectedCode:  400,
-			recordHourErr: nil,
+			name:         "record http hours as negative number",
+			path:         "/tracker/tdd?hours=-1",
+			recorded:     map[string]time.Duration{},
+			expectedCode: 400,
+			recordErr:    nil,
+		},
+		{
+			name:         "record less than a second",
+			path:         "/tracker/tdd?hours=0.0001",
+			recorded:     map[string]time.Duration{},
+			expectedCode: 400,
+			recordErr:    nil,
+		},
+		{
+			name:         "record hours that overflow",
+			path:         "/tracker/tdd?hours=1e10",
+			recorded:     map[string]time.Duration{},
+			expectedCode: 400,
+			recordErr:    nil,
+		},
+		{
+			name:         "expected 500",
+			path:         "/tracker/db?hours=2",
+			recorded:     map[string]time.Duration{"db": 2 * time.Hour},
+			expectedCode: 500,
+			recordErr:    errors.New("persistent storage failure"),
+		},
+		{
+			name:         "empty subject",
+			path:         "/tracker/?hours=2",
+			recorded:     map[string]time.Duration{},
+			expectedCode: 400,
+			recordErr:    nil,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			store := &testhelpers.StubSubjectStore{
-				Hours:         map[string]int{},
-				RecordCall:    []string{},
-				RecordHourErr: tt.recordHourErr,
-			}
-			server := mustMakeStudyServer(t, store, &testhelpers.SpySession{})
+			session := &testhelpers.SpySession{ManualCalls: map[string]time.Duration{}, StubRecordErr: tt.recordErr}
+			server := mustMakeStudyServer(t, database.NewInMemorySubjectStore(), session)
 			request, err := http.NewRequest(http.MethodPost, tt.path, nil)
 			if err != nil {
 				t.Fatal(err)
@@ -150,16 +182,52 @@ func TestPostHoursToSubject(t *testing.T) {
 			server.ServeHTTP(response, request)
 
 			assert.Equal(t, tt.expectedCode, response.Code)
-			assert.Equal(t, tt.subjectsSlice, store.RecordCall)
-			assert.Equal(t, tt.numHours, store.Hours["tdd"])
+			assert.Equal(t, tt.recorded, session.ManualCalls)
 		})
 	}
+
+	t.Run("records a session that ends at the clock's now", func(t *testing.T) {
+		store := database.NewInMemorySubjectStore()
+		now := time.Date(2026, 3, 18, 15, 0, 0, 0, time.UTC)
+		server := mustMakeStudyServer(t, store, domain.NewStudySession(store, nil, testhelpers.NewFakeClock(now)))
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/tracker/tdd?hours=2", nil))
+
+		assert.Equal(t, http.StatusAccepted, response.Code)
+		sessions, err := store.GetSessions(t.Context(), "tdd")
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, now.Add(-2*time.Hour), sessions[0].StartedAt)
+		assert.Equal(t, now, sessions[0].EndedAt)
+	})
+}
+
+// blockingStore never answers before the caller's context is done.
+type blockingStore struct {
+	domain.SubjectStore
+}
+
+func (b *blockingStore) GetHours(ctx context.Context, subject string) (time.Duration, error) {
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server := mustMakeStudyServer(t, &blockingStore{database.NewInMemorySubjectStore()}, &testhelpers.SpySession{})
+	server.requestTimeout = 10 * time.Millisecond
+
+	request, err := http.NewRequest(http.MethodGet, "/tracker/tdd", nil)
+	assert.NoError(t, err)
+	response := httptest.NewRecorder()
+
+	within(t, 500*time.Millisecond, func() { server.ServeHTTP(response, request) })
+
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
 }
 
 func TestMethodNotAllowed(t *testing.T) {
-	store := &testhelpers.StubSubjectStore{
-		Hours: map[string]int{},
-	}
+	store := database.NewInMemorySubjectStore()
 	server := mustMakeStudyServer(t, store, &testhelpers.SpySession{})
 	t.Run("handle 405", func(t *testing.T) {
 		request, err := http.NewRequest(http.MethodPut, "/tracker/tdd", nil)
@@ -174,19 +242,12 @@ func TestMethodNotAllowed(t *testing.T) {
 	})
 }
 
-func TestRacePostgresSubjectStore(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping race condition test in short mode")
-	}
-	connStr := testhelpers.SetupTestContainer(t)
-	store, err := database.NewPostgresSubjectStore(connStr)
-	if err != nil {
-		t.Fatalf("failed to create store: %v", err)
-	}
-	server := mustMakeStudyServer(t, store, &testhelpers.SpySession{})
+func TestRaceSubjectStore(t *testing.T) {
+	store := database.NewInMemorySubjectStore()
+	server := mustMakeStudyServer(t, store, domain.NewStudySession(store, nil, testhelpers.NewFakeClock(time.Now())))
 
 	const concurrentRequests = 100
-	const hoursPerRequest = 2
+	const hoursPerRequest = 2 * time.Hour
 
 	var wg sync.WaitGroup
 
@@ -201,7 +262,7 @@ func TestRacePostgresSubjectStore(t *testing.T) {
 	}
 	wg.Wait()
 
-	got, err := store.GetHours("tdd")
+	got, err := store.GetHours(t.Context(), "tdd")
 	assert.NoError(t, err)
 
 	expected := concurrentRequests * hoursPerRequest
@@ -209,15 +270,8 @@ func TestRacePostgresSubjectStore(t *testing.T) {
 }
 
 func TestRecordingHoursAndRetrievingThem(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test in short mode")
-	}
-	connStr := testhelpers.SetupTestContainer(t)
-	store, err := database.NewPostgresSubjectStore(connStr)
-	if err != nil {
-		t.Fatalf("failed to create store: %v", err)
-	}
-	server := mustMakeStudyServer(t, store, &testhelpers.SpySession{})
+	store := database.NewInMemorySubjectStore()
+	server := mustMakeStudyServer(t, store, domain.NewStudySession(store, nil, testhelpers.NewFakeClock(time.Now())))
 
 	postReq, err := http.NewRequest(http.MethodPost, "/tracker/tdd?hours=1", nil)
 	if err != nil {
@@ -234,19 +288,50 @@ func TestRecordingHoursAndRetrievingThem(t *testing.T) {
 	server.ServeHTTP(response, getReq)
 
 	assert.Equal(t, http.StatusOK, response.Code)
-	h, err := store.GetHours("tdd")
+	h, err := store.GetHours(t.Context(), "tdd")
 	assert.NoError(t, err)
-	assert.Equal(t, strconv.Itoa(h), response.Body.String())
+	assert.Equal(t, domain.FormatHours(h), response.Body.String())
+}
+
+func TestUsers(t *testing.T) {
+	store := database.NewInMemorySubjectStore()
+	server := mustMakeStudyServer(t, store, domain.NewStudySession(store, nil, testhelpers.NewFakeClock(time.Now())))
+
+	post := func(t *testing.T, target, user string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, target, nil)
+		if user != "" {
+			request.Header.Set(userHeader, user)
+		}
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("records for the user named by the header or the query", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, post(t, "/tracker/tdd?hours=2", "alice").Code)
+		assert.Equal(t, http.StatusAccepted, post(t, "/tracker/tdd?hours=1&user=bob", "").Code)
+		assert.Equal(t, http.StatusAccepted, post(t, "/tracker/tdd?hours=3", "").Code)
+
+		for user, want := range map[domain.UserID]time.Duration{"alice": 2 * time.Hour, "bob": time.Hour, domain.DefaultUser: 3 * time.Hour} {
+			h, err := store.GetHours(domain.WithUser(t.Context(), user), "tdd")
+			assert.NoError(t, err)
+			assert.Equal(t, want, h, "hours of %s", user)
+		}
+	})
+	t.Run("returns 400 for an invalid user", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, post(t, "/tracker/tdd?hours=1", "alice smith").Code)
+	})
 }
 
 func TestReport(t *testing.T) {
 	t.Run("returns 200 on /report", func(t *testing.T) {
 		wantedReport := domain.Report{
-			{Subject: "Docker", Hours: 4},
-			{Subject: "TDD", Hours: 6},
+			{Subject: "TDD", Duration: 6*time.Hour + 15*time.Minute},
+			{Subject: "Docker", Duration: 4 * time.Hour},
 		}
-		store := &testhelpers.StubSubjectStore{
-			Report: wantedReport,
+		store := database.NewInMemorySubjectStore()
+		for _, activity := range wantedReport {
+			storetest.RecordHours(t, t.Context(), store, activity.Subject, activity.Duration)
 		}
 		server := mustMakeStudyServer(t, store, &testhelpers.SpySession{})
 		request, err := http.NewRequest(http.MethodGet, "/report", nil)
@@ -262,9 +347,55 @@ func TestReport(t *testing.T) {
 		assert.Equal(t, jsonContentType, response.Result().Header.Get("content-type"))
 
 	})
+	t.Run("passes date range to the store", func(t *testing.T) {
+		store := &testhelpers.StubSubjectStore{SubjectStore: database.NewInMemorySubjectStore()}
+		server := mustMakeStudyServer(t, store, &testhelpers.SpySession{})
+		request, err := http.NewRequest(http.MethodGet, "/report?from=2026-03-01&to=2026-03-14", nil)
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, domain.TimeRange{
+			From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local),
+			To:   time.Date(2026, 3, 15, 0, 0, 0, 0, time.Local),
+		}, store.ReportRange)
+	})
+	t.Run("accepts RFC 3339 bounds and named periods", func(t *testing.T) {
+		store := &testhelpers.StubSubjectStore{SubjectStore: database.NewInMemorySubjectStore()}
+		server := mustMakeStudyServer(t, store, &testhelpers.SpySession{StubNow: testNow})
+		request, err := http.NewRequest(http.MethodGet, "/report?period=month&to=2099-03-14T12:00:00Z", nil)
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, domain.ThisMonth(testNow).From, store.ReportRange.From, "the month should be the session's")
+		assert.True(t, time.Date(2099, 3, 14, 12, 0, 0, 0, time.UTC).Equal(store.ReportRange.To))
+	})
+	t.Run("handle invalid range with 400", func(t *testing.T) {
+		paths := []string{
+			"/report?from=yesterday",
+			"/report?period=year",
+			"/report?from=2026-03-14&to=2026-03-01",
+		}
+		for _, path := range paths {
+			store := database.NewInMemorySubjectStore()
+			server := mustMakeStudyServer(t, store, &testhelpers.SpySession{})
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			assert.NoError(t, err)
+			response := httptest.NewRecorder()
+
+			server.ServeHTTP(response, request)
+
+			assert.Equal(t, http.StatusBadRequest, response.Code, path)
+		}
+	})
 	t.Run("handle 500", func(t *testing.T) {
 		store := &testhelpers.StubSubjectStore{
-			Hours:        map[string]int{},
+			SubjectStore: database.NewInMemorySubjectStore(),
 			GetReportErr: errors.New("database connection failed"),
 		}
 		server := mustMakeStudyServer(t, store, &testhelpers.SpySession{})
@@ -292,7 +423,7 @@ func getReportFromResponse(t testing.TB, body io.Reader) domain.Report {
 func TestStudy(t *testing.T) {
 
 	t.Run("GET /study returns 200", func(t *testing.T) {
-		store := &testhelpers.StubSubjectStore{}
+		store := database.NewInMemorySubjectStore()
 		server := mustMakeStudyServer(t, store, &testhelpers.SpySession{})
 		request := newStudyRequest(t)
 		response := httptest.NewRecorder()
@@ -303,15 +434,12 @@ func TestStudy(t *testing.T) {
 	})
 	t.Run("upgrade request to websocket, send two messages, send alert", func(t *testing.T) {
 		wantedScheduleAlert := "Session started. Stay focused!"
-		store := &testhelpers.StubSubjectStore{
-			Hours:      map[string]int{},
-			RecordCall: []string{},
-		}
+		store := database.NewInMemorySubjectStore()
 		pomodoroMessage := `{"command":"start_pomodoro","subject":"websocket"}`
 		manualRecordMessage := `{"command":"record_manual","subject":"tdd","hours":3}`
 
 		session := &testhelpers.SpySession{
-			ManualCalls:   map[string]int{},
+			ManualCalls:   map[string]time.Duration{},
 			PomodoroCalls: []string{},
 			ScheduleAlert: []byte(wantedScheduleAlert),
 		}
@@ -325,16 +453,424 @@ func TestStudy(t *testing.T) {
 		defer conn.Close()
 
 		writeWSMessage(t, manualRecordMessage, conn)
-		within(t, 10*time.Millisecond, func() { assertWebsocketGotMsg(t, conn, "Recorded 3 hours for \"tdd\"") })
+		within(t, 10*time.Millisecond, func() { assertWebsocketGotEvent(t, conn, EventAck, "Recorded 3h for \"tdd\"") })
 		writeWSMessage(t, pomodoroMessage, conn)
-		within(t, 10*time.Millisecond, func() { assertWebsocketGotMsg(t, conn, wantedScheduleAlert) })
+		within(t, 10*time.Millisecond, func() {
+			started := assertWebsocketGotEvent(t, conn, EventAck, "Pomodoro started")
+			alert := assertWebsocketGotEvent(t, conn, EventAlert, wantedScheduleAlert)
+			complete := readEvent(t, conn)
+
+			assert.NotEmpty(t, started.SessionID)
+			assert.Equal(t, "websocket", started.Subject)
+			assert.Equal(t, started.SessionID, alert.SessionID, "alerts should carry the session id")
+			assert.Equal(t, EventComplete, complete.Type)
+			assert.JSONEq(t, `{"status":"finished"}`, string(complete.Payload))
+		})
 
-		assertSessionManualCalls(t, session, map[string]int{"tdd": 3})
+		assertSessionManualCalls(t, session, map[string]time.Duration{"tdd": 3 * time.Hour})
 		assertSessionPomodoroCalls(t, session, []string{"websocket"})
 	})
 }
 
-func assertSessionManualCalls(t testing.TB, session *testhelpers.SpySession, storeMap map[string]int) {
+// blockingPomodoroSession runs a Pomodoro that only ends when its context or
+// its control is cancelled, reporting every state change it sees.
+type blockingPomodoroSession struct {
+	testhelpers.SpySession
+	started   chan struct{}
+	cancelled chan struct{}
+}
+
+func (b *blockingPomodoroSession) RecordActivePomodoro(ctx context.Context, id, subject string, config domain.PomodoroConfig, out io.Writer, control *domain.PomodoroControl) error {
+	close(b.started)
+	defer close(b.cancelled)
+	for {
+		changed := control.Changed()
+		if control.State() == domain.PomodoroCancelled {
+			return domain.ErrPomodoroCancelled
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func TestWebSocketReattach(t *testing.T) {
+	session := &blockingPomodoroSession{
+		started:   make(chan struct{}),
+		cancelled: make(chan struct{}),
+	}
+	studyServer := mustMakeStudyServer(t, database.NewInMemorySubjectStore(), session)
+	server := httptest.NewServer(studyServer)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	conn := mustDialWS(t, wsURL)
+
+	writeWSMessage(t, `{"command":"start_pomodoro","subject":"websocket"}`, conn)
+	within(t, 500*time.Millisecond, func() { <-session.started })
+	started := assertWebsocketGotEvent(t, conn, EventAck, "Pomodoro started")
+	conn.Close()
+
+	select {
+	case <-session.cancelled:
+		t.Fatal("closing the connection should not stop the pomodoro")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	conn = mustDialWS(t, wsURL)
+	defer conn.Close()
+
+	writeWSMessage(t, `{"command":"attach","session_id":"unknown"}`, conn)
+	within(t, 500*time.Millisecond, func() { assertWebsocketGotEvent(t, conn, EventError, `no pomodoro "unknown" is running`) })
+
+	other := mustDialWS(t, wsURL+"?user=mallory")
+	defer other.Close()
+	writeWSMessage(t, `{"command":"attach","session_id":"`+started.SessionID+`"}`, other)
+	within(t, 500*time.Millisecond, func() {
+		assertWebsocketGotEvent(t, other, EventError, `no pomodoro "`+started.SessionID+`" is running`)
+	})
+
+	writeWSMessage(t, `{"command":"attach","session_id":"`+started.SessionID+`"}`, conn)
+	within(t, 500*time.Millisecond, func() {
+		attached := assertWebsocketGotEvent(t, conn, EventAck, "Attached to pomodoro")
+		assert.Equal(t, started.SessionID, attached.SessionID)
+		assert.Equal(t, "websocket", attached.Subject)
+	})
+
+	writeWSMessage(t, `{"command":"cancel_pomodoro"}`, conn)
+	within(t, 500*time.Millisecond, func() { <-session.cancelled })
+	within(t, 500*time.Millisecond, func() {
+		complete := readEvent(t, conn)
+		assert.Equal(t, EventComplete, complete.Type)
+		assert.Equal(t, started.SessionID, complete.SessionID)
+	})
+}
+
+func TestResumePomodoros(t *testing.T) {
+	active := domain.ActivePomodoro{
+		ID:        "abc",
+		UserID:    domain.DefaultUser,
+		Subject:   "websocket",
+		StartedAt: time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC),
+		EndsAt:    time.Date(2026, 3, 16, 9, 25, 0, 0, time.UTC),
+		Focus:     25 * time.Minute,
+	}
+
+	t.Run("resumes every saved pomodoro", func(t *testing.T) {
+		store := database.NewInMemorySubjectStore()
+		require.NoError(t, store.SaveActivePomodoro(t.Context(), active))
+		session := &testhelpers.SpySession{}
+		studyServer := mustMakeStudyServer(t, store, session)
+
+		assert.NoError(t, studyServer.ResumePomodoros(t.Context()))
+		studyServer.Close()
+
+		assert.Equal(t, []domain.ActivePomodoro{active}, session.ResumeCalls)
+	})
+	t.Run("records resumed pomodoros for their owner", func(t *testing.T) {
+		store := database.NewInMemorySubjectStore()
+		alice := domain.WithUser(t.Context(), "alice")
+		require.NoError(t, store.SaveActivePomodoro(alice, active))
+		session := domain.NewStudySession(store, nil, testhelpers.NewFakeClock(active.EndsAt.Add(time.Hour)))
+		studyServer := mustMakeStudyServer(t, store, session)
+
+		assert.NoError(t, studyServer.ResumePomodoros(t.Context()))
+		studyServer.Close()
+
+		hours, err := store.GetHours(alice, "websocket")
+		assert.NoError(t, err)
+		assert.Equal(t, 25*time.Minute, hours)
+		_, err = store.GetHours(t.Context(), "websocket")
+		assert.ErrorIs(t, err, domain.ErrSubjectNotFound, "the default user should not get it")
+
+		remaining, err := store.GetActivePomodoros(t.Context())
+		assert.NoError(t, err)
+		assert.Empty(t, remaining)
+	})
+}
+
+func TestWebSocketPomodoroConfig(t *testing.T) {
+	session := &testhelpers.SpySession{ScheduleAlert: []byte("Session started. Stay focused!\n")}
+	studyServer := mustMakeStudyServer(t, database.NewInMemorySubjectStore(), session)
+	server := httptest.NewServer(studyServer)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	conn := mustDialWS(t, wsURL)
+	defer conn.Close()
+
+	writeWSMessage(t, `{"command":"start_pomodoro","subject":"go","duration":"50"}`, conn)
+	within(t, 500*time.Millisecond, func() {
+		assertWebsocketGotEvent(t, conn, EventError, `invalid pomodoro config: focus "50" should be a positive duration such as 50m`)
+	})
+	writeWSMessage(t, `{"command":"start_pomodoro","subject":"go","duration":"50m","pomodoro":{"alerts":[{"before":"5m","message":"5 minutes left"}]}}`, conn)
+	within(t, 500*time.Millisecond, func() {
+		assertWebsocketGotEvent(t, conn, EventAck, "Pomodoro started")
+		assertWebsocketGotEvent(t, conn, EventAlert, "Session started. Stay focused!")
+	})
+
+	assert.Equal(t, []domain.PomodoroConfig{{
+		Focus:  50 * time.Minute,
+		Alerts: []domain.PomodoroAlert{{At: 5 * time.Minute, FromEnd: true, Message: "5 minutes left"}},
+	}}, session.PomodoroConfigs)
+}
+
+func TestWebSocketPomodoroLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "rejects ticks under a second",
+			message: `{"command":"start_pomodoro","subject":"go","pomodoro":{"tick":"10ms"}}`,
+			want:    "invalid pomodoro config: tick 10ms should be at least 1s",
+		},
+		{
+			name:    "rejects a long focus",
+			message: `{"command":"start_pomodoro","subject":"go","duration":"5h"}`,
+			want:    "invalid pomodoro config: focus 5h should be at most 4h",
+		},
+		{
+			name:    "rejects long breaks",
+			message: `{"command":"start_pomodoro","subject":"go","pomodoro":{"long_break":"90m"}}`,
+			want:    "invalid pomodoro config: breaks should be at most 1h",
+		},
+		{
+			name:    "rejects too many rounds",
+			message: `{"command":"start_pomodoro","subject":"go","pomodoro":{"rounds":100}}`,
+			want:    "invalid pomodoro config: rounds 100 should be at most 12",
+		},
+		{
+			name:    "rejects ticks under a second in a cycle",
+			message: `{"command":"start_pomodoro_cycle","subject":"go","pomodoro":{"tick":"500ms"}}`,
+			want:    "invalid pomodoro config: tick 500ms should be at least 1s",
+		},
+		{
+			name:    "rejects a long focus in a cycle",
+			message: `{"command":"start_pomodoro_cycle","subject":"go","pomodoro":{"focus":"8h"}}`,
+			want:    "invalid pomodoro config: focus 8h should be at most 4h",
+		},
+		{
+			name:    "rejects long short breaks in a cycle",
+			message: `{"command":"start_pomodoro_cycle","subject":"go","pomodoro":{"short_break":"2h"}}`,
+			want:    "invalid pomodoro config: breaks should be at most 1h",
+		},
+		{
+			name:    "rejects too many rounds in a cycle",
+			message: `{"command":"start_pomodoro_cycle","subject":"go","pomodoro":{"rounds":13}}`,
+			want:    "invalid pomodoro config: rounds 13 should be at most 12",
+		},
+		{
+			name:    "rejects long cycles",
+			message: `{"command":"start_pomodoro_cycle","subject":"go","pomodoro":{"focus":"2h","rounds":6}}`,
+			want:    "invalid pomodoro config: a cycle of 12h40m should be at most 12h",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &testhelpers.SpySession{}
+			server := httptest.NewServer(mustMakeStudyServer(t, database.NewInMemorySubjectStore(), session))
+			defer server.Close()
+			conn := mustDialWS(t, "ws"+strings.TrimPrefix(server.URL, "http")+"/ws")
+			defer conn.Close()
+
+			writeWSMessage(t, tt.message, conn)
+			within(t, 500*time.Millisecond, func() {
+				assertWebsocketGotEvent(t, conn, EventError, tt.want)
+			})
+			writeWSMessage(t, `{"command":"status"}`, conn)
+			within(t, 500*time.Millisecond, func() {
+				event := readEvent(t, conn)
+				var status StatusPayload
+				assert.NoError(t, json.Unmarshal(event.Payload, &status))
+				assert.Equal(t, EventStatus, event.Type)
+				assert.Equal(t, StateIdle, status.State, "no pomodoro should run")
+			})
+			assert.Empty(t, session.PomodoroCalls)
+			assert.Empty(t, session.CycleCalls)
+		})
+	}
+}
+
+func TestWebSocketPomodoroCycle(t *testing.T) {
+	session := &testhelpers.SpySession{ScheduleAlert: []byte("Pomodoro 1 of 4")}
+	studyServer := mustMakeStudyServer(t, database.NewInMemorySubjectStore(), session)
+	server := httptest.NewServer(studyServer)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	conn := mustDialWS(t, wsURL)
+	defer conn.Close()
+
+	writeWSMessage(t, `{"command":"start_pomodoro_cycle","subject":"go","pomodoro":{"short_break":"10m","rounds":2}}`, conn)
+	within(t, 500*time.Millisecond, func() {
+		assertWebsocketGotEvent(t, conn, EventAck, "Pomodoro started")
+		assertWebsocketGotEvent(t, conn, EventAlert, "Pomodoro 1 of 4")
+	})
+
+	assert.Equal(t, []string{"go"}, session.CycleCalls)
+	assert.Empty(t, session.PomodoroCalls)
+	assert.Equal(t, []domain.PomodoroConfig{{ShortBreak: 10 * time.Minute, Rounds: 2}}, session.PomodoroConfigs)
+}
+
+// tickingPomodoroSession ticks once through the countdown writer it is given.
+type tickingPomodoroSession struct {
+	testhelpers.SpySession
+}
+
+func (s *tickingPomodoroSession) RecordActivePomodoro(ctx context.Context, id, subject string, config domain.PomodoroConfig, out io.Writer, control *domain.PomodoroControl) error {
+	return out.(interface{ WriteTick(time.Duration) error }).WriteTick(24*time.Minute + 59*time.Second)
+}
+
+func TestWebSocketPomodoroTicks(t *testing.T) {
+	studyServer := mustMakeStudyServer(t, database.NewInMemorySubjectStore(), &tickingPomodoroSession{})
+	server := httptest.NewServer(studyServer)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	conn := mustDialWS(t, wsURL)
+	defer conn.Close()
+
+	writeWSMessage(t, `{"command":"start_pomodoro","subject":"go"}`, conn)
+	within(t, 500*time.Millisecond, func() {
+		started := assertWebsocketGotEvent(t, conn, EventAck, "Pomodoro started")
+		tick := readEvent(t, conn)
+
+		assert.Equal(t, EventTick, tick.Type)
+		assert.Equal(t, started.SessionID, tick.SessionID)
+		assert.JSONEq(t, `{"remaining_seconds":1499}`, string(tick.Payload))
+	})
+}
+
+func TestWebSocketProtocolErrors(t *testing.T) {
+	studyServer := mustMakeStudyServer(t, database.NewInMemorySubjectStore(), &testhelpers.SpySession{})
+	server := httptest.NewServer(studyServer)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	conn := mustDialWS(t, wsURL)
+	defer conn.Close()
+
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{`not json`, "invalid message format"},
+		{`{"command":"dance"}`, "invalid command"},
+		{`{"version":2,"command":"record_manual","subject":"go","duration":"1h"}`, "unsupported protocol version 2, should be 1"},
+		{`{"command":"record_manual","subject":"go","duration":"soon"}`, `invalid duration "soon": time: invalid duration "soon"`},
+	}
+	for _, tt := range tests {
+		writeWSMessage(t, tt.message, conn)
+		within(t, 500*time.Millisecond, func() { assertWebsocketGotEvent(t, conn, EventError, tt.want) })
+	}
+}
+
+func TestWebSocketCommandsDuringPomodoro(t *testing.T) {
+	session := &blockingPomodoroSession{
+		SpySession: testhelpers.SpySession{ManualCalls: map[string]time.Duration{}},
+		started:    make(chan struct{}),
+		cancelled:  make(chan struct{}),
+	}
+	studyServer := mustMakeStudyServer(t, database.NewInMemorySubjectStore(), session)
+	server := httptest.NewServer(studyServer)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	conn := mustDialWS(t, wsURL)
+	defer conn.Close()
+
+	writeWSMessage(t, `{"command":"status"}`, conn)
+	within(t, 500*time.Millisecond, func() {
+		status := readEvent(t, conn)
+		assert.Equal(t, EventStatus, status.Type)
+		assert.JSONEq(t, `{"state":"idle"}`, string(status.Payload))
+	})
+
+	writeWSMessage(t, `{"command":"start_pomodoro","subject":"websocket"}`, conn)
+	within(t, 500*time.Millisecond, func() { <-session.started })
+	started := assertWebsocketGotEvent(t, conn, EventAck, "Pomodoro started")
+
+	writeWSMessage(t, `{"command":"record_manual","subject":"tdd","duration":"45m"}`, conn)
+	within(t, 500*time.Millisecond, func() { assertWebsocketGotEvent(t, conn, EventAck, `Recorded 45m for "tdd"`) })
+	assertSessionManualCalls(t, &session.SpySession, map[string]time.Duration{"tdd": 45 * time.Minute})
+
+	writeWSMessage(t, `{"command":"pause_pomodoro"}`, conn)
+	within(t, 500*time.Millisecond, func() { assertWebsocketGotEvent(t, conn, EventAck, "Pomodoro paused") })
+
+	writeWSMessage(t, `{"command":"status"}`, conn)
+	within(t, 500*time.Millisecond, func() {
+		status := readEvent(t, conn)
+		assert.Equal(t, EventStatus, status.Type)
+		assert.Equal(t, started.SessionID, status.SessionID)
+		assert.Equal(t, "websocket", status.Subject)
+		assert.JSONEq(t, `{"state":"paused"}`, string(status.Payload))
+	})
+
+	writeWSMessage(t, `{"command":"cancel_pomodoro"}`, conn)
+	within(t, 500*time.Millisecond, func() { <-session.cancelled })
+	within(t, 500*time.Millisecond, func() {
+		complete := readEvent(t, conn)
+		assert.Equal(t, EventComplete, complete.Type)
+	})
+}
+
+func TestWebSocketPomodoroControls(t *testing.T) {
+	session := &blockingPomodoroSession{
+		started:   make(chan struct{}),
+		cancelled: make(chan struct{}),
+	}
+	studyServer := mustMakeStudyServer(t, database.NewInMemorySubjectStore(), session)
+	server := httptest.NewServer(studyServer)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	conn := mustDialWS(t, wsURL)
+	defer conn.Close()
+
+	writeWSMessage(t, `{"command":"pause_pomodoro"}`, conn)
+	within(t, 500*time.Millisecond, func() { assertWebsocketGotEvent(t, conn, EventError, "no pomodoro is running") })
+
+	writeWSMessage(t, `{"command":"start_pomodoro","subject":"websocket"}`, conn)
+	within(t, 500*time.Millisecond, func() { <-session.started })
+	started := assertWebsocketGotEvent(t, conn, EventAck, "Pomodoro started")
+
+	writeWSMessage(t, `{"command":"start_pomodoro","subject":"other"}`, conn)
+	within(t, 500*time.Millisecond, func() {
+		assertWebsocketGotEvent(t, conn, EventError, "a pomodoro is already running")
+	})
+
+	writeWSMessage(t, `{"command":"pause_pomodoro"}`, conn)
+	within(t, 500*time.Millisecond, func() {
+		paused := assertWebsocketGotEvent(t, conn, EventAck, "Pomodoro paused")
+		assert.Equal(t, started.SessionID, paused.SessionID)
+	})
+
+	writeWSMessage(t, `{"command":"pause_pomodoro"}`, conn)
+	within(t, 500*time.Millisecond, func() {
+		assertWebsocketGotEvent(t, conn, EventError, "invalid pomodoro state change: cannot go from paused to paused")
+	})
+
+	writeWSMessage(t, `{"command":"resume_pomodoro"}`, conn)
+	within(t, 500*time.Millisecond, func() { assertWebsocketGotEvent(t, conn, EventAck, "Pomodoro resumed") })
+
+	writeWSMessage(t, `{"command":"cancel_pomodoro"}`, conn)
+	within(t, 500*time.Millisecond, func() { <-session.cancelled })
+	within(t, 500*time.Millisecond, func() {
+		complete := readEvent(t, conn)
+		assert.Equal(t, EventComplete, complete.Type)
+		assert.Equal(t, started.SessionID, complete.SessionID)
+		assert.JSONEq(t, `{"status":"cancelled"}`, string(complete.Payload))
+	})
+
+	writeWSMessage(t, `{"command":"cancel_pomodoro"}`, conn)
+	within(t, 500*time.Millisecond, func() { assertWebsocketGotEvent(t, conn, EventError, "no pomodoro is running") })
+}
+
+func assertSessionManualCalls(t testing.TB, session *testhelpers.SpySession, storeMap map[string]time.Duration) {
 	t.Helper()
 
 	passed := retryUntil(500*time.Millisecond, func() bool {
@@ -379,9 +915,40 @@ func mustMakeStudyServer(t *testing.T, store domain.SubjectStore, session domain
 	if err != nil {
 		t.Fatalf("failed to set up server: %v", err)
 	}
+	t.Cleanup(studyServer.Close)
 	return studyServer
 }
 
+// testNow is the fixed "now" of the handler tests, a Wednesday afternoon.
+var testNow = time.Date(2026, 3, 18, 15, 0, 0, 0, time.Local)
+
+// testServer is a StudyServer over an in-memory store, with the study session
+// it records through.
+type testServer struct {
+	*StudyServer
+	store   *database.InMemorySubjectStore
+	session *domain.StudySession
+}
+
+func newTestServer(t *testing.T, clock domain.Clock) *testServer {
+	t.Helper()
+	store := database.NewInMemorySubjectStore()
+	session := domain.NewStudySession(store, nil, clock)
+	return &testServer{
+		StudyServer: mustMakeStudyServer(t, store, session),
+		store:       store,
+		session:     session,
+	}
+}
+
+// serve sends a request to the server and returns its response.
+func (s *testServer) serve(method, target, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	response := httptest.NewRecorder()
+	s.ServeHTTP(response, request)
+	return response
+}
+
 func mustDialWS(t *testing.T, wsURL string) *websocket.Conn {
 	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
 	if err != nil {
@@ -396,10 +963,34 @@ func writeWSMessage(t *testing.T, message string, conn *websocket.Conn) {
 	}
 }
 
-func assertWebsocketGotMsg(t *testing.T, conn *websocket.Conn, want string) {
-	_, msg, err := conn.ReadMessage()
-	assert.NoError(t, err)
-	assert.Equal(t, want, string(msg))
+// receivedEvent is an Event as a client decodes it, with the payload left raw.
+type receivedEvent struct {
+	Version   int             `json:"version"`
+	Type      EventType       `json:"type"`
+	SessionID string          `json:"session_id"`
+	Subject   string          `json:"subject"`
+	Payload   json.RawMessage `json:"payload"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+func readEvent(t *testing.T, conn *websocket.Conn) receivedEvent {
+	var event receivedEvent
+	assert.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, ProtocolVersion, event.Version)
+	assert.False(t, event.Timestamp.IsZero(), "event should be timestamped")
+	return event
+}
+
+// assertWebsocketGotEvent reads the next event and checks its type and payload message.
+func assertWebsocketGotEvent(t *testing.T, conn *websocket.Conn, wantType EventType, wantMessage string) receivedEvent {
+	event := readEvent(t, conn)
+	var payload struct {
+		Message string `json:"message"`
+	}
+	assert.NoError(t, json.Unmarshal(event.Payload, &payload))
+	assert.Equal(t, wantType, event.Type)
+	assert.Equal(t, wantMessage, payload.Message)
+	return event
 }
 
 func within(t testing.TB, d time.Duration, assert func()) {
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+const sessionsPath = "/sessions/"
+
+var errNothingToChange = errors.New("nothing to change: set subject, hours or duration")
+
+// sessionJSON is how the API shows a logged session, with the duration both
+// as fractional hours and as a Go duration string like reports do.
+type sessionJSON struct {
+	ID        int64                `json:"id"`
+	Subject   string               `json:"subject"`
+	StartedAt time.Time            `json:"started_at"`
+	EndedAt   time.Time            `json:"ended_at"`
+	Hours     float64              `json:"hours"`
+	Duration  string               `json:"duration"`
+	Source    domain.SessionSource `json:"source"`
+}
+
+func newSessionJSON(session domain.LoggedSession) sessionJSON {
+	return sessionJSON{
+		ID:        session.ID,
+		Subject:   session.Subject,
+		StartedAt: session.StartedAt,
+		EndedAt:   session.EndedAt,
+		Hours:     session.Duration.Hours(),
+		Duration:  session.Duration.String(),
+		Source:    session.Source,
+	}
+}
+
+// sessionPatch is the body of a PATCH request. Fields left out keep their value.
+type sessionPatch struct {
+	Subject  string  `json:"subject,omitempty"`
+	Hours    float64 `json:"hours,omitempty"`
+	Duration string  `json:"duration,omitempty"` // e.g. "1h30m"; takes precedence over Hours
+}
+
+func (p sessionPatch) change() (domain.SessionChange, error) {
+	change := domain.SessionChange{Subject: p.Subject}
+	var err error
+	switch {
+	case p.Duration != "":
+		change.Duration, err = domain.ParseDuration(p.Duration)
+	case p.Hours != 0:
+		change.Duration, err = domain.ParseDuration(strconv.FormatFloat(p.Hours, 'f', -1, 64))
+	}
+	if err != nil {
+		return domain.SessionChange{}, err
+	}
+	if change.IsZero() {
+		return domain.SessionChange{}, errNothingToChange
+	}
+	return change, nil
+}
+
+// sessionsHandler lists the sessions of a subject at /sessions/?subject=NAME
+// and reads, corrects or deletes a single one at /sessions/{id}.
+func (s *StudyServer) sessionsHandler(w http.ResponseWriter, r *http.Request) {
+	idParam := strings.TrimPrefix(r.URL.Path, sessionsPath)
+	if idParam == "" {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if allowed(w, r, domain.ScopeRead) {
+			s.listSessions(w, r)
+		}
+		return
+	}
+
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, fmt.Sprintf("invalid session id %q", idParam), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if allowed(w, r, domain.ScopeRead) {
+			session, err := s.store.GetSession(r.Context(), id)
+			writeSession(w, session, err)
+		}
+	case http.MethodPatch:
+		if allowed(w, r, domain.ScopeRecord) {
+			s.patchSession(w, r, id)
+		}
+	case http.MethodDelete:
+		if allowed(w, r, domain.ScopeRecord) {
+			s.deleteSession(w, r, id)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *StudyServer) listSessions(w http.ResponseWriter, r *http.Request) {
+	subject := r.URL.Query().Get("subject")
+	if strings.TrimSpace(subject) == "" {
+		http.Error(w, "missing subject", http.StatusBadRequest)
+		return
+	}
+
+	sessions, err := s.store.GetSessions(r.Context(), subject)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	body := make([]sessionJSON, 0, len(sessions))
+	for _, session := range sessions {
+		body = append(body, newSessionJSON(session))
+	}
+	writeJSON(w, body)
+}
+
+func (s *StudyServer) patchSession(w http.ResponseWriter, r *http.Request, id int64) {
+	var patch sessionPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+	change, err := patch.change()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	session, err := s.session.EditSession(r.Context(), id, change)
+	writeSession(w, session, err)
+}
+
+func (s *StudyServer) deleteSession(w http.ResponseWriter, r *http.Request, id int64) {
+	if _, err := s.session.DeleteSession(r.Context(), id); err != nil {
+		writeSessionError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeSession(w http.ResponseWriter, session domain.LoggedSession, err error) {
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+	writeJSON(w, newSessionJSON(session))
+}
+
+// writeSessionError answers 404 for sessions the user does not have and 400
+// for invalid corrections.
+func writeSessionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrSessionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidDuration):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeStoreError(w, err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("content-type", jsonContentType)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Println("failed to encode:", err)
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions(t *testing.T) {
+	newServer := func(t *testing.T) *testServer {
+		server := newTestServer(t, testhelpers.NewFakeClock(testNow))
+		_, err := server.session.RecordManual(t.Context(), "math", 20*time.Hour)
+		require.NoError(t, err)
+		_, err = server.session.RecordManual(domain.WithUser(t.Context(), "bob"), "math", time.Hour)
+		require.NoError(t, err)
+		return server
+	}
+
+	t.Run("lists the sessions of a subject", func(t *testing.T) {
+		server := newServer(t)
+
+		response := server.serve(http.MethodGet, "/sessions/?subject=math", "")
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, jsonContentType, response.Header().Get("content-type"))
+
+		var got []sessionJSON
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&got))
+		require.Len(t, got, 1, "should only list the sessions of the user")
+		assert.Equal(t, int64(1), got[0].ID)
+		assert.Equal(t, 20.0, got[0].Hours)
+		assert.Equal(t, "20h0m0s", got[0].Duration)
+		assert.Equal(t, domain.SourceManual, got[0].Source)
+	})
+	t.Run("corrects the duration of a session", func(t *testing.T) {
+		server := newServer(t)
+
+		response := server.serve(http.MethodPatch, "/sessions/1", `{"hours":2}`)
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		var got sessionJSON
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&got))
+		assert.Equal(t, "2h0m0s", got.Duration)
+		assert.True(t, testNow.Equal(got.EndedAt), "the session should keep its end")
+
+		hours, err := server.store.GetHours(t.Context(), "math")
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Hour, hours)
+	})
+	t.Run("moves a session to another subject", func(t *testing.T) {
+		server := newServer(t)
+
+		response := server.serve(http.MethodPatch, "/sessions/1", `{"subject":"physics","duration":"1h30m"}`)
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		hours, err := server.store.GetHours(t.Context(), "physics")
+		assert.NoError(t, err)
+		assert.Equal(t, 90*time.Minute, hours)
+	})
+	t.Run("deletes a session", func(t *testing.T) {
+		server := newServer(t)
+
+		response := server.serve(http.MethodDelete, "/sessions/1", "")
+		assert.Equal(t, http.StatusNoContent, response.Code)
+
+		_, err := server.store.GetHours(t.Context(), "math")
+		assert.ErrorIs(t, err, domain.ErrSubjectNotFound)
+		assert.Equal(t, http.StatusNotFound, server.serve(http.MethodGet, "/sessions/1", "").Code)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"reads a session", http.MethodGet, "/sessions/1", "", http.StatusOK},
+		{"rejects a list without subject", http.MethodGet, "/sessions/", "", http.StatusBadRequest},
+		{"rejects an invalid id", http.MethodDelete, "/sessions/abc", "", http.StatusBadRequest},
+		{"rejects a negative duration", http.MethodPatch, "/sessions/1", `{"hours":-2}`, http.StatusBadRequest},
+		{"rejects an empty correction", http.MethodPatch, "/sessions/1", `{}`, http.StatusBadRequest},
+		{"rejects an invalid body", http.MethodPatch, "/sessions/1", `hours=2`, http.StatusBadRequest},
+		{"hides the sessions of other users", http.MethodDelete, "/sessions/2", "", http.StatusNotFound},
+		{"returns 404 for an unknown session", http.MethodPatch, "/sessions/42", `{"hours":2}`, http.StatusNotFound},
+		{"rejects other methods", http.MethodPost, "/sessions/1", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newServer(t)
+			assert.Equal(t, tt.want, server.serve(tt.method, tt.target, tt.body).Code)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+const statsPath = "/stats"
+
+// statsHandler shows the streaks and consistency of the user. The optional
+// "weeks" query parameter sets how many calendar weeks of active days to show.
+func (s *StudyServer) statsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !allowed(w, r, domain.ScopeRead) {
+		return
+	}
+
+	weeks := domain.DefaultStatsWeeks
+	if value := r.URL.Query().Get("weeks"); value != "" {
+		var err error
+		if weeks, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "weeks should be a number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	stats, err := s.session.Stats(r.Context(), weeks)
+	switch {
+	case errors.Is(err, domain.ErrInvalidStats):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		writeStoreError(w, err)
+	default:
+		writeJSON(w, stats)
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	clock := testhelpers.NewFakeClock(testNow.AddDate(0, 0, -1))
+	server := newTestServer(t, clock)
+
+	_, err := server.session.RecordManual(t.Context(), "go", time.Hour)
+	require.NoError(t, err)
+	clock.Advance(24 * time.Hour)
+	_, err = server.session.RecordManual(t.Context(), "go", 2*time.Hour)
+	require.NoError(t, err)
+	_, err = server.session.RecordManual(t.Context(), "sql", 30*time.Minute)
+	require.NoError(t, err)
+
+	t.Run("shows streaks, active days and session lengths", func(t *testing.T) {
+		response := server.serve(http.MethodGet, "/stats?weeks=2", "")
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, jsonContentType, response.Header().Get("content-type"))
+
+		var got struct {
+			CurrentStreak     int     `json:"current_streak_days"`
+			LongestStreak     int     `json:"longest_streak_days"`
+			ActiveDaysPerWeek float64 `json:"active_days_per_week"`
+			Weeks             []struct {
+				ActiveDays int `json:"active_days"`
+			} `json:"weeks"`
+			Subjects []map[string]any `json:"subjects"`
+		}
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&got))
+		assert.Equal(t, 2, got.CurrentStreak)
+		assert.Equal(t, 2, got.LongestStreak)
+		assert.Equal(t, 1.0, got.ActiveDaysPerWeek)
+		if assert.Len(t, got.Weeks, 2) {
+			assert.Equal(t, 2, got.Weeks[1].ActiveDays)
+		}
+		if assert.Len(t, got.Subjects, 2) {
+			assert.Equal(t, "go", got.Subjects[0]["subject"])
+			assert.Equal(t, 90.0, got.Subjects[0]["average_session_minutes"])
+		}
+	})
+
+	tests := []struct {
+		name   string
+		target string
+		want   int
+	}{
+		{"shows four weeks by default", "/stats", http.StatusOK},
+		{"rejects weeks that are not a number", "/stats?weeks=many", http.StatusBadRequest},
+		{"rejects no weeks", "/stats?weeks=0", http.StatusBadRequest},
+		{"rejects too many weeks", "/stats?weeks=1000", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, server.serve(http.MethodGet, tt.target, "").Code)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+const subjectsPath = "/subjects/"
+
+// subjectsRequest is the body of POST /subjects/rename ({"from", "to"}) and
+// POST /subjects/merge ({"into", "from": [...]}).
+type subjectsRequest struct {
+	From json.RawMessage `json:"from"` // a subject to rename, or the subjects to merge
+	To   string          `json:"to,omitempty"`
+	Into string          `json:"into,omitempty"`
+}
+
+// subjectsResponse tells which subject the sessions moved to and how many moved.
+type subjectsResponse struct {
+	Subject string `json:"subject"`
+	Moved   int    `json:"moved"`
+}
+
+// subjectsHandler renames and merges subjects of the user.
+func (s *StudyServer) subjectsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !allowed(w, r, domain.ScopeRecord) {
+		return
+	}
+
+	var req subjectsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var subject string
+	var moved int
+	var err error
+	switch strings.TrimPrefix(r.URL.Path, subjectsPath) {
+	case "rename":
+		var from string
+		if err := json.Unmarshal(req.From, &from); err != nil {
+			http.Error(w, `"from" should be the subject to rename`, http.StatusBadRequest)
+			return
+		}
+		subject = strings.TrimSpace(req.To)
+		moved, err = s.session.RenameSubject(r.Context(), from, req.To)
+	case "merge":
+		var from []string
+		if err := json.Unmarshal(req.From, &from); err != nil {
+			http.Error(w, `"from" should be the list of subjects to merge`, http.StatusBadRequest)
+			return
+		}
+		subject = strings.TrimSpace(req.Into)
+		moved, err = s.session.MergeSubjects(r.Context(), req.Into, from)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case errors.Is(err, domain.ErrSubjectNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrSubjectExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrInvalidSubject):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		writeStoreError(w, err)
+	default:
+		writeJSON(w, subjectsResponse{Subject: subject, Moved: moved})
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubjects(t *testing.T) {
+	newServer := func(t *testing.T) *testServer {
+		server := newTestServer(t, testhelpers.NewFakeClock(testNow))
+		for _, subject := range []string{"tdd", "TDD", "test-driven"} {
+			_, err := server.session.RecordManual(t.Context(), subject, time.Hour)
+			require.NoError(t, err)
+		}
+		return server
+	}
+
+	t.Run("merges subjects", func(t *testing.T) {
+		server := newServer(t)
+
+		response := server.serve(http.MethodPost, "/subjects/merge", `{"into":"tdd","from":["TDD","test-driven"]}`)
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		var got subjectsResponse
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&got))
+		assert.Equal(t, subjectsResponse{Subject: "tdd", Moved: 2}, got)
+
+		hours, err := server.store.GetHours(t.Context(), "tdd")
+		assert.NoError(t, err)
+		assert.Equal(t, 3*time.Hour, hours)
+	})
+	t.Run("renames a subject", func(t *testing.T) {
+		server := newServer(t)
+
+		response := server.serve(http.MethodPost, "/subjects/rename", `{"from":"test-driven","to":"test driven development"}`)
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		_, err := server.store.GetHours(t.Context(), "test driven development")
+		assert.NoError(t, err)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"rejects renaming to a taken name", http.MethodPost, "/subjects/rename", `{"from":"TDD","to":"tdd"}`, http.StatusConflict},
+		{"rejects renaming an unknown subject", http.MethodPost, "/subjects/rename", `{"from":"java","to":"kotlin"}`, http.StatusNotFound},
+		{"rejects merging an unknown subject", http.MethodPost, "/subjects/merge", `{"into":"tdd","from":["TDD","typo"]}`, http.StatusNotFound},
+		{"rejects merging into itself", http.MethodPost, "/subjects/merge", `{"into":"tdd","from":["tdd"]}`, http.StatusBadRequest},
+		{"rejects a single subject to merge", http.MethodPost, "/subjects/merge", `{"into":"tdd","from":"TDD"}`, http.StatusBadRequest},
+		{"rejects an invalid body", http.MethodPost, "/subjects/merge", `into=tdd`, http.StatusBadRequest},
+		{"rejects unknown operations", http.MethodPost, "/subjects/split", `{}`, http.StatusNotFound},
+		{"rejects other methods", http.MethodGet, "/subjects/rename", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newServer(t)
+			assert.Equal(t, tt.want, server.serve(tt.method, tt.target, tt.body).Code)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/adapters/clock"
+	"github.com/bryack/study_hours_tracker/adapters/database"
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+// runExport writes every session of a user to out, or to the file named by
+// -o, e.g. 'study-cli export -format jsonl > sessions.jsonl'.
+func runExport(ctx context.Context, args []string, out io.Writer, opts options) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	storeKind := fs.String("store", opts.store, "storage backend: file, postgres or memory (also $STUDY_STORE)")
+	userName := fs.String("user", opts.user, "user whose sessions to export (also $STUDY_USER)")
+	formatName := fs.String("format", string(domain.ExportCSV), "csv or jsonl")
+	periodName := fs.String("period", domain.PeriodAll, "sessions to export: today, week, month or all")
+	path := fs.String("o", "", "file to write to instead of standard output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	user, err := domain.ParseUserID(*userName)
+	if err != nil {
+		return err
+	}
+	format, err := domain.ParseExportFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	period, err := domain.ParsePeriod(*periodName, time.Now())
+	if err != nil {
+		return err
+	}
+	store, err := database.SetupStore(ctx, *storeKind)
+	if err != nil {
+		return err
+	}
+	ctx = domain.WithUser(ctx, user)
+
+	session := domain.NewStudySession(store, nil, clock.Real{})
+	if *path == "" {
+		_, err = session.Export(ctx, out, format, period)
+		return err
+	}
+
+	f, err := os.Create(*path)
+	if err != nil {
+		return err
+	}
+	exported, err := session.Export(ctx, f, format, period)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "exported %d sessions to %s\n", exported, *path)
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/adapters/clock"
+	"github.com/bryack/study_hours_tracker/adapters/database"
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+const importUsage = "usage: study-cli import [-format csv|jsonl|toggl|clockify] [-column KEY=NAME]... [-dry-run] [-user NAME] [-store KIND] FILE"
+
+// runImport imports the sessions of a file for a user, e.g.
+// 'study-cli import -format toggl -dry-run toggl.csv'.
+func runImport(ctx context.Context, args []string, out io.Writer, opts options) error {
+	var columns domain.ImportColumns
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	storeKind := fs.String("store", opts.store, "storage backend: file, postgres or memory (also $STUDY_STORE)")
+	userName := fs.String("user", opts.user, "user to import for (also $STUDY_USER)")
+	subjectPolicy := fs.String("subjects", opts.subjects, "how to spell imported subjects: exact or case-insensitive (also $STUDY_SUBJECTS)")
+	formatName := fs.String("format", string(domain.ImportCSV), "csv, jsonl, toggl or clockify")
+	fs.Var((*columnsFlag)(&columns), "column", "a column that differs from the format's, e.g. subject=Description; keys: "+strings.Join(domain.ImportColumnKeys, ", "))
+	dryRun := fs.Bool("dry-run", false, "only sum up what the import would do")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New(importUsage)
+	}
+
+	user, err := domain.ParseUserID(*userName)
+	if err != nil {
+		return err
+	}
+	policy, err := domain.ParseSubjectPolicy(*subjectPolicy)
+	if err != nil {
+		return err
+	}
+	format, err := domain.ParseImportFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	store, err := database.SetupStore(ctx, *storeKind)
+	if err != nil {
+		return err
+	}
+	store = domain.NormalizeSubjects(store, policy)
+	ctx = domain.WithUser(ctx, user)
+
+	session := domain.NewStudySession(store, nil, clock.Real{})
+	summary, err := session.Import(ctx, f, domain.ImportOptions{Format: format, Columns: columns, Location: time.Local, DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "read %d sessions: %d new, %d already recorded\n", summary.Read, summary.Imported, summary.Duplicates)
+	for _, activity := range summary.Subjects {
+		fmt.Fprintf(out, "  %s: %s\n", activity.Subject, domain.FormatDuration(activity.Duration))
+	}
+	if summary.DryRun {
+		fmt.Fprintln(out, "dry run: nothing was imported")
+	} else {
+		fmt.Fprintf(out, "imported %d sessions for %s\n", summary.Imported, user)
+	}
+	return nil
+}
+
+// columnsFlag sets import columns from KEY=NAME flags.
+type columnsFlag domain.ImportColumns
+
+func (c *columnsFlag) String() string {
+	return ""
+}
+
+func (c *columnsFlag) Set(value string) error {
+	key, name, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("%q should be KEY=NAME", value)
+	}
+	return (*domain.ImportColumns)(c).Set(key, name)
+}
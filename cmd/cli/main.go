@@ -1,31 +1,155 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
 
 	"github.com/bryack/study_hours_tracker/adapters/cli"
+	"github.com/bryack/study_hours_tracker/adapters/clock"
 	"github.com/bryack/study_hours_tracker/adapters/database"
 	"github.com/bryack/study_hours_tracker/adapters/pomodoro"
 	"github.com/bryack/study_hours_tracker/domain"
 	domainPomodoro "github.com/bryack/study_hours_tracker/domain/pomodoro"
 )
 
+// options are the flags given before the command. The commands with flags
+// of their own take them as defaults, so that they may go either side of the
+// command name.
+type options struct {
+	store          string
+	pomodoroConfig string
+	user           string
+	subjects       string
+}
+
 func main() {
-	store, err := database.SetupPostgres()
+	var opts options
+	flag.StringVar(&opts.store, "store", database.StoreFromEnv(database.StoreFile), "storage backend: file, postgres or memory (also $STUDY_STORE)")
+	flag.StringVar(&opts.pomodoroConfig, "pomodoro-config", "", "Pomodoro focus and alerts config file (also $STUDY_POMODORO_CONFIG)")
+	flag.StringVar(&opts.user, "user", cmp.Or(os.Getenv("STUDY_USER"), string(domain.DefaultUser)), "user to record and report for (also $STUDY_USER)")
+	flag.StringVar(&opts.subjects, "subjects", os.Getenv("STUDY_SUBJECTS"), "how to spell recorded subjects: exact or case-insensitive (also $STUDY_SUBJECTS)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), cli.CommandUsage)
+		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	code := cli.ExitOK
+	var err error
+	args := flag.Args()
+	switch flag.Arg(0) {
+	case "migrate":
+		err = runMigrate(ctx, args[1:], os.Stdout)
+	case "token":
+		if !isFlagSet("store") {
+			// Tokens are checked by the web server, which keeps them in Postgres.
+			opts.store = database.StoreFromEnv(database.StorePostgres)
+		}
+		err = runToken(ctx, args[1:], os.Stdout, opts)
+	case "export":
+		err = runExport(ctx, args[1:], os.Stdout, opts)
+	case "import":
+		err = runImport(ctx, args[1:], os.Stdout, opts)
+	case "":
+		err = runInteractive(ctx, opts)
+	default:
+		code, err = runCommand(ctx, args, opts)
+	}
+	stop()
 	if err != nil {
 		log.Fatal(err)
 	}
+	os.Exit(code)
+}
+
+// runInteractive starts the interactive session, which draws the countdown of
+// a Pomodoro when it runs on a terminal.
+func runInteractive(ctx context.Context, opts options) error {
+	ctx, session, err := newSession(ctx, opts, pomodoro.IsTerminal(os.Stdout))
+	if err != nil {
+		return err
+	}
+	return cli.NewCLI(os.Stdin, os.Stdout, session).Run(ctx)
+}
+
+// runCommand runs a single command like 'record math 2' and returns its exit
+// code. Its output may be read by a script, so it gets no countdown.
+func runCommand(ctx context.Context, args []string, opts options) (int, error) {
+	ctx, session, err := newSession(ctx, opts, false)
+	if err != nil {
+		return cli.ExitError, err
+	}
+	return cli.RunCommand(ctx, args, os.Stdout, os.Stderr, session), nil
+}
+
+// newSession sets up the study session for the user of opts and returns it
+// with a context for that user.
+func newSession(ctx context.Context, opts options, countdown bool) (context.Context, *domain.StudySession, error) {
+	user, err := domain.ParseUserID(opts.user)
+	if err != nil {
+		return nil, nil, err
+	}
+	policy, err := domain.ParseSubjectPolicy(opts.subjects)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx = domain.WithUser(ctx, user)
+
+	store, err := database.SetupStore(ctx, opts.store)
+	if err != nil {
+		return nil, nil, err
+	}
+	store = domain.NormalizeSubjects(store, policy)
+
+	pomodoroConfig, err := loadPomodoroConfig(opts.pomodoroConfig)
+	if err != nil {
+		return nil, nil, err
+	}
 
+	// Without a countdown, alerts are printed as plain lines.
 	alerter := pomodoro.Alerter{
-		ScheduleFunc: pomodoro.RealScheduleAlert,
-		WaitFunc:     pomodoro.RealWait,
+		AlertFunc: pomodoro.PlainAlert,
+		TickFunc:  pomodoro.NoTick,
+	}
+	if countdown {
+		alerter = pomodoro.Alerter{
+			AlertFunc: pomodoro.RealAlert,
+			TickFunc:  pomodoro.RealTick,
+		}
 	}
 
-	pomodoroRunner := domainPomodoro.NewPomodoro(alerter)
-	session := domain.NewStudySession(store, pomodoroRunner)
-	tracker := cli.NewCLI(os.Stdin, os.Stdout, session)
-	if err := tracker.Run(); err != nil {
-		log.Fatal(err)
+	realClock := clock.Real{}
+	pomodoroRunner := domainPomodoro.NewPomodoro(alerter, realClock, pomodoroConfig)
+	return ctx, domain.NewStudySession(store, pomodoroRunner, realClock), nil
+}
+
+// isFlagSet reports whether the global flag name was given on the command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+// loadPomodoroConfig reads the Pomodoro config from path, or from the file
+// named by STUDY_POMODORO_CONFIG or the default location when path is empty.
+func loadPomodoroConfig(path string) (domain.PomodoroConfig, error) {
+	if path == "" {
+		var err error
+		path, err = pomodoro.ConfigFileFromEnv()
+		if err != nil {
+			return domain.PomodoroConfig{}, err
+		}
 	}
+	return pomodoro.LoadConfig(path)
 }
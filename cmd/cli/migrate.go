@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/bryack/study_hours_tracker/adapters/database"
+)
+
+const migrateUsage = "usage: study-cli migrate [up | down [-steps N] | status]"
+
+// runMigrate applies or rolls back the Postgres schema at DATABASE_URL.
+func runMigrate(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		args = []string{"up"}
+	}
+
+	m, err := database.SetupMigrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		version, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "schema is at version %d\n", version)
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "number of migrations to roll back")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		version, err := m.Down(ctx, *steps)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "schema is at version %d\n", version)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(out, "%04d_%s\t%s\n", s.Version, s.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/adapters/database"
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+const tokenUsage = "usage: study-cli token issue|list|revoke [-user NAME] [-store KIND] [-scope read|record] [-name NAME] [ID]"
+
+// runToken issues, lists and revokes the API tokens the web server accepts.
+// Tokens live in the store, so it has to be the one the server uses.
+func runToken(ctx context.Context, args []string, out io.Writer, opts options) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing token command\n%s", tokenUsage)
+	}
+
+	fs := flag.NewFlagSet("token "+args[0], flag.ContinueOnError)
+	storeKind := fs.String("store", opts.store, "storage backend of the web server: file, postgres or memory (also $STUDY_STORE)")
+	userName := fs.String("user", opts.user, "user the tokens belong to (also $STUDY_USER)")
+	scopeName := fs.String("scope", string(domain.ScopeRecord), "what the token may do: read or record")
+	name := fs.String("name", "", "what the token is for, e.g. laptop")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	user, err := domain.ParseUserID(*userName)
+	if err != nil {
+		return err
+	}
+	store, err := database.SetupStore(ctx, *storeKind)
+	if err != nil {
+		return err
+	}
+	ctx = domain.WithUser(ctx, user)
+
+	switch args[0] {
+	case "issue":
+		scope, err := domain.ParseScope(*scopeName)
+		if err != nil {
+			return err
+		}
+		token, secret := domain.NewAPIToken(user, scope, *name, time.Now())
+		if err := store.SaveToken(ctx, token); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "issued %s token %s for %s; it is shown only once:\n%s\n", scope, token.ID, user, secret)
+	case "list":
+		tokens, err := store.GetTokens(ctx)
+		if err != nil {
+			return err
+		}
+		for _, token := range tokens {
+			fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", token.ID, token.Scope, token.CreatedAt.Format(time.DateTime), token.Name)
+		}
+	case "revoke":
+		if fs.NArg() != 1 {
+			return fmt.Errorf("token revoke needs a token id\n%s", tokenUsage)
+		}
+		if err := store.DeleteToken(ctx, fs.Arg(0)); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "revoked token %s\n", fs.Arg(0))
+	default:
+		return fmt.Errorf("unknown token command %q\n%s", args[0], tokenUsage)
+	}
+	return nil
+}
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 
+	"github.com/bryack/study_hours_tracker/adapters/clock"
 	"github.com/bryack/study_hours_tracker/adapters/database"
 	"github.com/bryack/study_hours_tracker/adapters/pomodoro"
 	"github.com/bryack/study_hours_tracker/adapters/server"
@@ -14,22 +21,79 @@ import (
 const defaultPort = ":5000"
 
 func main() {
-	store, err := database.SetupPostgres()
+	storeKind := flag.String("store", database.StoreFromEnv(database.StorePostgres), "storage backend: file, postgres or memory (also $STUDY_STORE)")
+	pomodoroConfigFile := flag.String("pomodoro-config", "", "Pomodoro focus and alerts config file (also $STUDY_POMODORO_CONFIG)")
+	noAuth := flag.Bool("no-auth", false, "serve without authentication; requests name their user with the X-Study-User header")
+	subjectPolicy := flag.String("subjects", os.Getenv("STUDY_SUBJECTS"), "how to spell recorded subjects: exact or case-insensitive (also $STUDY_SUBJECTS)")
+	flag.Parse()
+
+	policy, err := domain.ParseSubjectPolicy(*subjectPolicy)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	store, err := database.SetupStore(ctx, *storeKind)
+	if err != nil {
+		log.Fatal(err)
+	}
+	store = domain.NormalizeSubjects(store, policy)
+
+	pomodoroConfig, err := loadPomodoroConfig(*pomodoroConfigFile)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	alerter := pomodoro.Alerter{
-		ScheduleFunc: pomodoro.RealScheduleAlert,
-		WaitFunc:     pomodoro.RealWait,
+		AlertFunc: pomodoro.RealAlert,
+		TickFunc:  pomodoro.RealTick,
 	}
 
-	pomodoroRunner := domainPomodoro.NewPomodoro(alerter)
-	session := domain.NewStudySession(store, pomodoroRunner)
+	realClock := clock.Real{}
+	pomodoroRunner := domainPomodoro.NewPomodoro(alerter, realClock, pomodoroConfig)
+	session := domain.NewStudySession(store, pomodoroRunner, realClock)
 
 	svr, err := server.NewStudyServer(store, session)
 	if err != nil {
 		log.Fatal(err)
 	}
-	log.Fatal(http.ListenAndServe(defaultPort, svr))
+	if !*noAuth {
+		svr.RequireAuth()
+	}
+	if err := svr.ResumePomodoros(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	// Every request context derives from ctx, so an interrupt cancels store
+	// calls before the server shuts down.
+	httpServer := &http.Server{
+		Addr:        defaultPort,
+		Handler:     svr,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		httpServer.Shutdown(context.Background())
+	}()
+
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	// Running Pomodoros stay saved and are resumed on the next start.
+	svr.Close()
+}
+
+// loadPomodoroConfig reads the Pomodoro config from path, or from the file
+// named by STUDY_POMODORO_CONFIG or the default location when path is empty.
+func loadPomodoroConfig(path string) (domain.PomodoroConfig, error) {
+	if path == "" {
+		var err error
+		path, err = pomodoro.ConfigFileFromEnv()
+		if err != nil {
+			return domain.PomodoroConfig{}, err
+		}
+	}
+	return pomodoro.LoadConfig(path)
 }
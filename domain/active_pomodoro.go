@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ActivePomodoro is a Pomodoro in progress. It is persisted so that the
+// Pomodoro outlives the connection and the process that started it. A cycle
+// is kept as the Pomodoro of the round it is in; StartedAt and EndsAt are
+// zero while it is on the break before Round.
+type ActivePomodoro struct {
+	ID        string        `json:"id"`
+	UserID    UserID        `json:"user_id,omitempty"` // set by the store from the context
+	Subject   string        `json:"subject"`
+	StartedAt time.Time     `json:"started_at"`
+	EndsAt    time.Time     `json:"ends_at"`            // planned end of the focus, moved back by pauses
+	PausedAt  time.Time     `json:"paused_at,omitzero"` // zero unless paused
+	Focus     time.Duration `json:"focus"`              // planned focus length
+	Round     int           `json:"round,omitempty"`    // position in a cycle, from 1
+	Rounds    int           `json:"rounds,omitempty"`   // Pomodoros of the cycle, zero outside cycles
+}
+
+// InCycle reports whether the Pomodoro is a round of a cycle.
+func (a ActivePomodoro) InCycle() bool {
+	return a.Rounds > 0
+}
+
+// OnBreak reports whether the cycle is on the break before Round.
+func (a ActivePomodoro) OnBreak() bool {
+	return a.InCycle() && a.StartedAt.IsZero()
+}
+
+// Paused reports whether the Pomodoro was paused when it was saved.
+func (a ActivePomodoro) Paused() bool {
+	return !a.PausedAt.IsZero()
+}
+
+// Remaining returns the focus time left at now. The clock stands still while
+// the Pomodoro is paused.
+func (a ActivePomodoro) Remaining(now time.Time) time.Duration {
+	if a.Paused() {
+		now = a.PausedAt
+	}
+	return a.EndsAt.Sub(now)
+}
+
+// ActivePomodoroStore keeps the Pomodoros in progress.
+type ActivePomodoroStore interface {
+	// SaveActivePomodoro adds the Pomodoro for the user of ctx or replaces
+	// the one with the same ID.
+	SaveActivePomodoro(ctx context.Context, active ActivePomodoro) error
+	// DeleteActivePomodoro removes the Pomodoro of the user of ctx; an
+	// unknown ID is not an error.
+	DeleteActivePomodoro(ctx context.Context, id string) error
+	// GetActivePomodoros returns the Pomodoros in progress of every user,
+	// ordered by start time, so that they can be resumed after a restart.
+	GetActivePomodoros(ctx context.Context) ([]ActivePomodoro, error)
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// Clock tells the time and provides timers. Time-dependent code takes a Clock
+// instead of calling the time package so tests can control the passing of time.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a pending call started by Clock.AfterFunc.
+type Timer interface {
+	// Stop prevents the call from happening and reports whether it did so.
+	Stop() bool
+}
+
+// Ticker delivers the time on C at regular intervals until stopped.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidExportFormat = errors.New("invalid export format")
+
+// ExportFormat is how exported sessions are encoded.
+type ExportFormat string
+
+const (
+	// ExportCSV writes a header line followed by one line per session.
+	ExportCSV ExportFormat = "csv"
+	// ExportJSONLines writes one JSON object per line and session.
+	ExportJSONLines ExportFormat = "jsonl"
+)
+
+// ParseExportFormat parses "csv" or "jsonl"; empty means csv.
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch format := ExportFormat(s); format {
+	case "":
+		return ExportCSV, nil
+	case ExportCSV, ExportJSONLines:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w %q: should be %s or %s", ErrInvalidExportFormat, s, ExportCSV, ExportJSONLines)
+	}
+}
+
+// exportColumns are the CSV header and the keys of the JSON lines.
+var exportColumns = []string{"id", "subject", "started_at", "ended_at", "hours", "duration", "source"}
+
+// exportedSession is a session as it is exported, with the duration both as
+// fractional hours and as a Go duration string.
+type exportedSession struct {
+	ID        int64         `json:"id"`
+	Subject   string        `json:"subject"`
+	StartedAt time.Time     `json:"started_at"`
+	EndedAt   time.Time     `json:"ended_at"`
+	Hours     float64       `json:"hours"`
+	Duration  string        `json:"duration"`
+	Source    SessionSource `json:"source"`
+}
+
+// SessionExporter encodes sessions one at a time, so that exports never hold
+// more than one session.
+type SessionExporter struct {
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+// NewSessionExporter returns an exporter writing format to w. Call Flush when
+// all sessions are written.
+func NewSessionExporter(w io.Writer, format ExportFormat) (*SessionExporter, error) {
+	switch format {
+	case ExportCSV:
+		e := &SessionExporter{csv: csv.NewWriter(w)}
+		if err := e.csv.Write(exportColumns); err != nil {
+			return nil, fmt.Errorf("failed to write header: %w", err)
+		}
+		return e, nil
+	case ExportJSONLines:
+		return &SessionExporter{json: json.NewEncoder(w)}, nil
+	default:
+		_, err := ParseExportFormat(string(format))
+		return nil, err
+	}
+}
+
+// Export writes session.
+func (e *SessionExporter) Export(session LoggedSession) error {
+	if e.json != nil {
+		return e.json.Encode(exportedSession{
+			ID:        session.ID,
+			Subject:   session.Subject,
+			StartedAt: session.StartedAt,
+			EndedAt:   session.EndedAt,
+			Hours:     session.Duration.Hours(),
+			Duration:  session.Duration.String(),
+			Source:    session.Source,
+		})
+	}
+	return e.csv.Write([]string{
+		strconv.FormatInt(session.ID, 10),
+		session.Subject,
+		session.StartedAt.Format(time.RFC3339Nano),
+		session.EndedAt.Format(time.RFC3339Nano),
+		FormatHours(session.Duration),
+		session.Duration.String(),
+		string(session.Source),
+	})
+}
+
+// Flush writes out what the exporter buffered and reports any earlier write error.
+func (e *SessionExporter) Flush() error {
+	if e.csv == nil {
+		return nil
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
@@ -0,0 +1,96 @@
+package domain_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/adapters/database"
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionExporter(t *testing.T) {
+	start := time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)
+	sessions := []domain.LoggedSession{
+		{ID: 1, Subject: "go", StartedAt: start, EndedAt: start.Add(90 * time.Minute), Duration: 90 * time.Minute, Source: domain.SourceManual},
+		{ID: 2, Subject: "sql, joins", StartedAt: start.Add(2 * time.Hour), EndedAt: start.Add(2*time.Hour + 25*time.Minute), Duration: 25 * time.Minute, Source: domain.SourcePomodoro},
+	}
+	export := func(t *testing.T, format domain.ExportFormat) string {
+		t.Helper()
+		out := &bytes.Buffer{}
+		exporter, err := domain.NewSessionExporter(out, format)
+		require.NoError(t, err)
+		for _, session := range sessions {
+			require.NoError(t, exporter.Export(session))
+		}
+		require.NoError(t, exporter.Flush())
+		return out.String()
+	}
+
+	t.Run("writes csv with a header", func(t *testing.T) {
+		assert.Equal(t, "id,subject,started_at,ended_at,hours,duration,source\n"+
+			"1,go,2026-03-16T09:00:00Z,2026-03-16T10:30:00Z,1.5,1h30m0s,manual\n"+
+			"2,\"sql, joins\",2026-03-16T11:00:00Z,2026-03-16T11:25:00Z,0.4166666666666667,25m0s,pomodoro\n",
+			export(t, domain.ExportCSV))
+	})
+	t.Run("writes a json object per line", func(t *testing.T) {
+		lines := bytes.Split(bytes.TrimSpace([]byte(export(t, domain.ExportJSONLines))), []byte("\n"))
+		require.Len(t, lines, 2)
+		assert.JSONEq(t, `{
+			"id": 1,
+			"subject": "go",
+			"started_at": "2026-03-16T09:00:00Z",
+			"ended_at": "2026-03-16T10:30:00Z",
+			"hours": 1.5,
+			"duration": "1h30m0s",
+			"source": "manual"
+		}`, string(lines[0]))
+
+		var second map[string]any
+		require.NoError(t, json.Unmarshal(lines[1], &second))
+		assert.Equal(t, "sql, joins", second["subject"])
+	})
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		_, err := domain.NewSessionExporter(&bytes.Buffer{}, "xml")
+		assert.ErrorIs(t, err, domain.ErrInvalidExportFormat)
+
+		format, err := domain.ParseExportFormat("")
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ExportCSV, format)
+	})
+}
+
+func TestStudySession_Export(t *testing.T) {
+	store := database.NewInMemorySubjectStore()
+	session := domain.NewStudySession(store, &SpyPomodoroRunner{}, testhelpers.NewFakeClock(sessionStart))
+	_, err := session.RecordManual(t.Context(), "go", time.Hour)
+	require.NoError(t, err)
+	_, err = session.RecordManual(t.Context(), "sql", 2*time.Hour)
+	require.NoError(t, err)
+
+	t.Run("exports every session", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		exported, err := session.Export(t.Context(), out, domain.ExportJSONLines, domain.AllTime())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, exported)
+		assert.Equal(t, 2, bytes.Count(out.Bytes(), []byte("\n")))
+	})
+	t.Run("stops at the first failed write", func(t *testing.T) {
+		exported, err := session.Export(t.Context(), failingWriter{}, domain.ExportJSONLines, domain.AllTime())
+		assert.ErrorIs(t, err, errWriteFailed)
+		assert.Zero(t, exported)
+	})
+}
+
+var errWriteFailed = errors.New("write failed")
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errWriteFailed
+}
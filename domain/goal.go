@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrGoalNotFound = errors.New("goal not found")
+	ErrInvalidGoal  = errors.New("invalid goal")
+)
+
+// GoalPeriod is how often a goal starts over.
+type GoalPeriod string
+
+const (
+	GoalDaily   GoalPeriod = "daily"
+	GoalWeekly  GoalPeriod = "weekly"
+	GoalMonthly GoalPeriod = "monthly"
+)
+
+// ParseGoalPeriod parses "daily", "weekly" or "monthly".
+func ParseGoalPeriod(s string) (GoalPeriod, error) {
+	switch period := GoalPeriod(s); period {
+	case GoalDaily, GoalWeekly, GoalMonthly:
+		return period, nil
+	default:
+		return "", fmt.Errorf("%w period %q: should be one of %s, %s, %s", ErrInvalidGoal, s, GoalDaily, GoalWeekly, GoalMonthly)
+	}
+}
+
+// Range returns the calendar day, week or month containing now.
+func (p GoalPeriod) Range(now time.Time) TimeRange {
+	switch p {
+	case GoalDaily:
+		return Today(now)
+	case GoalMonthly:
+		return ThisMonth(now)
+	default:
+		return ThisWeek(now)
+	}
+}
+
+// Goal is how long to study a subject each day, week or month, e.g. 10h of
+// Go a week. A user has at most one goal per subject and period.
+type Goal struct {
+	UserID  UserID        `json:"user_id,omitempty"` // set by the store from the context
+	Subject string        `json:"subject"`
+	Period  GoalPeriod    `json:"period"`
+	Target  time.Duration `json:"target"`
+}
+
+// validate checks a goal before it is saved.
+func (g Goal) validate() error {
+	if strings.TrimSpace(g.Subject) == "" {
+		return fmt.Errorf("%w: subject cannot be empty", ErrInvalidGoal)
+	}
+	if _, err := ParseGoalPeriod(string(g.Period)); err != nil {
+		return err
+	}
+	if g.Target <= 0 {
+		return fmt.Errorf("%w target %s: should be positive", ErrInvalidGoal, g.Target)
+	}
+	return nil
+}
+
+// GoalStore keeps the goals of every user.
+type GoalStore interface {
+	// SaveGoal adds a goal of the user of ctx, replacing the one with the same
+	// subject and period.
+	SaveGoal(ctx context.Context, goal Goal) error
+	// GetGoals returns the goals of the user of ctx ordered by subject and period.
+	GetGoals(ctx context.Context) ([]Goal, error)
+	// DeleteGoal removes a goal of the user of ctx, or returns ErrGoalNotFound.
+	DeleteGoal(ctx context.Context, subject string, period GoalPeriod) error
+}
+
+// GoalProgress is the time studied for a goal in its current period.
+type GoalProgress struct {
+	Goal
+	Done time.Duration
+}
+
+// Remaining returns the time still to study, or zero once the goal is met.
+func (p GoalProgress) Remaining() time.Duration {
+	return max(p.Target-p.Done, 0)
+}
+
+// Met reports whether the target has been reached.
+func (p GoalProgress) Met() bool {
+	return p.Done >= p.Target
+}
+
+// Percent returns how much of the target is done, which goes past 100 when
+// more was studied.
+func (p GoalProgress) Percent() int {
+	return int(p.Done * 100 / p.Target)
+}
+
+type goalProgressJSON struct {
+	Subject     string     `json:"subject"`
+	Period      GoalPeriod `json:"period"`
+	TargetHours float64    `json:"target_hours"`
+	Target      string     `json:"target"`
+	DoneHours   float64    `json:"done_hours"`
+	Done        string     `json:"done"`
+	Remaining   string     `json:"remaining"`
+	Percent     int        `json:"percent"`
+	Met         bool       `json:"met"`
+}
+
+// MarshalJSON encodes durations both as fractional hours and as Go duration
+// strings, like StudyActivity does.
+func (p GoalProgress) MarshalJSON() ([]byte, error) {
+	return json.Marshal(goalProgressJSON{
+		Subject:     p.Subject,
+		Period:      p.Period,
+		TargetHours: p.Target.Hours(),
+		Target:      p.Target.String(),
+		DoneHours:   p.Done.Hours(),
+		Done:        p.Done.String(),
+		Remaining:   p.Remaining().String(),
+		Percent:     p.Percent(),
+		Met:         p.Met(),
+	})
+}
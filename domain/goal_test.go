@@ -0,0 +1,51 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoalPeriod(t *testing.T) {
+	now := time.Date(2026, 3, 18, 15, 0, 0, 0, time.UTC)
+	tests := []struct {
+		period domain.GoalPeriod
+		want   domain.TimeRange
+	}{
+		{domain.GoalDaily, domain.Today(now)},
+		{domain.GoalWeekly, domain.ThisWeek(now)},
+		{domain.GoalMonthly, domain.ThisMonth(now)},
+	}
+	for _, tt := range tests {
+		period, err := domain.ParseGoalPeriod(string(tt.period))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, period.Range(now), "%s range", tt.period)
+	}
+
+	_, err := domain.ParseGoalPeriod("week")
+	assert.ErrorIs(t, err, domain.ErrInvalidGoal)
+}
+
+func TestGoalProgressJSON(t *testing.T) {
+	progress := domain.GoalProgress{
+		Goal: domain.Goal{Subject: "go", Period: domain.GoalWeekly, Target: 10 * time.Hour},
+		Done: 7*time.Hour + 30*time.Minute,
+	}
+
+	got, err := json.Marshal(progress)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"subject": "go",
+		"period": "weekly",
+		"target_hours": 10,
+		"target": "10h0m0s",
+		"done_hours": 7.5,
+		"done": "7h30m0s",
+		"remaining": "2h30m0s",
+		"percent": 75,
+		"met": false
+	}`, string(got))
+}
@@ -0,0 +1,410 @@
+package domain
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidImport = errors.New("invalid import")
+
+// ImportFormat names where an import file comes from.
+type ImportFormat string
+
+const (
+	// ImportCSV reads the CSV that ExportCSV writes, or a spreadsheet whose
+	// columns are named with ImportColumns.
+	ImportCSV ImportFormat = "csv"
+	// ImportJSONLines reads the JSON lines that ExportJSONLines writes.
+	ImportJSONLines ImportFormat = "jsonl"
+	// ImportToggl reads the detailed CSV report of Toggl Track.
+	ImportToggl ImportFormat = "toggl"
+	// ImportClockify reads the detailed CSV report of Clockify.
+	ImportClockify ImportFormat = "clockify"
+)
+
+// ParseImportFormat parses "csv", "jsonl", "toggl" or "clockify"; empty means csv.
+func ParseImportFormat(s string) (ImportFormat, error) {
+	switch format := ImportFormat(s); format {
+	case "":
+		return ImportCSV, nil
+	case ImportCSV, ImportJSONLines, ImportToggl, ImportClockify:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w format %q: should be one of %s, %s, %s, %s", ErrInvalidImport, s, ImportCSV, ImportJSONLines, ImportToggl, ImportClockify)
+	}
+}
+
+// ImportColumns names the CSV columns sessions are read from, regardless of
+// case. Subject, Start and either Duration or End are needed.
+type ImportColumns struct {
+	Subject string
+	// Start holds the start date and time, or only the date when StartTime
+	// holds the time.
+	Start     string
+	StartTime string
+	// End and EndTime hold the end like Start and StartTime do the start.
+	End     string
+	EndTime string
+	// Duration holds hours ("1.5"), a Go duration ("1h30m") or hours, minutes
+	// and seconds ("1:30:00"). Without it sessions last from start to end.
+	Duration string
+	// Source holds how sessions were recorded. Sessions without it, or with
+	// another source than this tracker's, are marked as imported.
+	Source string
+}
+
+// ImportColumnKeys are the keys Set accepts.
+var ImportColumnKeys = []string{"subject", "start", "start_time", "end", "end_time", "duration", "source"}
+
+// Set names the column of key, one of ImportColumnKeys.
+func (c *ImportColumns) Set(key, column string) error {
+	fields := []*string{&c.Subject, &c.Start, &c.StartTime, &c.End, &c.EndTime, &c.Duration, &c.Source}
+	i := slices.Index(ImportColumnKeys, key)
+	if i < 0 {
+		return fmt.Errorf("%w column %q: should be one of %s", ErrInvalidImport, key, strings.Join(ImportColumnKeys, ", "))
+	}
+	*fields[i] = column
+	return nil
+}
+
+// importColumns are the columns each CSV format has.
+var importColumns = map[ImportFormat]ImportColumns{
+	ImportCSV:      {Subject: "subject", Start: "started_at", End: "ended_at", Duration: "duration", Source: "source"},
+	ImportToggl:    {Subject: "Project", Start: "Start date", StartTime: "Start time", End: "End date", EndTime: "End time", Duration: "Duration"},
+	ImportClockify: {Subject: "Project", Start: "Start Date", StartTime: "Start Time", End: "End Date", EndTime: "End Time", Duration: "Duration (h)"},
+}
+
+// ImportOptions tells how to read an import file.
+type ImportOptions struct {
+	Format ImportFormat
+	// Columns name columns that differ from those of Format, e.g. the
+	// Description column of Toggl as the subject.
+	Columns ImportColumns
+	// Location is where dates and times without a zone were taken;
+	// time.Local when nil.
+	Location *time.Location
+	// DryRun only sums up what an import would do.
+	DryRun bool
+}
+
+// ImportSummary sums up the new sessions of an import.
+type ImportSummary struct {
+	Format     ImportFormat `json:"format"`
+	Read       int          `json:"read"`
+	Imported   int          `json:"imported"` // or would be, on a dry run
+	Duplicates int          `json:"duplicates"`
+	DryRun     bool         `json:"dry_run"`
+	From       time.Time    `json:"from,omitzero"` // start of the earliest new session
+	To         time.Time    `json:"to,omitzero"`   // end of the latest new session
+	Subjects   Report       `json:"subjects"`      // time per subject of the new sessions
+}
+
+// ParseImport reads the sessions of an import file. It fails on the first
+// row it cannot read, naming its line.
+func ParseImport(r io.Reader, options ImportOptions) ([]LoggedSession, error) {
+	format, err := ParseImportFormat(string(options.Format))
+	if err != nil {
+		return nil, err
+	}
+	loc := cmp.Or(options.Location, time.Local)
+	if format == ImportJSONLines {
+		return parseImportJSONLines(r, loc)
+	}
+	return parseImportCSV(r, importColumns[format], options.Columns, loc)
+}
+
+func parseImportCSV(r io.Reader, preset, override ImportColumns, loc *time.Location) ([]LoggedSession, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, importReadError("", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // spreadsheets start CSV with a byte order mark
+		}
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	// column finds a column named by override or, when it has none, by preset.
+	// Only the columns named by override and those every import needs have to
+	// be there.
+	var missing error
+	column := func(overridden, preset string, needed bool) int {
+		name := cmp.Or(overridden, preset)
+		if name == "" {
+			return -1
+		}
+		i, ok := index[strings.ToLower(name)]
+		if !ok {
+			if (needed || overridden != "") && missing == nil {
+				missing = fmt.Errorf("%w: no %q column in %s", ErrInvalidImport, name, strings.Join(header, ", "))
+			}
+			return -1
+		}
+		return i
+	}
+	subject := column(override.Subject, preset.Subject, true)
+	start := column(override.Start, preset.Start, true)
+	startTime := column(override.StartTime, preset.StartTime, false)
+	end := column(override.End, preset.End, false)
+	endTime := column(override.EndTime, preset.EndTime, false)
+	duration := column(override.Duration, preset.Duration, false)
+	source := column(override.Source, preset.Source, false)
+	if missing != nil {
+		return nil, missing
+	}
+	if duration < 0 && end < 0 {
+		return nil, fmt.Errorf("%w: needs a duration or an end column", ErrInvalidImport)
+	}
+
+	var sessions []LoggedSession
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return sessions, nil
+		}
+		if err != nil {
+			return nil, importReadError("", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		session, err := importedSession(field(subject), field(start), field(startTime), field(end), field(endTime), field(duration), field(source), loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidImport, line, err)
+		}
+		sessions = append(sessions, session)
+	}
+}
+
+func parseImportJSONLines(r io.Reader, loc *time.Location) ([]LoggedSession, error) {
+	decoder := json.NewDecoder(r)
+	var sessions []LoggedSession
+	for entry := 1; ; entry++ {
+		var raw struct {
+			Subject   string  `json:"subject"`
+			StartedAt string  `json:"started_at"`
+			EndedAt   string  `json:"ended_at"`
+			Hours     float64 `json:"hours"`
+			Duration  string  `json:"duration"`
+			Source    string  `json:"source"`
+		}
+		err := decoder.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return sessions, nil
+		}
+		if err != nil {
+			return nil, importReadError(fmt.Sprintf("entry %d: ", entry), err)
+		}
+		duration := raw.Duration
+		if duration == "" && raw.Hours != 0 {
+			duration = strconv.FormatFloat(raw.Hours, 'f', -1, 64)
+		}
+		session, err := importedSession(raw.Subject, raw.StartedAt, "", raw.EndedAt, "", duration, raw.Source, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: entry %d: %v", ErrInvalidImport, entry, err)
+		}
+		sessions = append(sessions, session)
+	}
+}
+
+// importReadError tells a file that cannot be parsed, which is an invalid
+// import, from one that cannot be read.
+func importReadError(where string, err error) error {
+	var csvErr *csv.ParseError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &csvErr) || errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %s%v", ErrInvalidImport, where, err)
+	}
+	return fmt.Errorf("failed to read import: %w", err)
+}
+
+// importedSession builds a session from the fields of an imported row.
+func importedSession(subject, startDate, startClock, endDate, endClock, duration, source string, loc *time.Location) (LoggedSession, error) {
+	if subject == "" {
+		return LoggedSession{}, errors.New("no subject")
+	}
+	if startDate == "" {
+		return LoggedSession{}, errors.New("no start")
+	}
+	session := LoggedSession{Subject: subject, Source: SourceImport}
+	var err error
+	if session.StartedAt, err = parseImportTime(startDate, startClock, loc); err != nil {
+		return LoggedSession{}, err
+	}
+	if endDate != "" {
+		if session.EndedAt, err = parseImportTime(endDate, endClock, loc); err != nil {
+			return LoggedSession{}, err
+		}
+	}
+
+	switch {
+	case duration != "":
+		if session.Duration, err = parseImportDuration(duration); err != nil {
+			return LoggedSession{}, err
+		}
+	case !session.EndedAt.IsZero():
+		session.Duration = session.EndedAt.Sub(session.StartedAt).Round(time.Second)
+	default:
+		return LoggedSession{}, errors.New("no duration or end")
+	}
+	if session.Duration < time.Second {
+		return LoggedSession{}, fmt.Errorf("%w %s: should be at least a second", ErrInvalidDuration, session.Duration)
+	}
+	if session.EndedAt.IsZero() {
+		session.EndedAt = session.StartedAt.Add(session.Duration)
+	}
+
+	if s := SessionSource(source); s == SourceManual || s == SourcePomodoro {
+		session.Source = s
+	}
+	return session, nil
+}
+
+var (
+	importDateLayouts  = []string{time.DateOnly, "01/02/2006", "02.01.2006"}
+	importClockLayouts = []string{time.TimeOnly, "15:04", "03:04:05 PM", "3:04:05 PM", "03:04 PM", "3:04 PM"}
+	importTimeLayouts  = func() []string {
+		layouts := []string{time.RFC3339Nano}
+		for _, date := range importDateLayouts {
+			for _, clock := range importClockLayouts {
+				layouts = append(layouts, date+" "+clock, date+"T"+clock)
+			}
+			layouts = append(layouts, date)
+		}
+		return layouts
+	}()
+)
+
+// parseImportTime parses a date and time, or a date and a separate time of
+// day. Dates are YYYY-MM-DD, MM/DD/YYYY or DD.MM.YYYY; a date alone is the
+// start of that day.
+func parseImportTime(date, clock string, loc *time.Location) (time.Time, error) {
+	value := date
+	if clock != "" {
+		value = date + " " + clock
+	}
+	for _, layout := range importTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date or time %q", value)
+}
+
+// parseImportDuration parses hours, a Go duration or hours, minutes and
+// seconds like "1:30:00", to the second.
+func parseImportDuration(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) == 1 {
+		d, err := ParseDuration(s)
+		return d.Round(time.Second), err
+	}
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("%w %q", ErrInvalidDuration, s)
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second}[:len(parts)] {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%w %q", ErrInvalidDuration, s)
+		}
+		d += time.Duration(n) * unit
+	}
+	return d, nil
+}
+
+// sessionKey identifies a session for deduplication: the same subject,
+// regardless of case, started in the same second and lasting as long.
+type sessionKey struct {
+	subject  string
+	start    int64
+	duration time.Duration
+}
+
+func keyOf(session LoggedSession) sessionKey {
+	return sessionKey{
+		subject:  strings.ToLower(strings.TrimSpace(session.Subject)),
+		start:    session.StartedAt.Unix(),
+		duration: session.Duration.Round(time.Second),
+	}
+}
+
+// DuplicatesWithin returns the period in which the logged sessions start that
+// may duplicate one of sessions, which must not be empty.
+func DuplicatesWithin(sessions []LoggedSession) TimeRange {
+	first := slices.MinFunc(sessions, func(a, b LoggedSession) int { return a.StartedAt.Compare(b.StartedAt) })
+	last := slices.MaxFunc(sessions, func(a, b LoggedSession) int { return a.StartedAt.Compare(b.StartedAt) })
+	return TimeRange{
+		From: first.StartedAt.Truncate(time.Second),
+		To:   last.StartedAt.Truncate(time.Second).Add(time.Second),
+	}
+}
+
+// NewSessions returns the sessions that are neither in logged nor repeated
+// earlier in sessions: none has the same subject, regardless of case, started
+// in the same second and lasting as long.
+func NewSessions(sessions, logged []LoggedSession) []LoggedSession {
+	seen := make(map[sessionKey]bool, len(logged)+len(sessions))
+	for _, session := range logged {
+		seen[keyOf(session)] = true
+	}
+	fresh := make([]LoggedSession, 0, len(sessions))
+	for _, session := range sessions {
+		key := keyOf(session)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		fresh = append(fresh, session)
+	}
+	return fresh
+}
+
+// add counts fresh into the summary.
+func (s *ImportSummary) add(fresh []LoggedSession) {
+	s.Imported = len(fresh)
+	s.Duplicates = s.Read - len(fresh)
+	totals := make(map[string]time.Duration)
+	for _, session := range fresh {
+		if s.From.IsZero() || session.StartedAt.Before(s.From) {
+			s.From = session.StartedAt
+		}
+		if session.EndedAt.After(s.To) {
+			s.To = session.EndedAt
+		}
+		totals[session.Subject] += session.Duration
+	}
+	s.Subjects = make(Report, 0, len(totals))
+	for subject, total := range totals {
+		s.Subjects = append(s.Subjects, StudyActivity{Subject: subject, Duration: total})
+	}
+	slices.SortFunc(s.Subjects, func(a, b StudyActivity) int {
+		return cmp.Or(cmp.Compare(b.Duration, a.Duration), cmp.Compare(a.Subject, b.Subject))
+	})
+}
@@ -0,0 +1,191 @@
+package domain_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/adapters/database"
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	togglCSV = `User,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags,Amount ()
+Ann,ann@example.com,,Go,,Concurrency,No,2026-03-16,09:00:00,2026-03-16,10:30:00,01:30:00,,
+Ann,ann@example.com,,SQL,,Joins,No,2026-03-16,23:30:00,2026-03-17,00:15:00,00:45:00,,
+`
+	clockifyCSV = `Project,Client,Description,Task,User,Group,Email,Tags,Billable,Start Date,Start Time,End Date,End Time,Duration (h),Duration (decimal)
+Go,,Concurrency,,Ann,,ann@example.com,,No,03/16/2026,09:00:00 AM,03/16/2026,10:30:00 AM,01:30:00,1.50
+`
+)
+
+func TestParseImport(t *testing.T) {
+	utc := func(day, hour, minute int) time.Time { return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name    string
+		input   string
+		options domain.ImportOptions
+		want    []domain.LoggedSession
+	}{
+		{
+			name:    "toggl detailed report",
+			input:   togglCSV,
+			options: domain.ImportOptions{Format: domain.ImportToggl},
+			want: []domain.LoggedSession{
+				{Subject: "Go", StartedAt: utc(16, 9, 0), EndedAt: utc(16, 10, 30), Duration: 90 * time.Minute, Source: domain.SourceImport},
+				{Subject: "SQL", StartedAt: utc(16, 23, 30), EndedAt: utc(17, 0, 15), Duration: 45 * time.Minute, Source: domain.SourceImport},
+			},
+		},
+		{
+			name:    "toggl description as the subject",
+			input:   togglCSV,
+			options: domain.ImportOptions{Format: domain.ImportToggl, Columns: domain.ImportColumns{Subject: "description"}},
+			want: []domain.LoggedSession{
+				{Subject: "Concurrency", StartedAt: utc(16, 9, 0), EndedAt: utc(16, 10, 30), Duration: 90 * time.Minute, Source: domain.SourceImport},
+				{Subject: "Joins", StartedAt: utc(16, 23, 30), EndedAt: utc(17, 0, 15), Duration: 45 * time.Minute, Source: domain.SourceImport},
+			},
+		},
+		{
+			name:    "clockify detailed report",
+			input:   clockifyCSV,
+			options: domain.ImportOptions{Format: domain.ImportClockify},
+			want: []domain.LoggedSession{
+				{Subject: "Go", StartedAt: utc(16, 9, 0), EndedAt: utc(16, 10, 30), Duration: 90 * time.Minute, Source: domain.SourceImport},
+			},
+		},
+		{
+			name:    "spreadsheet with a byte order mark and its own columns",
+			input:   "\ufeffDay,Course,Hours\n2026-03-16,Go,1.5\n\n16.03.2026,SQL,2\n",
+			options: domain.ImportOptions{Columns: domain.ImportColumns{Subject: "Course", Start: "Day", Duration: "Hours"}},
+			want: []domain.LoggedSession{
+				{Subject: "Go", StartedAt: utc(16, 0, 0), EndedAt: utc(16, 1, 30), Duration: 90 * time.Minute, Source: domain.SourceImport},
+				{Subject: "SQL", StartedAt: utc(16, 0, 0), EndedAt: utc(16, 2, 0), Duration: 2 * time.Hour, Source: domain.SourceImport},
+			},
+		},
+		{
+			name:    "start and end without a duration",
+			input:   "subject,started_at,ended_at\ngo,2026-03-16 09:00,2026-03-16 09:25\n",
+			options: domain.ImportOptions{Format: domain.ImportCSV},
+			want: []domain.LoggedSession{
+				{Subject: "go", StartedAt: utc(16, 9, 0), EndedAt: utc(16, 9, 25), Duration: 25 * time.Minute, Source: domain.SourceImport},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.options.Location = time.UTC
+			got, err := domain.ParseImport(strings.NewReader(tt.input), tt.options)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("reads back what was exported", func(t *testing.T) {
+		start := time.Date(2026, 3, 16, 9, 0, 0, 123456789, time.UTC)
+		sessions := []domain.LoggedSession{
+			{Subject: "go", StartedAt: start, EndedAt: start.Add(25 * time.Minute), Duration: 25 * time.Minute, Source: domain.SourcePomodoro},
+			{Subject: "sql, joins", StartedAt: start.Add(time.Hour), EndedAt: start.Add(2 * time.Hour), Duration: time.Hour, Source: domain.SourceManual},
+		}
+		for _, format := range []domain.ExportFormat{domain.ExportCSV, domain.ExportJSONLines} {
+			out := &bytes.Buffer{}
+			exporter, err := domain.NewSessionExporter(out, format)
+			require.NoError(t, err)
+			for _, session := range sessions {
+				require.NoError(t, exporter.Export(session))
+			}
+			require.NoError(t, exporter.Flush())
+
+			got, err := domain.ParseImport(out, domain.ImportOptions{Format: domain.ImportFormat(format)})
+			require.NoError(t, err, format)
+			if assert.Len(t, got, 2, format) {
+				assert.True(t, start.Equal(got[0].StartedAt), format)
+				assert.Equal(t, domain.SourcePomodoro, got[0].Source, "%s should keep the source", format)
+				assert.Equal(t, "sql, joins", got[1].Subject, format)
+				assert.Equal(t, time.Hour, got[1].Duration, format)
+			}
+		}
+	})
+
+	errorTests := []struct {
+		name    string
+		input   string
+		options domain.ImportOptions
+		want    string
+	}{
+		{"unknown format", "", domain.ImportOptions{Format: "xml"}, `invalid import format "xml"`},
+		{"empty file", "", domain.ImportOptions{}, "the file is empty"},
+		{"missing column", "subject,date\n", domain.ImportOptions{}, `no "started_at" column`},
+		{"missing mapped column", togglCSV, domain.ImportOptions{Format: domain.ImportToggl, Columns: domain.ImportColumns{Duration: "Hours"}}, `no "Hours" column`},
+		{"no duration or end column", "subject,started_at\n", domain.ImportOptions{}, "needs a duration or an end column"},
+		{"unknown date", "subject,started_at,duration\ngo,2026-03-16,1h\ngo,yesterday,1h\n", domain.ImportOptions{}, `line 3: unknown date or time "yesterday"`},
+		{"invalid duration", "subject,started_at,duration\ngo,2026-03-16,1:xx\n", domain.ImportOptions{}, `line 2: invalid duration "1:xx"`},
+		{"no subject", "subject,started_at,duration\n,2026-03-16,1h\n", domain.ImportOptions{}, "line 2: no subject"},
+		{"broken json line", `{"subject":"go"`, domain.ImportOptions{Format: domain.ImportJSONLines}, "entry 1"},
+	}
+	for _, tt := range errorTests {
+		t.Run("rejects "+tt.name, func(t *testing.T) {
+			_, err := domain.ParseImport(strings.NewReader(tt.input), tt.options)
+			assert.ErrorIs(t, err, domain.ErrInvalidImport)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestStudySession_Import(t *testing.T) {
+	store := database.NewInMemorySubjectStore()
+	session := domain.NewStudySession(store, &SpyPomodoroRunner{}, testhelpers.NewFakeClock(sessionStart))
+	_, err := store.LogSession(t.Context(), domain.LoggedSession{
+		Subject:   "go",
+		StartedAt: time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC),
+		EndedAt:   time.Date(2026, 3, 16, 10, 30, 0, 0, time.UTC),
+		Duration:  90 * time.Minute,
+		Source:    domain.SourceManual,
+	})
+	require.NoError(t, err)
+	// The first row is logged already and the last one repeats the second.
+	input := togglCSV + "Ann,ann@example.com,,sql,,Joins,No,2026-03-16,23:30:00,2026-03-17,00:15:00,00:45:00,,\n"
+	options := domain.ImportOptions{Format: domain.ImportToggl, Location: time.UTC}
+
+	t.Run("sums up a dry run without logging", func(t *testing.T) {
+		options := options
+		options.DryRun = true
+		summary, err := session.Import(t.Context(), strings.NewReader(input), options)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{
+			Format:     domain.ImportToggl,
+			Read:       3,
+			Imported:   1,
+			Duplicates: 2,
+			DryRun:     true,
+			From:       time.Date(2026, 3, 16, 23, 30, 0, 0, time.UTC),
+			To:         time.Date(2026, 3, 17, 0, 15, 0, 0, time.UTC),
+			Subjects:   domain.Report{{Subject: "SQL", Duration: 45 * time.Minute}},
+		}, summary)
+		assert.Len(t, loggedSessions(t, store), 1)
+	})
+	t.Run("logs the new sessions", func(t *testing.T) {
+		summary, err := session.Import(t.Context(), strings.NewReader(input), options)
+		require.NoError(t, err)
+		assert.Equal(t, 1, summary.Imported)
+		require.Len(t, loggedSessions(t, store), 2)
+		assert.Equal(t, "SQL", loggedSessions(t, store)[1].Subject)
+		assert.Equal(t, domain.SourceImport, loggedSessions(t, store)[1].Source)
+	})
+	t.Run("logs nothing twice", func(t *testing.T) {
+		summary, err := session.Import(t.Context(), strings.NewReader(input), options)
+		require.NoError(t, err)
+		assert.Zero(t, summary.Imported)
+		assert.Equal(t, 3, summary.Duplicates)
+		assert.Len(t, loggedSessions(t, store), 2)
+	})
+	t.Run("logs nothing from an invalid file", func(t *testing.T) {
+		_, err := session.Import(t.Context(), strings.NewReader(input+"Ann,,,Go,,,No,someday,,,,1:00:00,,\n"), options)
+		assert.ErrorIs(t, err, domain.ErrInvalidImport)
+		assert.Len(t, loggedSessions(t, store), 2)
+	})
+}
@@ -0,0 +1,54 @@
+package pomodoro
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+// StartCycle runs a cycle of Pomodoros: each focus block is followed by a
+// short break, and the last one by a long break. Phase changes are announced
+// through the alerter and pauses apply to breaks as well. hooks.OnRound is
+// called as each focus block starts and hooks.OnFocus with every focus block
+// as it ends, including one cut short by cancellation.
+func (p *Pomodoro) StartCycle(ctx context.Context, config domain.PomodoroConfig, out io.Writer, control *domain.PomodoroControl, hooks domain.CycleHooks) error {
+	config = p.config.Override(config)
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	rounds := config.CycleRounds()
+	for round := config.StartRound(); round <= rounds; round++ {
+		p.alerter.Alert(fmt.Sprintf("Pomodoro %d of %d", round, rounds), out)
+		if hooks.OnRound != nil {
+			if err := hooks.OnRound(round); err != nil {
+				return err
+			}
+		}
+		startedAt := p.clock.Now()
+		focused, err := p.Start(ctx, config.RoundConfig(round), out, control)
+		if focused > 0 && hooks.OnFocus != nil {
+			if err := hooks.OnFocus(domain.FocusBlock{Round: round, StartedAt: startedAt, EndedAt: p.clock.Now(), Focused: focused}); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		breakTime, message := config.ShortBreakDuration(), "Short break: %s. Step away from the screen."
+		if round == rounds {
+			breakTime, message = config.LongBreakDuration(), "Long break: %s. You earned it!"
+		}
+		p.alerter.Alert(fmt.Sprintf(message, domain.FormatDuration(breakTime)), out)
+
+		rest := phase{length: breakTime, tick: config.TickInterval(), out: out}
+		if err := p.runUntil(ctx, control, &rest, rest.length); err != nil {
+			return err
+		}
+	}
+	p.alerter.Alert("Cycle complete! Start another one when you are ready.", out)
+	return nil
+}
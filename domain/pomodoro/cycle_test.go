@@ -0,0 +1,133 @@
+package pomodoro
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPomodoro_StartCycle(t *testing.T) {
+	config := domain.PomodoroConfig{
+		Focus:      10 * time.Minute,
+		Alerts:     []domain.PomodoroAlert{},
+		ShortBreak: 2 * time.Minute,
+		LongBreak:  5 * time.Minute,
+		Rounds:     2,
+	}
+
+	t.Run("alternates focus and breaks, reporting only focus", func(t *testing.T) {
+		p, alerter, clock := newTestPomodoro(config)
+
+		var blocks []domain.FocusBlock
+		err := fastForward(clock, func() error {
+			return p.StartCycle(t.Context(), domain.PomodoroConfig{}, &bytes.Buffer{}, domain.NewPomodoroControl(), domain.CycleHooks{OnFocus: func(block domain.FocusBlock) error {
+				blocks = append(blocks, block)
+				return nil
+			}})
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []ScheduledAlert{
+			{0, "Pomodoro 1 of 2"},
+			{10 * time.Minute, "Short break: 2m. Step away from the screen."},
+			{12 * time.Minute, "Pomodoro 2 of 2"},
+			{22 * time.Minute, "Long break: 5m. You earned it!"},
+			{27 * time.Minute, "Cycle complete! Start another one when you are ready."},
+		}, alerter.alerts())
+		assert.Equal(t, []domain.FocusBlock{
+			{Round: 1, StartedAt: sessionStart, EndedAt: sessionStart.Add(10 * time.Minute), Focused: 10 * time.Minute},
+			{Round: 2, StartedAt: sessionStart.Add(12 * time.Minute), EndedAt: sessionStart.Add(22 * time.Minute), Focused: 10 * time.Minute},
+		}, blocks)
+	})
+	t.Run("defaults to four rounds", func(t *testing.T) {
+		p, _, clock := newTestPomodoro(domain.PomodoroConfig{Alerts: []domain.PomodoroAlert{}})
+
+		var blocks int
+		err := fastForward(clock, func() error {
+			return p.StartCycle(t.Context(), domain.PomodoroConfig{}, &bytes.Buffer{}, domain.NewPomodoroControl(), domain.CycleHooks{OnFocus: func(domain.FocusBlock) error {
+				blocks++
+				return nil
+			}})
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, 4, blocks)
+		assert.Equal(t, 4*25*time.Minute+3*5*time.Minute+15*time.Minute, clock.Now().Sub(sessionStart))
+	})
+	t.Run("carries on from a later round with the focus it has left", func(t *testing.T) {
+		p, alerter, clock := newTestPomodoro(config)
+
+		var rounds []int
+		var blocks []domain.FocusBlock
+		err := fastForward(clock, func() error {
+			return p.StartCycle(t.Context(), domain.PomodoroConfig{FirstRound: 2, FirstFocus: 4 * time.Minute}, &bytes.Buffer{}, domain.NewPomodoroControl(), domain.CycleHooks{
+				OnRound: func(round int) error {
+					rounds = append(rounds, round)
+					return nil
+				},
+				OnFocus: func(block domain.FocusBlock) error {
+					blocks = append(blocks, block)
+					return nil
+				},
+			})
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []int{2}, rounds)
+		assert.Equal(t, []domain.FocusBlock{
+			{Round: 2, StartedAt: sessionStart, EndedAt: sessionStart.Add(4 * time.Minute), Focused: 4 * time.Minute},
+		}, blocks)
+		assert.Equal(t, []ScheduledAlert{
+			{0, "Pomodoro 2 of 2"},
+			{0, "Pomodoro resumed with 4m left."},
+			{4 * time.Minute, "Time's up! Recording your session..."},
+			{4 * time.Minute, "Long break: 5m. You earned it!"},
+			{9 * time.Minute, "Cycle complete! Start another one when you are ready."},
+		}, alerter.alerts())
+	})
+	t.Run("stops when cancelled during a break", func(t *testing.T) {
+		p, alerter, clock := newTestPomodoro(config)
+		control := domain.NewPomodoroControl()
+
+		var blocks int
+		err := fastForward(clock, func() error {
+			return p.StartCycle(t.Context(), domain.PomodoroConfig{}, &bytes.Buffer{}, control, domain.CycleHooks{OnFocus: func(domain.FocusBlock) error {
+				blocks++
+				return control.Cancel()
+			}})
+		})
+
+		assert.ErrorIs(t, err, domain.ErrPomodoroCancelled)
+		assert.Equal(t, 1, blocks)
+		alerts := alerter.alerts()
+		assert.Equal(t, "Short break: 2m. Step away from the screen.", alerts[len(alerts)-1].Message)
+	})
+	t.Run("stops when a focus block cannot be recorded", func(t *testing.T) {
+		p, alerter, clock := newTestPomodoro(config)
+		storeErr := errors.New("store is down")
+
+		err := fastForward(clock, func() error {
+			return p.StartCycle(t.Context(), domain.PomodoroConfig{}, &bytes.Buffer{}, domain.NewPomodoroControl(), domain.CycleHooks{OnFocus: func(domain.FocusBlock) error {
+				return storeErr
+			}})
+		})
+
+		assert.ErrorIs(t, err, storeErr)
+		assert.Len(t, alerter.alerts(), 1, "should not go on to the break")
+	})
+	t.Run("rejects an invalid config", func(t *testing.T) {
+		p, _, _ := newTestPomodoro(config)
+
+		err := p.StartCycle(t.Context(), domain.PomodoroConfig{Rounds: -1}, &bytes.Buffer{}, domain.NewPomodoroControl(), domain.CycleHooks{OnFocus: func(domain.FocusBlock) error {
+			return nil
+		}})
+		assert.ErrorIs(t, err, domain.ErrInvalidPomodoroConfig)
+
+		err = p.StartCycle(t.Context(), domain.PomodoroConfig{FirstRound: 3}, &bytes.Buffer{}, domain.NewPomodoroControl(), domain.CycleHooks{})
+		assert.ErrorIs(t, err, domain.ErrInvalidPomodoroConfig, "the first round should be within the cycle")
+	})
+}
@@ -2,36 +2,133 @@
 package pomodoro
 
 import (
+	"context"
 	"io"
 	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
 )
 
-const DefaultPomodoroDuration = 25 * time.Minute
+const DefaultPomodoroDuration = domain.DefaultPomodoroFocus
 
-// PomodoroAlerter represents a timer that can wait for a specified duration.
+// PomodoroAlerter delivers Pomodoro alerts and the periodic countdown of the
+// time remaining in the current focus block or break.
 type PomodoroAlerter interface {
-	ScheduleAlert(duration time.Duration, message string, out io.Writer)
-	Wait(duration time.Duration)
+	Alert(message string, out io.Writer)
+	Tick(remaining time.Duration, out io.Writer)
 }
 
 // Pomodoro represents a timer for focused study sessions using the Pomodoro Technique.
 type Pomodoro struct {
-	alerter  PomodoroAlerter
-	duration time.Duration
+	alerter PomodoroAlerter
+	clock   domain.Clock
+	config  domain.PomodoroConfig
 }
 
-// NewPomodoro creates a new Pomodoro timer with a default duration of 25 minutes.
-func NewPomodoro(alerter PomodoroAlerter) *Pomodoro {
+// NewPomodoro creates a new Pomodoro timer. Fields left zero in config fall
+// back to 25 minutes of focus with alerts at the start, halfway and the end.
+func NewPomodoro(alerter PomodoroAlerter, clock domain.Clock, config domain.PomodoroConfig) *Pomodoro {
 	return &Pomodoro{
-		alerter:  alerter,                 // timer implementation
-		duration: DefaultPomodoroDuration, // length of pomodoro session
+		alerter: alerter, // alert delivery
+		clock:   clock,   // timers and the focus time cut short by pauses
+		config:  config,  // default focus length and alert schedule
+	}
+}
+
+// Config returns the settings a start config overrides.
+func (p *Pomodoro) Config() domain.PomodoroConfig {
+	return p.config
+}
+
+// Start runs the Pomodoro with the fields set in config overriding its own
+// and returns the focus time. Paused time does not count. On cancellation
+// through control or ctx it returns the focus time spent so far along with
+// domain.ErrPomodoroCancelled or the context error.
+func (p *Pomodoro) Start(ctx context.Context, config domain.PomodoroConfig, out io.Writer, control *domain.PomodoroControl) (time.Duration, error) {
+	config = p.config.Override(config)
+	schedule, err := config.Schedule()
+	if err != nil {
+		return 0, err
+	}
+
+	focus := phase{length: config.FocusDuration(), tick: config.TickInterval(), out: out}
+	for _, a := range schedule {
+		if err := p.runUntil(ctx, control, &focus, a.At); err != nil {
+			return focus.elapsed, err
+		}
+		p.alerter.Alert(a.Message, out)
+	}
+	if err := p.runUntil(ctx, control, &focus, focus.length); err != nil {
+		return focus.elapsed, err
+	}
+	return focus.elapsed, nil
+}
+
+// phase is a focus block or break being timed.
+type phase struct {
+	length  time.Duration
+	elapsed time.Duration
+	tick    time.Duration
+	out     io.Writer
+}
+
+// runUntil lets the elapsed phase time run until it reaches target, sitting
+// out pauses and ticking the time remaining in the phase.
+func (p *Pomodoro) runUntil(ctx context.Context, control *domain.PomodoroControl, ph *phase, target time.Duration) error {
+	for ph.elapsed < target {
+		changed := control.Changed()
+		switch control.State() {
+		case domain.PomodoroCancelled:
+			return domain.ErrPomodoroCancelled
+		case domain.PomodoroPaused:
+			select {
+			case <-changed:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if err := p.runSegment(ctx, changed, ph, target); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-// Start begins the Pomodoro timer and waits for the configured duration.
-func (p *Pomodoro) Start(out io.Writer) {
-	p.alerter.ScheduleAlert(0, "Session started. Stay focused!", out)
-	p.alerter.ScheduleAlert(p.duration/2, "Halfway there! Keep it up.", out)
-	p.alerter.ScheduleAlert(p.duration, "Time's up! Recording your hour...", out)
-	p.alerter.Wait(p.duration)
+// runSegment waits until target is reached, the control changes or ctx is
+// done, whichever comes first, and adds the time waited to the phase.
+func (p *Pomodoro) runSegment(ctx context.Context, changed <-chan struct{}, ph *phase, target time.Duration) error {
+	started := p.clock.Now()
+	waited := func() time.Duration {
+		return min(target, ph.elapsed+p.clock.Now().Sub(started))
+	}
+
+	reached := make(chan struct{})
+	timer := p.clock.AfterFunc(target-ph.elapsed, func() { close(reached) })
+	defer timer.Stop()
+	var ticks <-chan time.Time // stays nil when no tick falls within the segment
+	if ph.tick < target-ph.elapsed {
+		ticker := p.clock.NewTicker(ph.tick)
+		defer ticker.Stop()
+		ticks = ticker.C()
+	}
+
+	for {
+		select {
+		case <-reached:
+			ph.elapsed = target
+			return nil
+		case <-ticks:
+			if remaining := ph.length - waited(); remaining > 0 {
+				p.alerter.Tick(remaining.Round(time.Second), ph.out)
+			}
+		case <-changed:
+			ph.elapsed = waited()
+			return nil
+		case <-ctx.Done():
+			ph.elapsed = waited()
+			return ctx.Err()
+		}
+	}
 }
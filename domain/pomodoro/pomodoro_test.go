@@ -2,45 +2,238 @@ package pomodoro
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/bryack/study_hours_tracker/testhelpers"
 	"github.com/stretchr/testify/assert"
 )
 
+var sessionStart = time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)
+
 type ScheduledAlert struct {
 	At      time.Duration
 	Message string
 }
 
+// SpyScheduleAlerter records when each alert was delivered on its clock.
 type SpyScheduleAlerter struct {
-	Alerts     []ScheduledAlert
-	WaitCalled int
+	clock *testhelpers.FakeClock
+
+	mu     sync.Mutex
+	Alerts []ScheduledAlert
+	Ticks  []time.Duration
+}
+
+func (s *SpyScheduleAlerter) Tick(remaining time.Duration, out io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Ticks = append(s.Ticks, remaining)
+}
+
+func (s *SpyScheduleAlerter) ticks() []time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.Ticks)
+}
+
+func (s *SpyScheduleAlerter) Alert(message string, out io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Alerts = append(s.Alerts, ScheduledAlert{At: s.clock.Now().Sub(sessionStart), Message: message})
+}
+
+func (s *SpyScheduleAlerter) alerts() []ScheduledAlert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.Alerts)
+}
+
+// newTestPomodoro builds a Pomodoro on a fake clock. Unless config sets a tick,
+// ticks are pushed out of the way so that fastForward lands on every alert.
+func newTestPomodoro(config domain.PomodoroConfig) (*Pomodoro, *SpyScheduleAlerter, *testhelpers.FakeClock) {
+	clock := testhelpers.NewFakeClock(sessionStart)
+	alerter := &SpyScheduleAlerter{clock: clock}
+	config = domain.PomodoroConfig{Tick: 24 * time.Hour}.Override(config)
+	return NewPomodoro(alerter, clock, config), alerter, clock
 }
 
-func (s *SpyScheduleAlerter) ScheduleAlert(duration time.Duration, message string, out io.Writer) {
-	s.Alerts = append(s.Alerts, ScheduledAlert{At: duration, Message: message})
+// fastForward runs start while moving the clock on to every timer it waits
+// for, so the run takes no real time.
+func fastForward[T any](clock *testhelpers.FakeClock, start func() T) T {
+	done := make(chan T, 1)
+	go func() { done <- start() }()
+	for {
+		select {
+		case got := <-done:
+			return got
+		case <-clock.Waiting():
+			clock.AdvanceToNext()
+		}
+	}
 }
 
-func (s *SpyScheduleAlerter) Wait(duration time.Duration) {
-	s.WaitCalled++
+type startResult struct {
+	focused time.Duration
+	err     error
 }
 
 func TestPomodoro_Start(t *testing.T) {
 	testcases := []ScheduledAlert{
 		{0, "Session started. Stay focused!"},
 		{12*time.Minute + 30*time.Second, "Halfway there! Keep it up."},
-		{25 * time.Minute, "Time's up! Recording your hour..."},
+		{25 * time.Minute, "Time's up! Recording your session..."},
 	}
 
-	out := &bytes.Buffer{}
-	alerter := &SpyScheduleAlerter{}
-	p := NewPomodoro(alerter)
-	p.Start(out)
+	p, alerter, clock := newTestPomodoro(domain.PomodoroConfig{})
+	got := fastForward(clock, func() startResult {
+		focused, err := p.Start(t.Context(), domain.PomodoroConfig{}, &bytes.Buffer{}, domain.NewPomodoroControl())
+		return startResult{focused, err}
+	})
+	assert.NoError(t, got.err)
+
+	assert.Equal(t, testcases, alerter.alerts())
+	assert.Equal(t, DefaultPomodoroDuration, got.focused, "should report the full focus time")
+	assert.Equal(t, sessionStart.Add(25*time.Minute), clock.Now())
+}
+
+func TestPomodoro_Ticks(t *testing.T) {
+	p, alerter, clock := newTestPomodoro(domain.PomodoroConfig{
+		Focus:  3 * time.Minute,
+		Alerts: []domain.PomodoroAlert{},
+		Tick:   time.Minute,
+	})
+
+	done := make(chan startResult)
+	go func() {
+		focused, err := p.Start(t.Context(), domain.PomodoroConfig{}, &bytes.Buffer{}, domain.NewPomodoroControl())
+		done <- startResult{focused, err}
+	}()
 
-	for i, want := range testcases {
-		assert.Equal(t, want, alerter.Alerts[i])
+	<-clock.Waiting()
+	for want := 1; want <= 2; want++ {
+		clock.Advance(time.Minute)
+		assert.Eventually(t, func() bool { return len(alerter.ticks()) == want }, time.Second, time.Millisecond)
 	}
-	assert.Equal(t, 1, alerter.WaitCalled)
+	clock.Advance(time.Minute)
+
+	got := <-done
+	assert.NoError(t, got.err)
+	assert.Equal(t, 3*time.Minute, got.focused)
+	assert.Equal(t, []time.Duration{2 * time.Minute, time.Minute}, alerter.ticks(), "should count down without a tick at zero")
+	assert.Zero(t, clock.Pending(), "should stop its ticker")
+}
+
+func TestPomodoro_StartConfigured(t *testing.T) {
+	defaults := domain.PomodoroConfig{
+		Focus: 50 * time.Minute,
+		Alerts: []domain.PomodoroAlert{
+			{At: 5 * time.Minute, FromEnd: true, Message: "5 minutes left"},
+			{At: 0, Message: "Go!"},
+		},
+	}
+
+	t.Run("uses the configured focus and alerts", func(t *testing.T) {
+		p, alerter, clock := newTestPomodoro(defaults)
+		got := fastForward(clock, func() startResult {
+			focused, err := p.Start(t.Context(), domain.PomodoroConfig{}, &bytes.Buffer{}, domain.NewPomodoroControl())
+			return startResult{focused, err}
+		})
+		assert.NoError(t, got.err)
+
+		assert.Equal(t, []ScheduledAlert{{0, "Go!"}, {45 * time.Minute, "5 minutes left"}}, alerter.alerts())
+		assert.Equal(t, 50*time.Minute, got.focused, "should focus until the end even without an alert there")
+	})
+	t.Run("session config overrides the focus length", func(t *testing.T) {
+		p, alerter, clock := newTestPomodoro(defaults)
+		got := fastForward(clock, func() startResult {
+			focused, err := p.Start(t.Context(), domain.PomodoroConfig{Focus: 10 * time.Minute}, &bytes.Buffer{}, domain.NewPomodoroControl())
+			return startResult{focused, err}
+		})
+		assert.NoError(t, got.err)
+
+		assert.Equal(t, []ScheduledAlert{{0, "Go!"}, {5 * time.Minute, "5 minutes left"}}, alerter.alerts())
+		assert.Equal(t, 10*time.Minute, got.focused)
+	})
+	t.Run("rejects alerts outside the focus", func(t *testing.T) {
+		p, alerter, _ := newTestPomodoro(defaults)
+		got, err := p.Start(t.Context(), domain.PomodoroConfig{Focus: 3 * time.Minute}, &bytes.Buffer{}, domain.NewPomodoroControl())
+
+		assert.ErrorIs(t, err, domain.ErrInvalidPomodoroConfig)
+		assert.Zero(t, got)
+		assert.Empty(t, alerter.alerts())
+	})
+}
+
+func TestPomodoro_StartCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	p, _, clock := newTestPomodoro(domain.PomodoroConfig{})
+	got, err := p.Start(ctx, domain.PomodoroConfig{}, &bytes.Buffer{}, domain.NewPomodoroControl())
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, got)
+	assert.Zero(t, clock.Pending(), "should stop its timer")
+}
+
+func TestPomodoro_PauseResumeCancel(t *testing.T) {
+	p, _, clock := newTestPomodoro(domain.PomodoroConfig{})
+	control := domain.NewPomodoroControl()
+
+	done := make(chan startResult)
+	go func() {
+		focused, err := p.Start(t.Context(), domain.PomodoroConfig{}, &bytes.Buffer{}, control)
+		done <- startResult{focused, err}
+	}()
+
+	<-clock.Waiting()
+	clock.Advance(5 * time.Minute)
+	assert.NoError(t, control.Pause())
+	assertNoTimers(t, clock)
+
+	clock.Advance(10 * time.Minute)
+	assert.NoError(t, control.Resume())
+	<-clock.Waiting()
+
+	clock.Advance(3 * time.Minute)
+	assert.NoError(t, control.Cancel())
+
+	got := <-done
+	assert.ErrorIs(t, got.err, domain.ErrPomodoroCancelled)
+	assert.Equal(t, 8*time.Minute, got.focused, "paused time should not count as focus")
+}
+
+func TestPomodoro_CancelWhilePaused(t *testing.T) {
+	p, _, clock := newTestPomodoro(domain.PomodoroConfig{})
+	control := domain.NewPomodoroControl()
+
+	done := make(chan startResult)
+	go func() {
+		focused, err := p.Start(t.Context(), domain.PomodoroConfig{}, &bytes.Buffer{}, control)
+		done <- startResult{focused, err}
+	}()
+
+	<-clock.Waiting()
+	clock.Advance(2 * time.Minute)
+	assert.NoError(t, control.Pause())
+	assertNoTimers(t, clock)
+
+	assert.NoError(t, control.Cancel())
+
+	got := <-done
+	assert.ErrorIs(t, got.err, domain.ErrPomodoroCancelled)
+	assert.Equal(t, 2*time.Minute, got.focused)
+}
+
+// assertNoTimers waits for the Pomodoro to stop its timer, which it does
+// once it has taken in a pause.
+func assertNoTimers(t testing.TB, clock *testhelpers.FakeClock) {
+	t.Helper()
+	assert.Eventually(t, func() bool { return clock.Pending() == 0 }, time.Second, time.Millisecond)
 }
@@ -0,0 +1,297 @@
+package domain
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+var ErrInvalidPomodoroConfig = errors.New("invalid pomodoro config")
+
+// Defaults used for the fields left zero in a PomodoroConfig.
+const (
+	DefaultPomodoroFocus = 25 * time.Minute
+	DefaultShortBreak    = 5 * time.Minute
+	DefaultLongBreak     = 15 * time.Minute
+	DefaultCycleRounds   = 4
+	DefaultTick          = time.Second
+)
+
+// PomodoroAlert is a message delivered at a point of the focus time.
+type PomodoroAlert struct {
+	// At is the offset from the start of the focus, or from its end when FromEnd is set.
+	At      time.Duration
+	FromEnd bool
+	Message string
+}
+
+// PomodoroConfig sets the focus length, alert schedule and countdown ticks of
+// a Pomodoro, and the breaks of a cycle of Pomodoros. Zero fields fall back to
+// the defaults: 25 minutes of focus with alerts at the start, halfway and at
+// the end, a tick every second, and cycles of 4 Pomodoros with 5 minute short
+// breaks and a 15 minute long break.
+type PomodoroConfig struct {
+	Focus  time.Duration
+	Alerts []PomodoroAlert
+	Tick   time.Duration // interval between remaining-time ticks
+
+	ShortBreak time.Duration
+	LongBreak  time.Duration
+	Rounds     int // Pomodoros per cycle
+
+	// FirstRound and FirstFocus carry on a cycle from the focus of a later
+	// round, with the focus time it has left when FirstFocus is set.
+	FirstRound int
+	FirstFocus time.Duration
+}
+
+// Override returns c with every field set in o replacing its own.
+func (c PomodoroConfig) Override(o PomodoroConfig) PomodoroConfig {
+	if o.Focus != 0 {
+		c.Focus = o.Focus
+	}
+	if o.Alerts != nil {
+		c.Alerts = o.Alerts
+	}
+	if o.Tick != 0 {
+		c.Tick = o.Tick
+	}
+	if o.ShortBreak != 0 {
+		c.ShortBreak = o.ShortBreak
+	}
+	if o.LongBreak != 0 {
+		c.LongBreak = o.LongBreak
+	}
+	if o.Rounds != 0 {
+		c.Rounds = o.Rounds
+	}
+	if o.FirstRound != 0 {
+		c.FirstRound = o.FirstRound
+	}
+	if o.FirstFocus != 0 {
+		c.FirstFocus = o.FirstFocus
+	}
+	return c
+}
+
+// FocusDuration returns the configured focus length or the default one.
+func (c PomodoroConfig) FocusDuration() time.Duration {
+	if c.Focus == 0 {
+		return DefaultPomodoroFocus
+	}
+	return c.Focus
+}
+
+// TickInterval returns the configured interval between ticks or the default one.
+func (c PomodoroConfig) TickInterval() time.Duration {
+	return cmp.Or(c.Tick, DefaultTick)
+}
+
+// ShortBreakDuration returns the configured short break or the default one.
+func (c PomodoroConfig) ShortBreakDuration() time.Duration {
+	return cmp.Or(c.ShortBreak, DefaultShortBreak)
+}
+
+// LongBreakDuration returns the configured long break or the default one.
+func (c PomodoroConfig) LongBreakDuration() time.Duration {
+	return cmp.Or(c.LongBreak, DefaultLongBreak)
+}
+
+// CycleRounds returns the configured number of Pomodoros per cycle or the default one.
+func (c PomodoroConfig) CycleRounds() int {
+	return cmp.Or(c.Rounds, DefaultCycleRounds)
+}
+
+// StartRound returns the round a cycle starts with, the first unless FirstRound is set.
+func (c PomodoroConfig) StartRound() int {
+	return cmp.Or(c.FirstRound, 1)
+}
+
+// RoundConfig returns the config of the focus of round in a cycle: the
+// FirstFocus left of the round carried on, announced as resumed.
+func (c PomodoroConfig) RoundConfig(round int) PomodoroConfig {
+	if round != c.StartRound() || c.FirstFocus == 0 {
+		return c
+	}
+	c.Focus, c.Alerts = c.FirstFocus, ResumedAlerts(c.FirstFocus)
+	return c
+}
+
+// ResumedAlerts are the alerts of a Pomodoro carried on with remaining focus time.
+func ResumedAlerts(remaining time.Duration) []PomodoroAlert {
+	return []PomodoroAlert{
+		{At: 0, Message: fmt.Sprintf("Pomodoro resumed with %s left.", FormatDuration(remaining.Round(time.Second)))},
+		{At: remaining, Message: "Time's up! Recording your session..."},
+	}
+}
+
+// Schedule returns the alerts as offsets from the start of the focus, in the
+// order they fire.
+func (c PomodoroConfig) Schedule() ([]PomodoroAlert, error) {
+	focus := c.FocusDuration()
+	if focus < 0 {
+		return nil, fmt.Errorf("%w: focus %s should be positive", ErrInvalidPomodoroConfig, focus)
+	}
+
+	alerts := c.Alerts
+	if alerts == nil {
+		alerts = []PomodoroAlert{
+			{At: 0, Message: "Session started. Stay focused!"},
+			{At: focus / 2, Message: "Halfway there! Keep it up."},
+			{At: focus, Message: "Time's up! Recording your session..."},
+		}
+	}
+
+	schedule := make([]PomodoroAlert, 0, len(alerts))
+	for _, a := range alerts {
+		if a.Message == "" {
+			return nil, fmt.Errorf("%w: alert at %s has no message", ErrInvalidPomodoroConfig, a.At)
+		}
+		if a.At < 0 || a.At > focus {
+			return nil, fmt.Errorf("%w: alert %q at %s is outside the %s focus", ErrInvalidPomodoroConfig, a.Message, a.At, focus)
+		}
+		if a.FromEnd {
+			a = PomodoroAlert{At: focus - a.At, Message: a.Message}
+		}
+		schedule = append(schedule, a)
+	}
+	slices.SortStableFunc(schedule, func(a, b PomodoroAlert) int { return cmp.Compare(a.At, b.At) })
+	return schedule, nil
+}
+
+// Validate reports whether the config describes a runnable Pomodoro and cycle.
+func (c PomodoroConfig) Validate() error {
+	if c.ShortBreakDuration() < 0 || c.LongBreakDuration() < 0 {
+		return fmt.Errorf("%w: breaks should be positive", ErrInvalidPomodoroConfig)
+	}
+	if c.TickInterval() < 0 {
+		return fmt.Errorf("%w: tick %s should be positive", ErrInvalidPomodoroConfig, c.Tick)
+	}
+	if c.CycleRounds() < 0 {
+		return fmt.Errorf("%w: rounds %d should be positive", ErrInvalidPomodoroConfig, c.Rounds)
+	}
+	if c.StartRound() < 1 || c.StartRound() > c.CycleRounds() {
+		return fmt.Errorf("%w: first round %d should be one of the %d rounds", ErrInvalidPomodoroConfig, c.FirstRound, c.CycleRounds())
+	}
+	if c.FirstFocus < 0 {
+		return fmt.Errorf("%w: first focus %s should be positive", ErrInvalidPomodoroConfig, c.FirstFocus)
+	}
+	_, err := c.Schedule()
+	return err
+}
+
+// ParseFocus parses a focus length such as "50m". Unlike ParseDuration a
+// plain number is rejected, since "50" is more likely meant as minutes than hours.
+func ParseFocus(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%w: focus %q should be a positive duration such as 50m", ErrInvalidPomodoroConfig, s)
+	}
+	return d, nil
+}
+
+type pomodoroConfigJSON struct {
+	Focus      string              `json:"focus,omitempty"`
+	Alerts     []pomodoroAlertJSON `json:"alerts,omitempty"`
+	Tick       string              `json:"tick,omitempty"`
+	ShortBreak string              `json:"short_break,omitempty"`
+	LongBreak  string              `json:"long_break,omitempty"`
+	Rounds     int                 `json:"rounds,omitempty"`
+}
+
+type pomodoroAlertJSON struct {
+	At      string `json:"at,omitempty"`
+	Before  string `json:"before,omitempty"`
+	Message string `json:"message"`
+}
+
+// MarshalJSON encodes durations as Go duration strings and end-relative
+// alerts with "before" instead of "at".
+func (c PomodoroConfig) MarshalJSON() ([]byte, error) {
+	raw := pomodoroConfigJSON{Rounds: c.Rounds}
+	if c.Focus != 0 {
+		raw.Focus = FormatDuration(c.Focus)
+	}
+	if c.Tick != 0 {
+		raw.Tick = FormatDuration(c.Tick)
+	}
+	if c.ShortBreak != 0 {
+		raw.ShortBreak = FormatDuration(c.ShortBreak)
+	}
+	if c.LongBreak != 0 {
+		raw.LongBreak = FormatDuration(c.LongBreak)
+	}
+	for _, a := range c.Alerts {
+		alert := pomodoroAlertJSON{Message: a.Message}
+		if a.FromEnd {
+			alert.Before = FormatDuration(a.At)
+		} else {
+			alert.At = FormatDuration(a.At)
+		}
+		raw.Alerts = append(raw.Alerts, alert)
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON decodes a config such as
+// {"focus":"50m","alerts":[{"at":"0s","message":"Go!"},{"before":"5m","message":"5 minutes left"}]}.
+func (c *PomodoroConfig) UnmarshalJSON(data []byte) error {
+	var raw pomodoroConfigJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	config := PomodoroConfig{Rounds: raw.Rounds}
+	if raw.Rounds < 0 {
+		return fmt.Errorf("%w: rounds %d should be positive", ErrInvalidPomodoroConfig, raw.Rounds)
+	}
+	var err error
+	if config.Tick, err = parseInterval("tick", raw.Tick); err != nil {
+		return err
+	}
+	if config.ShortBreak, err = parseInterval("short_break", raw.ShortBreak); err != nil {
+		return err
+	}
+	if config.LongBreak, err = parseInterval("long_break", raw.LongBreak); err != nil {
+		return err
+	}
+	if raw.Focus != "" {
+		d, err := ParseFocus(raw.Focus)
+		if err != nil {
+			return err
+		}
+		config.Focus = d
+	}
+	if raw.Alerts != nil {
+		config.Alerts = make([]PomodoroAlert, 0, len(raw.Alerts))
+	}
+	for _, a := range raw.Alerts {
+		if a.At != "" && a.Before != "" {
+			return fmt.Errorf("%w: alert %q sets both at and before", ErrInvalidPomodoroConfig, a.Message)
+		}
+		offset := cmp.Or(a.At, a.Before, "0s")
+		d, err := time.ParseDuration(offset)
+		if err != nil {
+			return fmt.Errorf("%w: alert %q offset %q: %v", ErrInvalidPomodoroConfig, a.Message, offset, err)
+		}
+		config.Alerts = append(config.Alerts, PomodoroAlert{At: d, FromEnd: a.Before != "", Message: a.Message})
+	}
+
+	*c = config
+	return nil
+}
+
+// parseInterval parses an optional positive duration; an empty value leaves the default.
+func parseInterval(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%w: %s %q should be a positive duration", ErrInvalidPomodoroConfig, name, value)
+	}
+	return d, nil
+}
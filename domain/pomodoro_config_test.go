@@ -0,0 +1,156 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPomodoroConfig_Schedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  domain.PomodoroConfig
+		want    []domain.PomodoroAlert
+		wantErr error
+	}{
+		{
+			name:   "defaults to start, halfway and end of 25 minutes",
+			config: domain.PomodoroConfig{},
+			want: []domain.PomodoroAlert{
+				{At: 0, Message: "Session started. Stay focused!"},
+				{At: 12*time.Minute + 30*time.Second, Message: "Halfway there! Keep it up."},
+				{At: 25 * time.Minute, Message: "Time's up! Recording your session..."},
+			},
+		},
+		{
+			name:   "default alerts follow the focus length",
+			config: domain.PomodoroConfig{Focus: 50 * time.Minute},
+			want: []domain.PomodoroAlert{
+				{At: 0, Message: "Session started. Stay focused!"},
+				{At: 25 * time.Minute, Message: "Halfway there! Keep it up."},
+				{At: 50 * time.Minute, Message: "Time's up! Recording your session..."},
+			},
+		},
+		{
+			name: "resolves alerts before the end and sorts them",
+			config: domain.PomodoroConfig{
+				Focus: 50 * time.Minute,
+				Alerts: []domain.PomodoroAlert{
+					{At: 5 * time.Minute, FromEnd: true, Message: "5 minutes left"},
+					{At: 10 * time.Minute, Message: "10 minutes in"},
+				},
+			},
+			want: []domain.PomodoroAlert{
+				{At: 10 * time.Minute, Message: "10 minutes in"},
+				{At: 45 * time.Minute, Message: "5 minutes left"},
+			},
+		},
+		{
+			name:   "an empty alert list is silent",
+			config: domain.PomodoroConfig{Alerts: []domain.PomodoroAlert{}},
+			want:   []domain.PomodoroAlert{},
+		},
+		{
+			name: "rejects alerts beyond the focus",
+			config: domain.PomodoroConfig{
+				Focus:  10 * time.Minute,
+				Alerts: []domain.PomodoroAlert{{At: 15 * time.Minute, Message: "late"}},
+			},
+			wantErr: domain.ErrInvalidPomodoroConfig,
+		},
+		{
+			name:    "rejects alerts without a message",
+			config:  domain.PomodoroConfig{Alerts: []domain.PomodoroAlert{{At: time.Minute}}},
+			wantErr: domain.ErrInvalidPomodoroConfig,
+		},
+		{
+			name:    "rejects a negative focus",
+			config:  domain.PomodoroConfig{Focus: -time.Minute},
+			wantErr: domain.ErrInvalidPomodoroConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.config.Schedule()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPomodoroConfig_Override(t *testing.T) {
+	defaults := domain.PomodoroConfig{
+		Focus:  50 * time.Minute,
+		Alerts: []domain.PomodoroAlert{{At: 0, Message: "Go!"}},
+	}
+
+	assert.Equal(t, defaults, defaults.Override(domain.PomodoroConfig{}))
+	assert.Equal(t, domain.PomodoroConfig{Focus: 15 * time.Minute, Alerts: defaults.Alerts},
+		defaults.Override(domain.PomodoroConfig{Focus: 15 * time.Minute}))
+}
+
+func TestPomodoroConfig_JSON(t *testing.T) {
+	t.Run("round trips offsets from the start and before the end", func(t *testing.T) {
+		data := `{"focus":"50m","alerts":[{"at":"10m","message":"10 minutes in"},{"before":"5m","message":"5 minutes left"}]}`
+
+		var config domain.PomodoroConfig
+		require.NoError(t, json.Unmarshal([]byte(data), &config))
+		assert.Equal(t, domain.PomodoroConfig{
+			Focus: 50 * time.Minute,
+			Alerts: []domain.PomodoroAlert{
+				{At: 10 * time.Minute, Message: "10 minutes in"},
+				{At: 5 * time.Minute, FromEnd: true, Message: "5 minutes left"},
+			},
+		}, config)
+
+		encoded, err := json.Marshal(config)
+		require.NoError(t, err)
+		assert.JSONEq(t, data, string(encoded))
+	})
+	t.Run("round trips ticks and cycle breaks", func(t *testing.T) {
+		data := `{"focus":"50m","tick":"1m","short_break":"10m","long_break":"30m","rounds":3}`
+
+		var config domain.PomodoroConfig
+		require.NoError(t, json.Unmarshal([]byte(data), &config))
+		assert.Equal(t, domain.PomodoroConfig{
+			Focus:      50 * time.Minute,
+			Tick:       time.Minute,
+			ShortBreak: 10 * time.Minute,
+			LongBreak:  30 * time.Minute,
+			Rounds:     3,
+		}, config)
+
+		encoded, err := json.Marshal(config)
+		require.NoError(t, err)
+		assert.JSONEq(t, data, string(encoded))
+	})
+	t.Run("leaves unset fields to the defaults", func(t *testing.T) {
+		var config domain.PomodoroConfig
+		require.NoError(t, json.Unmarshal([]byte(`{}`), &config))
+		assert.Equal(t, domain.PomodoroConfig{}, config)
+	})
+	t.Run("rejects invalid durations", func(t *testing.T) {
+		for _, data := range []string{
+			`{"focus":"soon"}`,
+			`{"focus":"-5m"}`,
+			`{"alerts":[{"at":"later","message":"x"}]}`,
+			`{"alerts":[{"at":"1m","before":"1m","message":"x"}]}`,
+			`{"short_break":"0s"}`,
+			`{"tick":"-1s"}`,
+			`{"long_break":"an hour"}`,
+			`{"rounds":-2}`,
+		} {
+			var config domain.PomodoroConfig
+			assert.ErrorIs(t, json.Unmarshal([]byte(data), &config), domain.ErrInvalidPomodoroConfig, data)
+		}
+	})
+}
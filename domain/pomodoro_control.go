@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrPomodoroCancelled = errors.New("pomodoro cancelled")
+	ErrPomodoroState     = errors.New("invalid pomodoro state change")
+)
+
+// PomodoroState is the lifecycle state of a Pomodoro run.
+type PomodoroState string
+
+const (
+	PomodoroRunning   PomodoroState = "running"
+	PomodoroPaused    PomodoroState = "paused"
+	PomodoroCancelled PomodoroState = "cancelled"
+	PomodoroFinished  PomodoroState = "finished"
+)
+
+// PomodoroControl lets other goroutines pause, resume or cancel a running
+// Pomodoro. The runner watches Changed to react to state changes.
+type PomodoroControl struct {
+	mu      sync.Mutex
+	state   PomodoroState
+	changed chan struct{}
+}
+
+// NewPomodoroControl returns a control in the running state.
+func NewPomodoroControl() *PomodoroControl {
+	return &PomodoroControl{
+		state:   PomodoroRunning,
+		changed: make(chan struct{}),
+	}
+}
+
+// Pause stops the focus time from running until Resume is called.
+func (c *PomodoroControl) Pause() error {
+	return c.transition(PomodoroPaused, PomodoroRunning)
+}
+
+// Resume continues a paused Pomodoro.
+func (c *PomodoroControl) Resume() error {
+	return c.transition(PomodoroRunning, PomodoroPaused)
+}
+
+// Cancel stops the Pomodoro for good; only the focus time spent so far is kept.
+func (c *PomodoroControl) Cancel() error {
+	return c.transition(PomodoroCancelled, PomodoroRunning, PomodoroPaused)
+}
+
+// State returns the current state.
+func (c *PomodoroControl) State() PomodoroState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Changed returns a channel that is closed on the next state change.
+func (c *PomodoroControl) Changed() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.changed
+}
+
+// finish marks the run as over so that later commands are rejected.
+func (c *PomodoroControl) finish() {
+	c.transition(PomodoroFinished, PomodoroRunning, PomodoroPaused, PomodoroCancelled)
+}
+
+func (c *PomodoroControl) transition(to PomodoroState, from ...PomodoroState) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, state := range from {
+		if c.state == state {
+			c.state = to
+			close(c.changed)
+			c.changed = make(chan struct{})
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: cannot go from %s to %s", ErrPomodoroState, c.state, to)
+}
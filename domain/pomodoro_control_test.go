@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPomodoroControl(t *testing.T) {
+	t.Run("pauses, resumes and cancels", func(t *testing.T) {
+		c := NewPomodoroControl()
+		assert.Equal(t, PomodoroRunning, c.State())
+
+		assert.NoError(t, c.Pause())
+		assert.Equal(t, PomodoroPaused, c.State())
+
+		assert.NoError(t, c.Resume())
+		assert.Equal(t, PomodoroRunning, c.State())
+
+		assert.NoError(t, c.Cancel())
+		assert.Equal(t, PomodoroCancelled, c.State())
+	})
+
+	t.Run("signals every change", func(t *testing.T) {
+		c := NewPomodoroControl()
+		changed := c.Changed()
+
+		assert.NoError(t, c.Pause())
+
+		select {
+		case <-changed:
+		default:
+			t.Fatal("changed channel should be closed after pause")
+		}
+		assert.NotEqual(t, changed, c.Changed(), "a new channel should wait for the next change")
+	})
+
+	t.Run("rejects invalid changes", func(t *testing.T) {
+		c := NewPomodoroControl()
+
+		assert.ErrorIs(t, c.Resume(), ErrPomodoroState)
+		assert.NoError(t, c.Pause())
+		assert.ErrorIs(t, c.Pause(), ErrPomodoroState)
+
+		c.finish()
+		assert.ErrorIs(t, c.Cancel(), ErrPomodoroState)
+		assert.Equal(t, PomodoroFinished, c.State())
+	})
+}
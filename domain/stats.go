@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+)
+
+var ErrInvalidStats = errors.New("invalid stats request")
+
+const (
+	// DefaultStatsWeeks is how many weeks of active days stats show by default.
+	DefaultStatsWeeks = 4
+	// MaxStatsWeeks bounds the weeks of active days stats show.
+	MaxStatsWeeks = 104
+)
+
+// Stats describes how consistently someone studies.
+type Stats struct {
+	// CurrentStreak counts the consecutive days with study up to today. A
+	// streak that reached yesterday still counts until today is over.
+	CurrentStreak int `json:"current_streak_days"`
+	// LongestStreak is the most consecutive days with study ever.
+	LongestStreak int `json:"longest_streak_days"`
+	// ActiveDaysPerWeek averages the days with study over Weeks.
+	ActiveDaysPerWeek float64 `json:"active_days_per_week"`
+	// Weeks are the most recent calendar weeks, oldest first and ending with
+	// the current week.
+	Weeks []WeekStats `json:"weeks"`
+	// Subjects are ordered by total time, longest first.
+	Subjects []SubjectStats `json:"subjects"`
+}
+
+// WeekStats is how many days of a calendar week had any study.
+type WeekStats struct {
+	Start      time.Time `json:"week_start"` // Monday
+	ActiveDays int       `json:"active_days"`
+}
+
+// SubjectStats sums up the sessions of one subject.
+type SubjectStats struct {
+	Subject  string
+	Sessions int
+	Total    time.Duration
+}
+
+// Average returns the mean session length to the second.
+func (s SubjectStats) Average() time.Duration {
+	if s.Sessions == 0 {
+		return 0
+	}
+	return (s.Total / time.Duration(s.Sessions)).Round(time.Second)
+}
+
+type subjectStatsJSON struct {
+	Subject        string  `json:"subject"`
+	Sessions       int     `json:"sessions"`
+	TotalHours     float64 `json:"total_hours"`
+	Total          string  `json:"total"`
+	AverageMinutes float64 `json:"average_session_minutes"`
+	Average        string  `json:"average_session"`
+}
+
+// MarshalJSON encodes durations both as numbers and as Go duration strings.
+func (s SubjectStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(subjectStatsJSON{
+		Subject:        s.Subject,
+		Sessions:       s.Sessions,
+		TotalHours:     s.Total.Hours(),
+		Total:          s.Total.String(),
+		AverageMinutes: s.Average().Minutes(),
+		Average:        s.Average().String(),
+	})
+}
+
+// ComputeStats derives streaks, active days of the last weeks and session
+// lengths from sessions. A session counts for the day it started on in the
+// location of now.
+func ComputeStats(sessions []LoggedSession, now time.Time, weeks int) Stats {
+	loc := now.Location()
+	active := make(map[time.Time]bool)
+	bySubject := make(map[string]*SubjectStats)
+	for _, session := range sessions {
+		active[startOfDay(session.StartedAt.In(loc))] = true
+
+		s, ok := bySubject[session.Subject]
+		if !ok {
+			s = &SubjectStats{Subject: session.Subject}
+			bySubject[session.Subject] = s
+		}
+		s.Sessions++
+		s.Total += session.Duration
+	}
+
+	stats := Stats{
+		CurrentStreak: currentStreak(active, startOfDay(now)),
+		LongestStreak: longestStreak(active),
+		Weeks:         make([]WeekStats, 0, weeks),
+		Subjects:      make([]SubjectStats, 0, len(bySubject)),
+	}
+
+	thisWeek := ThisWeek(now).From
+	total := 0
+	for i := weeks - 1; i >= 0; i-- {
+		week := WeekStats{Start: thisWeek.AddDate(0, 0, -7*i)}
+		for day := range 7 {
+			if active[week.Start.AddDate(0, 0, day)] {
+				week.ActiveDays++
+			}
+		}
+		total += week.ActiveDays
+		stats.Weeks = append(stats.Weeks, week)
+	}
+	if weeks > 0 {
+		stats.ActiveDaysPerWeek = float64(total) / float64(weeks)
+	}
+
+	for _, s := range bySubject {
+		stats.Subjects = append(stats.Subjects, *s)
+	}
+	slices.SortFunc(stats.Subjects, func(a, b SubjectStats) int {
+		return cmp.Or(cmp.Compare(b.Total, a.Total), cmp.Compare(a.Subject, b.Subject))
+	})
+	return stats
+}
+
+// currentStreak counts the active days before today, and today if it is active.
+func currentStreak(active map[time.Time]bool, today time.Time) int {
+	day := today
+	if !active[day] {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for active[day] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+func longestStreak(active map[time.Time]bool) int {
+	longest := 0
+	for day := range active {
+		// Only count from the first day of each streak.
+		if active[day.AddDate(0, 0, -1)] {
+			continue
+		}
+		streak := 0
+		for d := day; active[d]; d = d.AddDate(0, 0, 1) {
+			streak++
+		}
+		longest = max(longest, streak)
+	}
+	return longest
+}
@@ -0,0 +1,88 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// statsNow is a Wednesday.
+var statsNow = time.Date(2026, 3, 18, 15, 0, 0, 0, time.UTC)
+
+func studiedOn(subject string, month time.Month, day int, duration time.Duration) domain.LoggedSession {
+	start := time.Date(2026, month, day, 9, 0, 0, 0, time.UTC)
+	return domain.LoggedSession{Subject: subject, StartedAt: start, EndedAt: start.Add(duration), Duration: duration}
+}
+
+func TestComputeStats(t *testing.T) {
+	sessions := []domain.LoggedSession{
+		studiedOn("go", time.March, 9, time.Hour),
+		studiedOn("go", time.March, 10, time.Hour),
+		studiedOn("go", time.March, 11, time.Hour),
+		studiedOn("sql", time.March, 16, 30*time.Minute),
+		studiedOn("go", time.March, 17, 2*time.Hour),
+	}
+
+	t.Run("counts streaks", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			sessions []domain.LoggedSession
+			current  int
+			longest  int
+		}{
+			{"nothing studied", nil, 0, 0},
+			{"streak reaching yesterday", sessions, 2, 3},
+			{"streak reaching today", append(sessions, studiedOn("go", time.March, 18, time.Hour)), 3, 3},
+			{"broken streak", sessions[:3], 0, 3},
+			{"several sessions a day", append(sessions, studiedOn("sql", time.March, 17, time.Hour)), 2, 3},
+		}
+		for _, tt := range tests {
+			stats := domain.ComputeStats(tt.sessions, statsNow, 1)
+			assert.Equal(t, tt.current, stats.CurrentStreak, "%s: current streak", tt.name)
+			assert.Equal(t, tt.longest, stats.LongestStreak, "%s: longest streak", tt.name)
+		}
+	})
+	t.Run("counts active days of the last weeks", func(t *testing.T) {
+		stats := domain.ComputeStats(sessions, statsNow, 3)
+		assert.Equal(t, []domain.WeekStats{
+			{Start: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), ActiveDays: 0},
+			{Start: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), ActiveDays: 3},
+			{Start: time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), ActiveDays: 2},
+		}, stats.Weeks)
+		assert.InDelta(t, 5.0/3, stats.ActiveDaysPerWeek, 1e-9)
+	})
+	t.Run("takes days in the location of now", func(t *testing.T) {
+		// 23:30 UTC on Tuesday is already Wednesday in Berlin.
+		berlin := time.FixedZone("CET", 60*60)
+		late := domain.LoggedSession{Subject: "go", StartedAt: time.Date(2026, 3, 17, 23, 30, 0, 0, time.UTC), Duration: time.Hour}
+		thursday := statsNow.Add(24 * time.Hour)
+
+		assert.Equal(t, 0, domain.ComputeStats([]domain.LoggedSession{late}, thursday, 1).CurrentStreak)
+		assert.Equal(t, 1, domain.ComputeStats([]domain.LoggedSession{late}, thursday.In(berlin), 1).CurrentStreak,
+			"the session should count for Wednesday in Berlin")
+	})
+	t.Run("averages session length per subject", func(t *testing.T) {
+		stats := domain.ComputeStats(sessions, statsNow, 1)
+		if assert.Len(t, stats.Subjects, 2) {
+			assert.Equal(t, domain.SubjectStats{Subject: "go", Sessions: 4, Total: 5 * time.Hour}, stats.Subjects[0])
+			assert.Equal(t, 75*time.Minute, stats.Subjects[0].Average())
+			assert.Equal(t, domain.SubjectStats{Subject: "sql", Sessions: 1, Total: 30 * time.Minute}, stats.Subjects[1])
+		}
+	})
+}
+
+func TestSubjectStatsJSON(t *testing.T) {
+	got, err := json.Marshal(domain.SubjectStats{Subject: "go", Sessions: 4, Total: 5 * time.Hour})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"subject": "go",
+		"sessions": 4,
+		"total_hours": 5,
+		"total": "5h0m0s",
+		"average_session_minutes": 75,
+		"average_session": "1h15m0s"
+	}`, string(got))
+}
@@ -1,12 +1,107 @@
 package domain
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
 
-var ErrSubjectNotFound = errors.New("subject not found")
+var (
+	ErrSubjectNotFound = errors.New("subject not found")
+	ErrInvalidDuration = errors.New("invalid duration")
+)
 
 type StudyActivity struct {
-	Subject string `json:"subject"`
-	Hours   int    `json:"hours"`
+	Subject  string
+	Duration time.Duration
+}
+
+type studyActivityJSON struct {
+	Subject  string  `json:"subject"`
+	Hours    float64 `json:"hours"`
+	Duration string  `json:"duration"`
+}
+
+// MarshalJSON encodes the duration both as fractional hours and as a Go duration string.
+func (sa StudyActivity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(studyActivityJSON{
+		Subject:  sa.Subject,
+		Hours:    sa.Duration.Hours(),
+		Duration: sa.Duration.String(),
+	})
+}
+
+// UnmarshalJSON decodes the duration string, falling back to fractional hours.
+func (sa *StudyActivity) UnmarshalJSON(data []byte) error {
+	var raw studyActivityJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	sa.Subject = raw.Subject
+	if raw.Duration == "" {
+		sa.Duration = time.Duration(raw.Hours * float64(time.Hour))
+		return nil
+	}
+	d, err := time.ParseDuration(raw.Duration)
+	if err != nil {
+		return fmt.Errorf("%w %q: %v", ErrInvalidDuration, raw.Duration, err)
+	}
+	sa.Duration = d
+	return nil
 }
 
 type Report []StudyActivity
+
+// MaxDuration bounds parsed durations, far below where time.Duration overflows.
+const MaxDuration = 10000 * time.Hour
+
+// ParseDuration parses a study duration between a second and MaxDuration. A
+// plain number is read as hours ("2", "1.5"), anything else as a Go duration
+// ("1h30m", "45m").
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var d time.Duration
+	if hours, err := strconv.ParseFloat(s, 64); err == nil {
+		// Check before converting: NaN, infinities and huge numbers do not fit a time.Duration.
+		if math.IsNaN(hours) || hours > MaxDuration.Hours() {
+			return 0, fmt.Errorf("%w %q: should be at most %s", ErrInvalidDuration, s, FormatDuration(MaxDuration))
+		}
+		d = time.Duration(hours * float64(time.Hour))
+	} else {
+		d, err = time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("%w %q: %v", ErrInvalidDuration, s, err)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%w %q: should be positive", ErrInvalidDuration, s)
+	}
+	if d < time.Second {
+		return 0, fmt.Errorf("%w %q: should be at least a second", ErrInvalidDuration, s)
+	}
+	if d > MaxDuration {
+		return 0, fmt.Errorf("%w %q: should be at most %s", ErrInvalidDuration, s, FormatDuration(MaxDuration))
+	}
+	return d, nil
+}
+
+// FormatDuration renders a duration without trailing zero units, e.g. "1h30m" or "45m".
+func FormatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// FormatHours renders a duration as a number of hours, e.g. "2" or "1.5".
+func FormatHours(d time.Duration) string {
+	return strconv.FormatFloat(d.Hours(), 'f', -1, 64)
+}
@@ -0,0 +1,68 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "2", want: 2 * time.Hour},
+		{input: "1.5", want: 90 * time.Minute},
+		{input: "1h30m", want: 90 * time.Minute},
+		{input: "45m", want: 45 * time.Minute},
+		{input: "five", wantErr: true},
+		{input: "-2", wantErr: true},
+		{input: "0m", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "NaN", wantErr: true},
+		{input: "Inf", wantErr: true},
+		{input: "-Inf", wantErr: true},
+		{input: "1e10", wantErr: true},
+		{input: "10001", wantErr: true},
+		{input: "10000", want: domain.MaxDuration},
+		{input: "2562047h", wantErr: true},
+		{input: "0.0001", wantErr: true},
+		{input: "500ms", wantErr: true},
+		{input: "1s", want: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := domain.ParseDuration(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidDuration)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "3h", domain.FormatDuration(3*time.Hour))
+	assert.Equal(t, "1h30m", domain.FormatDuration(90*time.Minute))
+	assert.Equal(t, "45m", domain.FormatDuration(45*time.Minute))
+	assert.Equal(t, "1m30s", domain.FormatDuration(90*time.Second))
+}
+
+func TestStudyActivity_JSON(t *testing.T) {
+	activity := domain.StudyActivity{Subject: "math", Duration: 90 * time.Minute}
+
+	data, err := json.Marshal(activity)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"subject":"math","hours":1.5,"duration":"1h30m0s"}`, string(data))
+
+	var got domain.StudyActivity
+	assert.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, activity, got)
+}
@@ -1,40 +1,629 @@
 package domain
 
-import "io"
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
 
-// SessionRunner defines the interface for managing study sessions.
+// SessionRecorder records study time entered by hand or spent in Pomodoros.
+type SessionRecorder interface {
+	RecordManual(ctx context.Context, subject string, duration time.Duration) (LoggedSession, error)
+	RecordPomodoro(ctx context.Context, subject string, config PomodoroConfig, out io.Writer, control *PomodoroControl) (LoggedSession, error)
+	RecordPomodoroCycle(ctx context.Context, subject string, config PomodoroConfig, out io.Writer, control *PomodoroControl) error
+}
+
+// ActivePomodoroRecorder records Pomodoros that are kept in the store while
+// they run, so that they can be resumed after a restart.
+type ActivePomodoroRecorder interface {
+	RecordActivePomodoro(ctx context.Context, id, subject string, config PomodoroConfig, out io.Writer, control *PomodoroControl) error
+	RecordActivePomodoroCycle(ctx context.Context, id, subject string, config PomodoroConfig, out io.Writer, control *PomodoroControl) error
+	ResumeActivePomodoro(ctx context.Context, active ActivePomodoro, out io.Writer, control *PomodoroControl) error
+}
+
+// HistoryEditor corrects sessions and subjects already recorded.
+type HistoryEditor interface {
+	EditSession(ctx context.Context, id int64, change SessionChange) (LoggedSession, error)
+	DeleteSession(ctx context.Context, id int64) (LoggedSession, error)
+	UndoLastSession(ctx context.Context) (LoggedSession, error)
+	RenameSubject(ctx context.Context, from, to string) (int, error)
+	MergeSubjects(ctx context.Context, into string, from []string) (int, error)
+}
+
+// GoalTracker sets study goals and reports the progress towards them.
+type GoalTracker interface {
+	SetGoal(ctx context.Context, goal Goal) error
+	DeleteGoal(ctx context.Context, subject string, period GoalPeriod) error
+	Goals(ctx context.Context) ([]GoalProgress, error)
+}
+
+// StatsReporter sums up the time studied. Now is the time that named
+// periods, like this week, are relative to.
+type StatsReporter interface {
+	Now() time.Time
+	Report(ctx context.Context, period TimeRange) (Report, error)
+	Hours(ctx context.Context, subject string) (time.Duration, error)
+	Stats(ctx context.Context, weeks int) (Stats, error)
+}
+
+// SessionPorter exports and imports recorded sessions.
+type SessionPorter interface {
+	Export(ctx context.Context, w io.Writer, format ExportFormat, period TimeRange) (int, error)
+	Import(ctx context.Context, r io.Reader, options ImportOptions) (ImportSummary, error)
+}
+
+// SessionRunner is everything a study session does, as StudySession
+// implements it. Adapters take the roles they use.
 type SessionRunner interface {
-	RecordManual(subject string, hours int) error
-	RecordPomodoro(subject string, out io.Writer) error
+	SessionRecorder
+	ActivePomodoroRecorder
+	HistoryEditor
+	GoalTracker
+	StatsReporter
+	SessionPorter
 }
 
 // PomodoroRunner represents a timer that can be started for focused study sessions.
+// Start blocks until the session is over and returns how long the focus lasted.
+// Fields set in config override the runner's own focus length and alerts.
+// It honours pauses requested through control, and returns the focus time spent
+// so far together with ErrPomodoroCancelled or the context error when the
+// session is cut short.
+//
+// StartCycle runs a full cycle of focus blocks separated by short breaks and
+// ended by a long break, from the round config.StartRound returns. It calls
+// the hooks as each focus block starts and ends, and stops with the error a
+// hook returns.
+//
+// Config returns the runner's own settings, which a start config overrides.
 type PomodoroRunner interface {
-	Start(out io.Writer)
+	Config() PomodoroConfig
+	Start(ctx context.Context, config PomodoroConfig, out io.Writer, control *PomodoroControl) (time.Duration, error)
+	StartCycle(ctx context.Context, config PomodoroConfig, out io.Writer, control *PomodoroControl, hooks CycleHooks) error
+}
+
+// CycleHooks are called by PomodoroRunner.StartCycle as a cycle goes on. Nil
+// hooks are skipped.
+type CycleHooks struct {
+	// OnRound is called as the focus of a round starts.
+	OnRound func(round int) error
+	// OnFocus is called as each focus block ends, including one cut short.
+	OnFocus func(FocusBlock) error
+}
+
+// FocusBlock is one focus interval of a Pomodoro cycle.
+type FocusBlock struct {
+	Round     int
+	StartedAt time.Time
+	EndedAt   time.Time
+	Focused   time.Duration
 }
 
+// recordTimeout bounds logging a Pomodoro whose context was already cancelled.
+const recordTimeout = 5 * time.Second
+
 // StudySession encapsulates the business logic for recording study hours.
 type StudySession struct {
 	store          SubjectStore
 	pomodoroRunner PomodoroRunner
+	clock          Clock
 }
 
-// NewStudySession creates a new study session manager.
-func NewStudySession(store SubjectStore, pomodoroRunner PomodoroRunner) *StudySession {
+// NewStudySession creates a new study session manager. Sessions are
+// timestamped with clock.
+func NewStudySession(store SubjectStore, pomodoroRunner PomodoroRunner, clock Clock) *StudySession {
 	return &StudySession{
 		store:          store,
 		pomodoroRunner: pomodoroRunner,
+		clock:          clock,
+	}
+}
+
+// RecordManual records a manually entered study duration as a session that
+// ends now and returns the session logged.
+func (s *StudySession) RecordManual(ctx context.Context, subject string, duration time.Duration) (LoggedSession, error) {
+	now := s.clock.Now()
+	return s.store.LogSession(ctx, LoggedSession{
+		Subject:   subject,
+		StartedAt: now.Add(-duration),
+		EndedAt:   now,
+		Duration:  duration,
+		Source:    SourceManual,
+	})
+}
+
+// RecordPomodoro runs a Pomodoro session configured by config that can be
+// steered through control, which may be nil, and logs the focus time it
+// actually lasted, returning the session logged. When the session is cancelled
+// or ctx is done, the focus time spent so far is still logged and returned
+// with the interruption error. Less than a second of focus is not logged, and
+// the session returned is then zero.
+func (s *StudySession) RecordPomodoro(ctx context.Context, subject string, config PomodoroConfig, out io.Writer, control *PomodoroControl) (LoggedSession, error) {
+	if control == nil {
+		control = NewPomodoroControl()
+	}
+	defer control.finish()
+
+	startedAt := s.clock.Now()
+	focused, err := s.pomodoroRunner.Start(ctx, config, out, control)
+	logged, logErr := s.logPomodoro(ctx, subject, FocusBlock{StartedAt: startedAt, EndedAt: s.clock.Now(), Focused: focused})
+	if logErr != nil {
+		return LoggedSession{}, logErr
+	}
+	return logged, err
+}
+
+// RecordPomodoroCycle runs a cycle of Pomodoros with breaks and logs each
+// focus block as its own Pomodoro session; break time is not recorded. As with
+// RecordPomodoro, the focus time of an interrupted block is still logged.
+func (s *StudySession) RecordPomodoroCycle(ctx context.Context, subject string, config PomodoroConfig, out io.Writer, control *PomodoroControl) error {
+	if control == nil {
+		control = NewPomodoroControl()
+	}
+	defer control.finish()
+
+	return s.pomodoroRunner.StartCycle(ctx, config, out, control, CycleHooks{
+		OnFocus: func(block FocusBlock) error {
+			_, err := s.logPomodoro(ctx, subject, block)
+			return err
+		},
+	})
+}
+
+// RecordActivePomodoro runs a Pomodoro like RecordPomodoro, but keeps it in
+// the store as an ActivePomodoro under id while it runs, pauses included. When
+// ctx is done the Pomodoro is left in the store instead of being logged, so
+// that ResumeActivePomodoro can carry it on once the process is back.
+func (s *StudySession) RecordActivePomodoro(ctx context.Context, id, subject string, config PomodoroConfig, out io.Writer, control *PomodoroControl) error {
+	now := s.clock.Now()
+	focus := s.pomodoroRunner.Config().Override(config).FocusDuration()
+	active := ActivePomodoro{ID: id, Subject: subject, StartedAt: now, EndsAt: now.Add(focus), Focus: focus}
+	if err := s.store.SaveActivePomodoro(ctx, active); err != nil {
+		return fmt.Errorf("failed to save active pomodoro %s: %w", id, err)
+	}
+	return s.runActivePomodoro(ctx, active, config, out, control)
+}
+
+// RecordActivePomodoroCycle runs a cycle like RecordPomodoroCycle, but keeps
+// it in the store as an ActivePomodoro under id with the round it is in, so
+// that ResumeActivePomodoro can carry it on. As with RecordActivePomodoro,
+// the focus block under way when ctx is done is left in the store.
+func (s *StudySession) RecordActivePomodoroCycle(ctx context.Context, id, subject string, config PomodoroConfig, out io.Writer, control *PomodoroControl) error {
+	full := s.pomodoroRunner.Config().Override(config)
+	active := ActivePomodoro{ID: id, Subject: subject, Focus: full.FocusDuration(), Round: 1, Rounds: full.CycleRounds()}
+	return s.runActiveCycle(ctx, active, config, out, control)
+}
+
+// ResumeActivePomodoro carries on a Pomodoro saved by RecordActivePomodoro
+// for the focus time it has left, paused if it was saved paused. One whose
+// planned end has already passed is logged in full straight away. The logged
+// session spans the whole Pomodoro, including the time before the resume.
+//
+// A cycle saved by RecordActivePomodoroCycle carries on the same way from
+// the round it was in, with the breaks of the runner's own config. One saved
+// on a break carries on with the focus of the next round.
+func (s *StudySession) ResumeActivePomodoro(ctx context.Context, active ActivePomodoro, out io.Writer, control *PomodoroControl) error {
+	if active.InCycle() {
+		return s.resumeActiveCycle(ctx, active, out, control)
 	}
+	remaining := active.Remaining(s.clock.Now())
+	if remaining <= 0 {
+		return s.finishActivePomodoro(ctx, active, FocusBlock{StartedAt: active.StartedAt, EndedAt: active.EndsAt, Focused: active.Focus})
+	}
+
+	if control == nil {
+		control = NewPomodoroControl()
+	}
+	if active.Paused() {
+		control.Pause()
+	}
+	config := PomodoroConfig{Focus: remaining, Alerts: ResumedAlerts(remaining)}
+	return s.runActivePomodoro(ctx, active, config, out, control)
 }
 
-// RecordManual records manual study hours.
-func (s *StudySession) RecordManual(subject string, hours int) error {
-	return s.store.RecordHour(subject, hours)
+// resumeActiveCycle carries on a cycle from the round active is in.
+func (s *StudySession) resumeActiveCycle(ctx context.Context, active ActivePomodoro, out io.Writer, control *PomodoroControl) error {
+	config := PomodoroConfig{Focus: active.Focus, Rounds: active.Rounds, FirstRound: active.Round}
+	if !active.OnBreak() {
+		if remaining := active.Remaining(s.clock.Now()); remaining > 0 {
+			config.FirstFocus = remaining
+		} else {
+			if _, err := s.logPomodoro(ctx, active.Subject, FocusBlock{StartedAt: active.StartedAt, EndedAt: active.EndsAt, Focused: active.Focus}); err != nil {
+				return err
+			}
+			active = active.nextRound()
+			config.FirstRound = active.Round
+		}
+	}
+	if active.Round > active.Rounds {
+		return s.deleteActivePomodoro(ctx, active.ID)
+	}
+
+	if control == nil {
+		control = NewPomodoroControl()
+	}
+	if active.Paused() {
+		control.Pause()
+	}
+	return s.runActiveCycle(ctx, active, config, out, control)
+}
+
+// nextRound returns the cycle on the break before its next round.
+func (a ActivePomodoro) nextRound() ActivePomodoro {
+	a.Round++
+	a.StartedAt, a.EndsAt, a.PausedAt = time.Time{}, time.Time{}, time.Time{}
+	return a
 }
 
-// RecordPomodoro starts a 25-minute Pomodoro session and records it as 1 study hour.
-// Note: This is a simplified tracking where 1 Pomodoro = 1 recorded hour for convenience.
-func (s *StudySession) RecordPomodoro(subject string, out io.Writer) error {
-	s.pomodoroRunner.Start(out)
-	return s.store.RecordHour(subject, 1)
+// runActivePomodoro runs the focus time active has left with config and
+// keeps the stored Pomodoro up to date until it is over.
+func (s *StudySession) runActivePomodoro(ctx context.Context, active ActivePomodoro, config PomodoroConfig, out io.Writer, control *PomodoroControl) error {
+	if control == nil {
+		control = NewPomodoroControl()
+	}
+	defer control.finish()
+
+	stop := s.trackPauses(ctx, &trackedPomodoro{active: active}, control)
+	spent := active.Focus - active.Remaining(s.clock.Now())
+	focused, err := s.pomodoroRunner.Start(ctx, config, out, control)
+	stop()
+
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return err
+	}
+	block := FocusBlock{StartedAt: active.StartedAt, EndedAt: s.clock.Now(), Focused: spent + focused}
+	if finishErr := s.finishActivePomodoro(ctx, active, block); finishErr != nil {
+		return finishErr
+	}
+	return err
+}
+
+// runActiveCycle runs the cycle active is in with config, saving each round
+// as it starts and the break after it once its focus is logged, and removes
+// the cycle from the store once it is over.
+func (s *StudySession) runActiveCycle(ctx context.Context, active ActivePomodoro, config PomodoroConfig, out io.Writer, control *PomodoroControl) error {
+	if control == nil {
+		control = NewPomodoroControl()
+	}
+	defer control.finish()
+
+	tracked := &trackedPomodoro{active: active}
+	if err := tracked.save(ctx, s.store, func(*ActivePomodoro) bool { return true }); err != nil {
+		return err
+	}
+	stop := s.trackPauses(ctx, tracked, control)
+	err := s.pomodoroRunner.StartCycle(ctx, config, out, control, CycleHooks{
+		OnRound: func(round int) error {
+			if round == config.StartRound() && config.FirstFocus > 0 {
+				return nil
+			}
+			now := s.clock.Now()
+			return tracked.save(ctx, s.store, func(a *ActivePomodoro) bool {
+				a.Round, a.StartedAt, a.EndsAt, a.PausedAt = round, now, now.Add(a.Focus), time.Time{}
+				return true
+			})
+		},
+		OnFocus: func(block FocusBlock) error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if block.Round == config.StartRound() && config.FirstFocus > 0 {
+				block.StartedAt = active.StartedAt
+				block.Focused += active.Focus - config.FirstFocus
+			}
+			if _, err := s.logPomodoro(ctx, active.Subject, block); err != nil {
+				return err
+			}
+			return tracked.save(ctx, s.store, func(a *ActivePomodoro) bool {
+				*a = a.nextRound()
+				return true
+			})
+		},
+	})
+	stop()
+
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return err
+	}
+	if deleteErr := s.deleteActivePomodoro(ctx, active.ID); deleteErr != nil {
+		return deleteErr
+	}
+	return err
+}
+
+// trackedPomodoro is the stored state of a running ActivePomodoro, changed
+// both by its run and by trackPauses.
+type trackedPomodoro struct {
+	mu     sync.Mutex
+	active ActivePomodoro
+}
+
+// save applies change and saves the result, unless change reports that it
+// changed nothing.
+func (p *trackedPomodoro) save(ctx context.Context, store ActivePomodoroStore, change func(*ActivePomodoro) bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !change(&p.active) {
+		return nil
+	}
+	if err := store.SaveActivePomodoro(ctx, p.active); err != nil {
+		return fmt.Errorf("failed to save active pomodoro %s: %w", p.active.ID, err)
+	}
+	return nil
+}
+
+// trackPauses saves tracked on every pause and resume until the returned
+// stop is called. A failed save only makes a later resume less accurate, so
+// it does not stop the Pomodoro. Pauses on a break are not saved.
+func (s *StudySession) trackPauses(ctx context.Context, tracked *trackedPomodoro, control *PomodoroControl) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			changed := control.Changed()
+			now := s.clock.Now()
+			state := control.State()
+			tracked.save(ctx, s.store, func(active *ActivePomodoro) bool {
+				switch {
+				case active.OnBreak():
+					return false
+				case state == PomodoroPaused && !active.Paused():
+					active.PausedAt = now
+				case state == PomodoroRunning && active.Paused():
+					active.EndsAt = active.EndsAt.Add(now.Sub(active.PausedAt))
+					active.PausedAt = time.Time{}
+				default:
+					return false
+				}
+				return true
+			})
+
+			select {
+			case <-changed:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// finishActivePomodoro logs the focus time of a Pomodoro that is over and
+// removes it from the store, even once ctx is done.
+func (s *StudySession) finishActivePomodoro(ctx context.Context, active ActivePomodoro, block FocusBlock) error {
+	if _, err := s.logPomodoro(ctx, active.Subject, block); err != nil {
+		return err
+	}
+	return s.deleteActivePomodoro(ctx, active.ID)
+}
+
+// deleteActivePomodoro removes the Pomodoro id from the store, even once ctx
+// is done.
+func (s *StudySession) deleteActivePomodoro(ctx context.Context, id string) error {
+	deleteCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+	if err := s.store.DeleteActivePomodoro(deleteCtx, id); err != nil {
+		return fmt.Errorf("failed to delete active pomodoro %s: %w", id, err)
+	}
+	return nil
+}
+
+// logPomodoro logs the whole seconds of a focus block, even once ctx is done,
+// and returns the session logged, which is zero when there was none.
+func (s *StudySession) logPomodoro(ctx context.Context, subject string, block FocusBlock) (LoggedSession, error) {
+	focused := block.Focused.Truncate(time.Second)
+	if focused <= 0 {
+		return LoggedSession{}, nil
+	}
+
+	logCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+	return s.store.LogSession(logCtx, LoggedSession{
+		Subject:   subject,
+		StartedAt: block.StartedAt,
+		EndedAt:   block.EndedAt,
+		Duration:  focused,
+		Source:    SourcePomodoro,
+	})
+}
+
+// EditSession corrects the logged session id and returns it as corrected.
+func (s *StudySession) EditSession(ctx context.Context, id int64, change SessionChange) (LoggedSession, error) {
+	if change.Duration < 0 {
+		return LoggedSession{}, fmt.Errorf("%w %s: should be positive", ErrInvalidDuration, change.Duration)
+	}
+	session, err := s.store.GetSession(ctx, id)
+	if err != nil {
+		return LoggedSession{}, err
+	}
+	session = change.Apply(session)
+	if err := s.store.UpdateSession(ctx, session); err != nil {
+		return LoggedSession{}, err
+	}
+	return session, nil
+}
+
+// DeleteSession removes the logged session id and returns what it was.
+func (s *StudySession) DeleteSession(ctx context.Context, id int64) (LoggedSession, error) {
+	session, err := s.store.GetSession(ctx, id)
+	if err != nil {
+		return LoggedSession{}, err
+	}
+	if err := s.store.DeleteSession(ctx, id); err != nil {
+		return LoggedSession{}, err
+	}
+	return session, nil
+}
+
+// UndoLastSession removes the session logged most recently, whichever way it
+// was recorded, and returns what it was.
+func (s *StudySession) UndoLastSession(ctx context.Context) (LoggedSession, error) {
+	return s.store.DeleteLastSession(ctx)
+}
+
+// RenameSubject gives the sessions of subject from the name to and returns
+// how many there were. To join a subject that already exists, use MergeSubjects.
+func (s *StudySession) RenameSubject(ctx context.Context, from, to string) (int, error) {
+	to = strings.TrimSpace(to)
+	if from == "" || to == "" {
+		return 0, fmt.Errorf("%w: rename needs a subject and its new name", ErrInvalidSubject)
+	}
+	if from == to {
+		return 0, fmt.Errorf("%w: %q is already called that", ErrInvalidSubject, from)
+	}
+	return s.store.RenameSubject(ctx, from, to)
+}
+
+// MergeSubjects moves the sessions of the subjects from into subject into,
+// e.g. "TDD" and "test-driven" into "tdd", and returns how many moved.
+func (s *StudySession) MergeSubjects(ctx context.Context, into string, from []string) (int, error) {
+	into = strings.TrimSpace(into)
+	if into == "" || len(from) == 0 {
+		return 0, fmt.Errorf("%w: merge needs a subject and the subjects to merge into it", ErrInvalidSubject)
+	}
+	sources := make([]string, 0, len(from))
+	for _, subject := range from {
+		if subject == into {
+			return 0, fmt.Errorf("%w: cannot merge %q into itself", ErrInvalidSubject, into)
+		}
+		if !slices.Contains(sources, subject) {
+			sources = append(sources, subject)
+		}
+	}
+	return s.store.MergeSubjects(ctx, into, sources)
+}
+
+// SetGoal sets how long to study a subject per period, replacing the
+// earlier goal of that subject and period.
+func (s *StudySession) SetGoal(ctx context.Context, goal Goal) error {
+	goal.Subject = strings.TrimSpace(goal.Subject)
+	if err := goal.validate(); err != nil {
+		return err
+	}
+	return s.store.SaveGoal(ctx, goal)
+}
+
+// DeleteGoal removes the goal of a subject and period.
+func (s *StudySession) DeleteGoal(ctx context.Context, subject string, period GoalPeriod) error {
+	return s.store.DeleteGoal(ctx, subject, period)
+}
+
+// Goals returns every goal with the time studied for it in the current day,
+// week or month.
+func (s *StudySession) Goals(ctx context.Context) ([]GoalProgress, error) {
+	goals, err := s.store.GetGoals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	reports := make(map[GoalPeriod]Report)
+	progress := make([]GoalProgress, 0, len(goals))
+	for _, goal := range goals {
+		report, ok := reports[goal.Period]
+		if !ok {
+			report, err = s.store.GetReport(ctx, goal.Period.Range(now))
+			if err != nil {
+				return nil, err
+			}
+			reports[goal.Period] = report
+		}
+
+		p := GoalProgress{Goal: goal}
+		if i := slices.IndexFunc(report, func(a StudyActivity) bool { return a.Subject == goal.Subject }); i >= 0 {
+			p.Done = report[i].Duration
+		}
+		progress = append(progress, p)
+	}
+	return progress, nil
+}
+
+// Report returns the time studied per subject within the given period.
+func (s *StudySession) Report(ctx context.Context, period TimeRange) (Report, error) {
+	return s.store.GetReport(ctx, period)
+}
+
+// Hours returns the total time studied on subject.
+// Now returns the time of the session's clock.
+func (s *StudySession) Now() time.Time {
+	return s.clock.Now()
+}
+
+func (s *StudySession) Hours(ctx context.Context, subject string) (time.Duration, error) {
+	return s.store.GetHours(ctx, subject)
+}
+
+// Stats returns the streaks of the user, the active days of the last weeks
+// calendar weeks and the session lengths per subject.
+func (s *StudySession) Stats(ctx context.Context, weeks int) (Stats, error) {
+	if weeks < 1 || weeks > MaxStatsWeeks {
+		return Stats{}, fmt.Errorf("%w: weeks should be between 1 and %d, got %d", ErrInvalidStats, MaxStatsWeeks, weeks)
+	}
+	sessions, err := s.store.GetAllSessions(ctx, AllTime())
+	if err != nil {
+		return Stats{}, err
+	}
+	return ComputeStats(sessions, s.clock.Now(), weeks), nil
+}
+
+// Export writes the sessions started within period to w in format, one at a
+// time, and returns how many it wrote.
+func (s *StudySession) Export(ctx context.Context, w io.Writer, format ExportFormat, period TimeRange) (int, error) {
+	exporter, err := NewSessionExporter(w, format)
+	if err != nil {
+		return 0, err
+	}
+	exported := 0
+	err = s.store.WalkSessions(ctx, period, func(session LoggedSession) error {
+		if err := exporter.Export(session); err != nil {
+			return fmt.Errorf("failed to export session %d: %w", session.ID, err)
+		}
+		exported++
+		return nil
+	})
+	if err != nil {
+		return exported, err
+	}
+	return exported, exporter.Flush()
+}
+
+// Import reads sessions from r and logs those that are not logged yet in one
+// go, or only sums them up with options.DryRun. A session is logged already
+// when one of the same subject, regardless of case, started in the same second
+// and lasted as long.
+func (s *StudySession) Import(ctx context.Context, r io.Reader, options ImportOptions) (ImportSummary, error) {
+	sessions, err := ParseImport(r, options)
+	if err != nil {
+		return ImportSummary{}, err
+	}
+	summary := ImportSummary{Format: cmp.Or(options.Format, ImportCSV), Read: len(sessions), DryRun: options.DryRun}
+	if len(sessions) == 0 {
+		summary.add(nil)
+		return summary, nil
+	}
+
+	if options.DryRun {
+		logged, err := s.store.GetAllSessions(ctx, DuplicatesWithin(sessions))
+		if err != nil {
+			return ImportSummary{}, err
+		}
+		summary.add(NewSessions(sessions, logged))
+		return summary, nil
+	}
+	fresh, err := s.store.LogNewSessions(ctx, sessions)
+	if err != nil {
+		return ImportSummary{}, fmt.Errorf("failed to import sessions: %w", err)
+	}
+	summary.add(fresh)
+	return summary, nil
 }
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionSource describes how a study session was recorded.
+type SessionSource string
+
+const (
+	SourceManual   SessionSource = "manual"
+	SourcePomodoro SessionSource = "pomodoro"
+	SourceImport   SessionSource = "import"
+)
+
+// LoggedSession is a single timestamped entry of the study session log.
+type LoggedSession struct {
+	ID        int64         `json:"id"`
+	UserID    UserID        `json:"user_id,omitempty"` // set by the store from the context
+	Subject   string        `json:"subject"`
+	StartedAt time.Time     `json:"started_at"`
+	EndedAt   time.Time     `json:"ended_at"`
+	Duration  time.Duration `json:"duration"`
+	Source    SessionSource `json:"source"`
+}
+
+// StudySessionLog stores every study session individually so that totals
+// and any other analysis can be derived from it. Sessions belong to the user
+// of the context they are logged with and are only returned to that user.
+type StudySessionLog interface {
+	// LogSession logs session and returns it with the ID and user it was
+	// logged with.
+	LogSession(ctx context.Context, session LoggedSession) (LoggedSession, error)
+	// LogSessions logs all of sessions or, when one of them fails, none.
+	LogSessions(ctx context.Context, sessions []LoggedSession) error
+	// LogNewSessions logs the sessions NewSessions keeps of them, checking
+	// against the sessions of the user of ctx and logging in one step, so
+	// that concurrent calls do not log a session twice. It returns the
+	// sessions it logged, all of them or, when one fails, none.
+	LogNewSessions(ctx context.Context, sessions []LoggedSession) ([]LoggedSession, error)
+	GetSessions(ctx context.Context, subject string) ([]LoggedSession, error)
+	// GetAllSessions returns the sessions of every subject of the user of ctx
+	// started within period, ordered by start time.
+	GetAllSessions(ctx context.Context, period TimeRange) ([]LoggedSession, error)
+	// WalkSessions calls fn with each session of the user of ctx started within
+	// period, in the order of GetAllSessions, and stops at the first error fn
+	// returns. Unlike GetAllSessions it does not need to hold every session.
+	WalkSessions(ctx context.Context, period TimeRange, fn func(LoggedSession) error) error
+	// GetSession returns the session id of the user of ctx, or ErrSessionNotFound.
+	GetSession(ctx context.Context, id int64) (LoggedSession, error)
+	// GetLastSession returns the session the user of ctx logged most recently,
+	// or ErrSessionNotFound when there is none.
+	GetLastSession(ctx context.Context) (LoggedSession, error)
+	// UpdateSession replaces the subject, times and duration of the session of
+	// the user of ctx with the ID of session, or returns ErrSessionNotFound.
+	UpdateSession(ctx context.Context, session LoggedSession) error
+	// DeleteSession removes the session id of the user of ctx, or returns
+	// ErrSessionNotFound.
+	DeleteSession(ctx context.Context, id int64) error
+	// DeleteLastSession removes the session the user of ctx logged most
+	// recently in a single step and returns it, or returns ErrSessionNotFound
+	// when there is none.
+	DeleteLastSession(ctx context.Context) (LoggedSession, error)
+}
+
+// SessionChange corrects a logged session. Empty fields keep their value.
+type SessionChange struct {
+	Subject  string
+	Duration time.Duration
+}
+
+// IsZero reports whether the change leaves a session as it is.
+func (c SessionChange) IsZero() bool {
+	return strings.TrimSpace(c.Subject) == "" && c.Duration == 0
+}
+
+// Apply returns session with the change made. A new duration keeps the end
+// of the session and moves its start, as if it had been recorded that way.
+func (c SessionChange) Apply(session LoggedSession) LoggedSession {
+	if subject := strings.TrimSpace(c.Subject); subject != "" {
+		session.Subject = subject
+	}
+	if c.Duration > 0 {
+		session.Duration = c.Duration
+		session.StartedAt = session.EndedAt.Add(-c.Duration)
+	}
+	return session
+}
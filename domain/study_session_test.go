@@ -2,79 +2,642 @@ package domain_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"testing"
+	"time"
 
+	"github.com/bryack/study_hours_tracker/adapters/database"
 	"github.com/bryack/study_hours_tracker/domain"
 	"github.com/bryack/study_hours_tracker/testhelpers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type SpyPomodoroRunner struct {
 	StartCallCount int
+	Configs        []domain.PomodoroConfig
+	// Focused and Err, when set, replace the full 25 minute run.
+	Focused time.Duration
+	Err     error
+	// Blocks are the focus blocks a cycle reports.
+	Blocks []domain.FocusBlock
+	// During, when set, is called while Start runs.
+	During func(control *domain.PomodoroControl)
 }
 
-func (s *SpyPomodoroRunner) Start(out io.Writer) {
+func (s *SpyPomodoroRunner) Config() domain.PomodoroConfig {
+	return domain.PomodoroConfig{}
+}
+
+func (s *SpyPomodoroRunner) Start(ctx context.Context, config domain.PomodoroConfig, out io.Writer, control *domain.PomodoroControl) (time.Duration, error) {
+	s.StartCallCount++
+	s.Configs = append(s.Configs, config)
+	if s.During != nil {
+		s.During(control)
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if s.Err != nil || s.Focused != 0 {
+		return s.Focused, s.Err
+	}
+	return 25 * time.Minute, nil
+}
+
+func (s *SpyPomodoroRunner) StartCycle(ctx context.Context, config domain.PomodoroConfig, out io.Writer, control *domain.PomodoroControl, hooks domain.CycleHooks) error {
 	s.StartCallCount++
+	s.Configs = append(s.Configs, config)
+	for _, block := range s.Blocks {
+		if hooks.OnRound != nil {
+			if err := hooks.OnRound(block.Round); err != nil {
+				return err
+			}
+		}
+		if s.During != nil {
+			s.During(control)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := hooks.OnFocus(block); err != nil {
+			return err
+		}
+	}
+	return s.Err
+}
+
+var sessionStart = time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)
+
+// hoursOf returns the time studied on subject in store, or zero for an unknown subject.
+func hoursOf(t *testing.T, store domain.SubjectStore, subject string) time.Duration {
+	t.Helper()
+	hours, err := store.GetHours(t.Context(), subject)
+	if errors.Is(err, domain.ErrSubjectNotFound) {
+		return 0
+	}
+	assert.NoError(t, err)
+	return hours
+}
+
+// loggedSessions returns every session of the default user in store.
+func loggedSessions(t *testing.T, store domain.StudySessionLog) []domain.LoggedSession {
+	t.Helper()
+	sessions, err := store.GetAllSessions(t.Context(), domain.AllTime())
+	require.NoError(t, err)
+	return sessions
+}
+
+// activePomodoros returns the active Pomodoros of the default user in store.
+func activePomodoros(t *testing.T, store domain.ActivePomodoroStore) []domain.ActivePomodoro {
+	t.Helper()
+	active, err := store.GetActivePomodoros(t.Context())
+	require.NoError(t, err)
+	return active
+}
+
+// storeWithActive returns an in-memory store holding the active Pomodoros.
+func storeWithActive(t *testing.T, active ...domain.ActivePomodoro) *database.InMemorySubjectStore {
+	t.Helper()
+	store := database.NewInMemorySubjectStore()
+	for _, a := range active {
+		require.NoError(t, store.SaveActivePomodoro(t.Context(), a))
+	}
+	return store
+}
+
+func TestStudySession_RecordPomodoroCycle(t *testing.T) {
+	start := time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)
+	blocks := []domain.FocusBlock{
+		{StartedAt: start, EndedAt: start.Add(25 * time.Minute), Focused: 25 * time.Minute},
+		{StartedAt: start.Add(30 * time.Minute), EndedAt: start.Add(40 * time.Minute), Focused: 10 * time.Minute},
+	}
+
+	t.Run("logs each focus block as a pomodoro session", func(t *testing.T) {
+		store := database.NewInMemorySubjectStore()
+		pomodoroSpy := &SpyPomodoroRunner{Blocks: blocks, Err: domain.ErrPomodoroCancelled}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart))
+
+		err := session.RecordPomodoroCycle(t.Context(), "cli", domain.PomodoroConfig{Rounds: 2}, &bytes.Buffer{}, nil)
+		assert.ErrorIs(t, err, domain.ErrPomodoroCancelled)
+
+		assert.Equal(t, []domain.PomodoroConfig{{Rounds: 2}}, pomodoroSpy.Configs)
+		assert.Len(t, loggedSessions(t, store), 2)
+		for i, logged := range loggedSessions(t, store) {
+			assert.Equal(t, domain.SourcePomodoro, logged.Source)
+			assert.Equal(t, blocks[i].Focused, logged.Duration)
+			assert.Equal(t, blocks[i].StartedAt, logged.StartedAt)
+			assert.Equal(t, blocks[i].EndedAt, logged.EndedAt)
+		}
+	})
+	t.Run("stops the cycle when the store fails", func(t *testing.T) {
+		store := &testhelpers.StubSubjectStore{SubjectStore: database.NewInMemorySubjectStore(), LogSessionErr: errors.New("persistent storage failure")}
+		session := domain.NewStudySession(store, &SpyPomodoroRunner{Blocks: blocks}, testhelpers.NewFakeClock(sessionStart))
+
+		err := session.RecordPomodoroCycle(t.Context(), "cli", domain.PomodoroConfig{}, &bytes.Buffer{}, nil)
+		assert.ErrorContains(t, err, "persistent storage failure")
+	})
 }
 
 func TestStudySession_RecordPomodoro(t *testing.T) {
-	t.Run("starts pomodoro and records 1 hour", func(t *testing.T) {
+	t.Run("starts pomodoro and logs its real length as a pomodoro session", func(t *testing.T) {
 		out := &bytes.Buffer{}
 		store := &testhelpers.StubSubjectStore{
-			Hours:      map[string]int{},
-			RecordCall: []string{},
+			SubjectStore: database.NewInMemorySubjectStore(),
 		}
 
 		pomodoroSpy := &SpyPomodoroRunner{}
-		session := domain.NewStudySession(store, pomodoroSpy)
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart))
 
-		err := session.RecordPomodoro("cli", out)
+		recorded, err := session.RecordPomodoro(t.Context(), "cli", domain.PomodoroConfig{}, out, nil)
 		assert.NoError(t, err)
 
-		v, ok := store.Hours["cli"]
-		assert.True(t, ok)
-		assert.Equal(t, 1, v, "should record 1 hour")
+		assert.Equal(t, 25*time.Minute, hoursOf(t, store, "cli"), "should record 25 minutes")
 		assert.Equal(t, 1, pomodoroSpy.StartCallCount, "should start pomodoro once")
+
+		assert.Len(t, loggedSessions(t, store), 1)
+		logged := loggedSessions(t, store)[0]
+		assert.Equal(t, "cli", logged.Subject)
+		assert.Equal(t, domain.SourcePomodoro, logged.Source)
+		assert.Equal(t, 25*time.Minute, logged.Duration)
+		assert.Equal(t, sessionStart, logged.StartedAt, "should be timestamped by the clock")
+		assert.Equal(t, sessionStart, logged.EndedAt)
+		assert.Equal(t, logged, recorded, "should return the session logged")
+	})
+	t.Run("passes the session config to the runner", func(t *testing.T) {
+		store := database.NewInMemorySubjectStore()
+		pomodoroSpy := &SpyPomodoroRunner{}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart))
+
+		config := domain.PomodoroConfig{Focus: 50 * time.Minute}
+		_, err := session.RecordPomodoro(t.Context(), "cli", config, &bytes.Buffer{}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.PomodoroConfig{config}, pomodoroSpy.Configs)
 	})
 	t.Run("returns error if store fails", func(t *testing.T) {
 		out := &bytes.Buffer{}
 		store := &testhelpers.StubSubjectStore{
-			Hours:         map[string]int{},
-			RecordCall:    []string{},
-			RecordHourErr: errors.New("persistent storage failure"),
+			SubjectStore:  database.NewInMemorySubjectStore(),
+			LogSessionErr: errors.New("persistent storage failure"),
 		}
 
 		pomodoroSpy := &SpyPomodoroRunner{}
-		session := domain.NewStudySession(store, pomodoroSpy)
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart))
 
-		err := session.RecordPomodoro("cli", out)
+		_, err := session.RecordPomodoro(t.Context(), "cli", domain.PomodoroConfig{}, out, nil)
 		assert.Error(t, err)
 
-		v, ok := store.Hours["cli"]
-		assert.True(t, !ok)
-		assert.Equal(t, 0, v, "should not record 1 hour")
+		assert.Zero(t, hoursOf(t, store, "cli"), "should not record the session")
 		assert.Equal(t, 1, pomodoroSpy.StartCallCount, "should still start pomodoro")
 	})
+	t.Run("records nothing when cancelled", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		store := database.NewInMemorySubjectStore()
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		pomodoroSpy := &SpyPomodoroRunner{}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart))
+
+		_, err := session.RecordPomodoro(ctx, "cli", domain.PomodoroConfig{}, out, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, loggedSessions(t, store), "cancelled pomodoro should not be logged")
+	})
+	t.Run("logs the focus time spent when cancelled midway", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		store := database.NewInMemorySubjectStore()
+
+		pomodoroSpy := &SpyPomodoroRunner{Focused: 10*time.Minute + 30*time.Millisecond, Err: domain.ErrPomodoroCancelled}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart))
+
+		control := domain.NewPomodoroControl()
+		recorded, err := session.RecordPomodoro(t.Context(), "cli", domain.PomodoroConfig{}, out, control)
+		assert.ErrorIs(t, err, domain.ErrPomodoroCancelled)
+
+		assert.Len(t, loggedSessions(t, store), 1)
+		assert.Equal(t, loggedSessions(t, store)[0], recorded, "should return the partial session")
+		assert.Equal(t, 10*time.Minute, loggedSessions(t, store)[0].Duration, "should log whole seconds of focus")
+		assert.Equal(t, domain.SourcePomodoro, loggedSessions(t, store)[0].Source)
+		assert.Equal(t, domain.PomodoroFinished, control.State(), "control should be finished once the session returns")
+	})
+	t.Run("logs nothing when cancelled before any focus", func(t *testing.T) {
+		store := database.NewInMemorySubjectStore()
+
+		pomodoroSpy := &SpyPomodoroRunner{Err: domain.ErrPomodoroCancelled}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart))
+
+		recorded, err := session.RecordPomodoro(t.Context(), "cli", domain.PomodoroConfig{}, &bytes.Buffer{}, nil)
+		assert.ErrorIs(t, err, domain.ErrPomodoroCancelled)
+		assert.Empty(t, loggedSessions(t, store))
+		assert.Zero(t, recorded)
+	})
+}
+
+func TestStudySession_RecordActivePomodoro(t *testing.T) {
+	t.Run("keeps the pomodoro in the store while it runs", func(t *testing.T) {
+		store := database.NewInMemorySubjectStore()
+		var during []domain.ActivePomodoro
+		pomodoroSpy := &SpyPomodoroRunner{During: func(*domain.PomodoroControl) {
+			during, _ = store.GetActivePomodoros(context.Background())
+		}}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart))
+
+		err := session.RecordActivePomodoro(t.Context(), "abc", "web", domain.PomodoroConfig{Focus: 50 * time.Minute}, &bytes.Buffer{}, nil)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []domain.ActivePomodoro{{
+			ID:        "abc",
+			UserID:    domain.DefaultUser,
+			Subject:   "web",
+			StartedAt: sessionStart,
+			EndsAt:    sessionStart.Add(50 * time.Minute),
+			Focus:     50 * time.Minute,
+		}}, during)
+		assert.Empty(t, activePomodoros(t, store), "should be removed once over")
+		assert.Len(t, loggedSessions(t, store), 1)
+		assert.Equal(t, 25*time.Minute, loggedSessions(t, store)[0].Duration)
+	})
+	t.Run("saves pauses", func(t *testing.T) {
+		store := database.NewInMemorySubjectStore()
+		clock := testhelpers.NewFakeClock(sessionStart)
+		activeAt := func() domain.ActivePomodoro {
+			active, _ := store.GetActivePomodoros(context.Background())
+			if len(active) != 1 {
+				return domain.ActivePomodoro{}
+			}
+			return active[0]
+		}
+		pomodoroSpy := &SpyPomodoroRunner{During: func(control *domain.PomodoroControl) {
+			clock.Advance(5 * time.Minute)
+			control.Pause()
+			assert.Eventually(t, func() bool { return activeAt().PausedAt.Equal(sessionStart.Add(5 * time.Minute)) }, time.Second, time.Millisecond)
+
+			clock.Advance(10 * time.Minute)
+			control.Resume()
+			assert.Eventually(t, func() bool { return !activeAt().Paused() }, time.Second, time.Millisecond)
+			assert.Equal(t, sessionStart.Add(35*time.Minute), activeAt().EndsAt, "should move the end back by the pause")
+		}}
+		session := domain.NewStudySession(store, pomodoroSpy, clock)
+
+		err := session.RecordActivePomodoro(t.Context(), "abc", "web", domain.PomodoroConfig{}, &bytes.Buffer{}, nil)
+		assert.NoError(t, err)
+	})
+	t.Run("leaves the pomodoro in the store when the context is done", func(t *testing.T) {
+		store := database.NewInMemorySubjectStore()
+		ctx, cancel := context.WithCancel(t.Context())
+		pomodoroSpy := &SpyPomodoroRunner{During: func(*domain.PomodoroControl) { cancel() }}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart))
+
+		err := session.RecordActivePomodoro(ctx, "abc", "web", domain.PomodoroConfig{}, &bytes.Buffer{}, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		assert.Len(t, activePomodoros(t, store), 1)
+		assert.Empty(t, loggedSessions(t, store), "should be logged once resumed")
+	})
+}
+
+func TestStudySession_RecordActivePomodoroCycle(t *testing.T) {
+	blocks := []domain.FocusBlock{
+		{Round: 1, StartedAt: sessionStart, EndedAt: sessionStart.Add(25 * time.Minute), Focused: 25 * time.Minute},
+		{Round: 2, StartedAt: sessionStart.Add(30 * time.Minute), EndedAt: sessionStart.Add(55 * time.Minute), Focused: 25 * time.Minute},
+	}
+
+	t.Run("keeps the cycle in the store with the round it is in", func(t *testing.T) {
+		store := database.NewInMemorySubjectStore()
+		var during []domain.ActivePomodoro
+		pomodoroSpy := &SpyPomodoroRunner{Blocks: blocks, During: func(*domain.PomodoroControl) {
+			active, _ := store.GetActivePomodoros(context.Background())
+			during = append(during, active...)
+		}}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart))
+
+		err := session.RecordActivePomodoroCycle(t.Context(), "abc", "web", domain.PomodoroConfig{Rounds: 2}, &bytes.Buffer{}, nil)
+		assert.NoError(t, err)
+
+		require.Len(t, during, 2)
+		assert.Equal(t, domain.ActivePomodoro{
+			ID:        "abc",
+			UserID:    domain.DefaultUser,
+			Subject:   "web",
+			StartedAt: sessionStart,
+			EndsAt:    sessionStart.Add(25 * time.Minute),
+			Focus:     25 * time.Minute,
+			Round:     1,
+			Rounds:    2,
+		}, during[0])
+		assert.Equal(t, 2, during[1].Round)
+		assert.Empty(t, activePomodoros(t, store), "should be removed once over")
+		assert.Len(t, loggedSessions(t, store), 2)
+	})
+	t.Run("leaves the round under way in the store when the context is done", func(t *testing.T) {
+		store := database.NewInMemorySubjectStore()
+		ctx, cancel := context.WithCancel(t.Context())
+		pomodoroSpy := &SpyPomodoroRunner{Blocks: blocks, During: func(*domain.PomodoroControl) { cancel() }}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart))
+
+		err := session.RecordActivePomodoroCycle(ctx, "abc", "web", domain.PomodoroConfig{}, &bytes.Buffer{}, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		require.Len(t, activePomodoros(t, store), 1)
+		assert.Equal(t, 1, activePomodoros(t, store)[0].Round)
+		assert.Equal(t, domain.DefaultCycleRounds, activePomodoros(t, store)[0].Rounds)
+		assert.Empty(t, loggedSessions(t, store), "should be logged once resumed")
+	})
+}
+
+func TestStudySession_ResumeActivePomodoro(t *testing.T) {
+	active := domain.ActivePomodoro{
+		ID:        "abc",
+		Subject:   "web",
+		StartedAt: sessionStart,
+		EndsAt:    sessionStart.Add(25 * time.Minute),
+		Focus:     25 * time.Minute,
+	}
+
+	t.Run("runs the focus time left and logs the whole pomodoro", func(t *testing.T) {
+		store := storeWithActive(t, active)
+		now := sessionStart.Add(10 * time.Minute)
+		pomodoroSpy := &SpyPomodoroRunner{Focused: 15 * time.Minute}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(now))
+
+		err := session.ResumeActivePomodoro(t.Context(), active, &bytes.Buffer{}, nil)
+		assert.NoError(t, err)
+
+		assert.Len(t, pomodoroSpy.Configs, 1)
+		assert.Equal(t, 15*time.Minute, pomodoroSpy.Configs[0].Focus)
+		assert.Empty(t, activePomodoros(t, store))
+		assert.Len(t, loggedSessions(t, store), 1)
+		assert.Equal(t, 25*time.Minute, loggedSessions(t, store)[0].Duration)
+		assert.Equal(t, sessionStart, loggedSessions(t, store)[0].StartedAt)
+	})
+	t.Run("finalizes a pomodoro whose end has passed", func(t *testing.T) {
+		store := storeWithActive(t, active)
+		pomodoroSpy := &SpyPomodoroRunner{}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart.Add(time.Hour)))
+
+		err := session.ResumeActivePomodoro(t.Context(), active, &bytes.Buffer{}, nil)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 0, pomodoroSpy.StartCallCount, "should not run again")
+		assert.Empty(t, activePomodoros(t, store))
+		assert.Len(t, loggedSessions(t, store), 1)
+		assert.Equal(t, 25*time.Minute, loggedSessions(t, store)[0].Duration)
+		assert.Equal(t, active.EndsAt, loggedSessions(t, store)[0].EndedAt)
+	})
+	t.Run("stays paused", func(t *testing.T) {
+		paused := active
+		paused.PausedAt = sessionStart.Add(20 * time.Minute)
+		store := storeWithActive(t, paused)
+		var state domain.PomodoroState
+		pomodoroSpy := &SpyPomodoroRunner{Focused: 5 * time.Minute, During: func(control *domain.PomodoroControl) { state = control.State() }}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart.Add(time.Hour)))
+
+		err := session.ResumeActivePomodoro(t.Context(), paused, &bytes.Buffer{}, nil)
+		assert.NoError(t, err)
+
+		assert.Equal(t, domain.PomodoroPaused, state)
+		assert.Equal(t, 5*time.Minute, pomodoroSpy.Configs[0].Focus, "paused time should not count")
+		assert.Equal(t, 25*time.Minute, loggedSessions(t, store)[0].Duration)
+	})
+
+	cycle := active
+	cycle.Round, cycle.Rounds = 2, 3
+
+	t.Run("carries on a cycle from the round it was in", func(t *testing.T) {
+		store := storeWithActive(t, cycle)
+		now := sessionStart.Add(10 * time.Minute)
+		pomodoroSpy := &SpyPomodoroRunner{Blocks: []domain.FocusBlock{
+			{Round: 2, StartedAt: now, EndedAt: now.Add(15 * time.Minute), Focused: 15 * time.Minute},
+			{Round: 3, StartedAt: now.Add(20 * time.Minute), EndedAt: now.Add(45 * time.Minute), Focused: 25 * time.Minute},
+		}}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(now))
+
+		err := session.ResumeActivePomodoro(t.Context(), cycle, &bytes.Buffer{}, nil)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []domain.PomodoroConfig{{Focus: 25 * time.Minute, Rounds: 3, FirstRound: 2, FirstFocus: 15 * time.Minute}}, pomodoroSpy.Configs)
+		assert.Empty(t, activePomodoros(t, store))
+		require.Len(t, loggedSessions(t, store), 2)
+		assert.Equal(t, 25*time.Minute, loggedSessions(t, store)[0].Duration, "the focus before the resume should count")
+		assert.Equal(t, sessionStart, loggedSessions(t, store)[0].StartedAt)
+		assert.Equal(t, 25*time.Minute, loggedSessions(t, store)[1].Duration)
+	})
+	t.Run("carries on a cycle on a break with the next round", func(t *testing.T) {
+		onBreak := domain.ActivePomodoro{ID: "abc", Subject: "web", Focus: 25 * time.Minute, Round: 3, Rounds: 3}
+		store := storeWithActive(t, onBreak)
+		pomodoroSpy := &SpyPomodoroRunner{}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart))
+
+		err := session.ResumeActivePomodoro(t.Context(), onBreak, &bytes.Buffer{}, nil)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []domain.PomodoroConfig{{Focus: 25 * time.Minute, Rounds: 3, FirstRound: 3}}, pomodoroSpy.Configs)
+		assert.Empty(t, activePomodoros(t, store))
+	})
+	t.Run("logs the round of a cycle whose end has passed", func(t *testing.T) {
+		last := cycle
+		last.Round = 3
+		store := storeWithActive(t, last)
+		pomodoroSpy := &SpyPomodoroRunner{}
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart.Add(time.Hour)))
+
+		err := session.ResumeActivePomodoro(t.Context(), last, &bytes.Buffer{}, nil)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 0, pomodoroSpy.StartCallCount, "the cycle should be over")
+		assert.Empty(t, activePomodoros(t, store))
+		require.Len(t, loggedSessions(t, store), 1)
+		assert.Equal(t, 25*time.Minute, loggedSessions(t, store)[0].Duration)
+	})
 }
 
 func TestStudySession_RecordManual(t *testing.T) {
 	t.Run("records manual hours to store", func(t *testing.T) {
 		store := &testhelpers.StubSubjectStore{
-			Hours:      map[string]int{},
-			RecordCall: []string{},
+			SubjectStore: database.NewInMemorySubjectStore(),
 		}
 
 		pomodoroSpy := &SpyPomodoroRunner{}
-		session := domain.NewStudySession(store, pomodoroSpy)
+		session := domain.NewStudySession(store, pomodoroSpy, testhelpers.NewFakeClock(sessionStart))
 
-		err := session.RecordManual("cli", 3)
+		recorded, err := session.RecordManual(t.Context(), "cli", 90*time.Minute)
 		assert.NoError(t, err)
 
-		v, ok := store.Hours["cli"]
-		assert.True(t, ok)
-		assert.Equal(t, 3, v)
+		assert.Equal(t, 90*time.Minute, hoursOf(t, store, "cli"))
 		assert.Equal(t, 0, pomodoroSpy.StartCallCount, "should not start pomodoro")
+		assert.Len(t, loggedSessions(t, store), 1)
+		assert.Equal(t, domain.SourceManual, loggedSessions(t, store)[0].Source)
+		assert.Equal(t, sessionStart.Add(-90*time.Minute), loggedSessions(t, store)[0].StartedAt, "should end at the clock's now")
+		assert.Equal(t, sessionStart, loggedSessions(t, store)[0].EndedAt)
+		assert.Equal(t, loggedSessions(t, store)[0], recorded, "should return the session logged")
+	})
+}
+
+func TestStudySession_EditSession(t *testing.T) {
+	newSession := func(t *testing.T) (*domain.StudySession, *database.InMemorySubjectStore) {
+		store := database.NewInMemorySubjectStore()
+		session := domain.NewStudySession(store, &SpyPomodoroRunner{}, testhelpers.NewFakeClock(sessionStart))
+		_, err := session.RecordManual(t.Context(), "math", 20*time.Hour)
+		assert.NoError(t, err)
+		return session, store
+	}
+
+	t.Run("corrects the duration and keeps the end", func(t *testing.T) {
+		session, store := newSession(t)
+
+		got, err := session.EditSession(t.Context(), 1, domain.SessionChange{Duration: 2 * time.Hour})
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Hour, got.Duration)
+		assert.Equal(t, sessionStart.Add(-2*time.Hour), got.StartedAt)
+		assert.Equal(t, sessionStart, got.EndedAt)
+		assert.Equal(t, 2*time.Hour, hoursOf(t, store, "math"))
+	})
+	t.Run("moves the session to another subject", func(t *testing.T) {
+		session, store := newSession(t)
+
+		got, err := session.EditSession(t.Context(), 1, domain.SessionChange{Subject: " physics "})
+		assert.NoError(t, err)
+		assert.Equal(t, "physics", got.Subject)
+		assert.Equal(t, 20*time.Hour, got.Duration)
+		assert.Equal(t, 20*time.Hour, hoursOf(t, store, "physics"))
+		assert.Zero(t, hoursOf(t, store, "math"))
+	})
+	t.Run("rejects a negative duration", func(t *testing.T) {
+		session, _ := newSession(t)
+
+		_, err := session.EditSession(t.Context(), 1, domain.SessionChange{Duration: -time.Hour})
+		assert.ErrorIs(t, err, domain.ErrInvalidDuration)
+	})
+	t.Run("returns ErrSessionNotFound for an unknown session", func(t *testing.T) {
+		session, _ := newSession(t)
+
+		_, err := session.EditSession(t.Context(), 42, domain.SessionChange{Duration: time.Hour})
+		assert.ErrorIs(t, err, domain.ErrSessionNotFound)
+	})
+}
+
+func TestStudySession_DeleteSession(t *testing.T) {
+	store := database.NewInMemorySubjectStore()
+	session := domain.NewStudySession(store, &SpyPomodoroRunner{}, testhelpers.NewFakeClock(sessionStart))
+	_, err := session.RecordManual(t.Context(), "math", time.Hour)
+	assert.NoError(t, err)
+	_, err = session.RecordManual(t.Context(), "go", time.Hour)
+	assert.NoError(t, err)
+
+	deleted, err := session.DeleteSession(t.Context(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "math", deleted.Subject)
+	assert.Len(t, loggedSessions(t, store), 1)
+
+	_, err = session.DeleteSession(t.Context(), 1)
+	assert.ErrorIs(t, err, domain.ErrSessionNotFound)
+}
+
+func TestStudySession_UndoLastSession(t *testing.T) {
+	t.Run("removes the session logged last", func(t *testing.T) {
+		store := database.NewInMemorySubjectStore()
+		session := domain.NewStudySession(store, &SpyPomodoroRunner{}, testhelpers.NewFakeClock(sessionStart))
+		_, err := session.RecordManual(t.Context(), "go", time.Hour)
+		assert.NoError(t, err)
+		_, err = session.RecordManual(t.Context(), "math", 20*time.Hour)
+		assert.NoError(t, err)
+
+		undone, err := session.UndoLastSession(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, "math", undone.Subject)
+		assert.Equal(t, 20*time.Hour, undone.Duration)
+		if assert.Len(t, loggedSessions(t, store), 1) {
+			assert.Equal(t, "go", loggedSessions(t, store)[0].Subject)
+		}
+	})
+	t.Run("returns ErrSessionNotFound when nothing was logged", func(t *testing.T) {
+		session := domain.NewStudySession(database.NewInMemorySubjectStore(), &SpyPomodoroRunner{}, testhelpers.NewFakeClock(sessionStart))
+
+		_, err := session.UndoLastSession(t.Context())
+		assert.ErrorIs(t, err, domain.ErrSessionNotFound)
+	})
+}
+
+func TestStudySession_Goals(t *testing.T) {
+	// sessionStart is a Monday, so the week and the day start together.
+	store := &testhelpers.StubSubjectStore{SubjectStore: database.NewInMemorySubjectStore()}
+	for subject, duration := range map[string]time.Duration{"go": 6 * time.Hour, "sql": 5 * time.Hour} {
+		_, err := store.LogSession(t.Context(), domain.LoggedSession{
+			Subject:   subject,
+			StartedAt: sessionStart,
+			EndedAt:   sessionStart.Add(duration),
+			Duration:  duration,
+			Source:    domain.SourceManual,
+		})
+		assert.NoError(t, err)
+	}
+	session := domain.NewStudySession(store, &SpyPomodoroRunner{}, testhelpers.NewFakeClock(sessionStart))
+
+	t.Run("rejects invalid goals", func(t *testing.T) {
+		tests := []struct {
+			name string
+			goal domain.Goal
+		}{
+			{"empty subject", domain.Goal{Subject: " ", Period: domain.GoalWeekly, Target: time.Hour}},
+			{"unknown period", domain.Goal{Subject: "go", Period: "yearly", Target: time.Hour}},
+			{"no target", domain.Goal{Subject: "go", Period: domain.GoalWeekly}},
+		}
+		for _, tt := range tests {
+			assert.ErrorIs(t, session.SetGoal(t.Context(), tt.goal), domain.ErrInvalidGoal, tt.name)
+		}
+		goals, err := store.GetGoals(t.Context())
+		assert.NoError(t, err)
+		assert.Empty(t, goals)
+	})
+	t.Run("computes progress from the time studied in the current period", func(t *testing.T) {
+		assert.NoError(t, session.SetGoal(t.Context(), domain.Goal{Subject: " go ", Period: domain.GoalWeekly, Target: 10 * time.Hour}))
+		assert.NoError(t, session.SetGoal(t.Context(), domain.Goal{Subject: "sql", Period: domain.GoalWeekly, Target: 4 * time.Hour}))
+		assert.NoError(t, session.SetGoal(t.Context(), domain.Goal{Subject: "math", Period: domain.GoalWeekly, Target: time.Hour}))
+
+		progress, err := session.Goals(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ThisWeek(sessionStart), store.ReportRange)
+		if assert.Len(t, progress, 3) {
+			assert.Equal(t, "go", progress[0].Subject)
+			assert.Equal(t, 6*time.Hour, progress[0].Done)
+			assert.Equal(t, 4*time.Hour, progress[0].Remaining())
+			assert.Equal(t, 60, progress[0].Percent())
+			assert.False(t, progress[0].Met())
+
+			assert.Equal(t, "math", progress[1].Subject, "goals should be ordered by subject")
+			assert.Zero(t, progress[1].Done, "a subject not studied yet should have no progress")
+
+			assert.True(t, progress[2].Met())
+			assert.Equal(t, 125, progress[2].Percent())
+			assert.Zero(t, progress[2].Remaining())
+		}
+	})
+	t.Run("removes goals", func(t *testing.T) {
+		assert.NoError(t, session.DeleteGoal(t.Context(), "math", domain.GoalWeekly))
+		assert.ErrorIs(t, session.DeleteGoal(t.Context(), "math", domain.GoalWeekly), domain.ErrGoalNotFound)
+	})
+}
+
+func TestStudySession_Stats(t *testing.T) {
+	store := database.NewInMemorySubjectStore()
+	session := domain.NewStudySession(store, &SpyPomodoroRunner{}, testhelpers.NewFakeClock(sessionStart))
+	_, err := session.RecordManual(t.Context(), "go", time.Hour)
+	assert.NoError(t, err)
+	_, err = session.RecordManual(t.Context(), "go", 2*time.Hour)
+	assert.NoError(t, err)
+
+	t.Run("computes stats from every session", func(t *testing.T) {
+		stats, err := session.Stats(t.Context(), 4)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, stats.CurrentStreak)
+		assert.Len(t, stats.Weeks, 4)
+		assert.Equal(t, []domain.SubjectStats{{Subject: "go", Sessions: 2, Total: 3 * time.Hour}}, stats.Subjects)
+	})
+	t.Run("rejects too few or too many weeks", func(t *testing.T) {
+		for _, weeks := range []int{0, domain.MaxStatsWeeks + 1} {
+			_, err := session.Stats(t.Context(), weeks)
+			assert.ErrorIs(t, err, domain.ErrInvalidStats, "%d weeks", weeks)
+		}
 	})
 }
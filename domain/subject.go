@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSubjectExists        = errors.New("subject already exists")
+	ErrInvalidSubject       = errors.New("invalid subject")
+	ErrInvalidSubjectPolicy = errors.New("invalid subject policy")
+)
+
+// SubjectPolicy decides how the subjects of new sessions are spelled.
+type SubjectPolicy string
+
+const (
+	// SubjectsExact keeps subjects as they were typed, so "tdd" and "TDD"
+	// are two subjects.
+	SubjectsExact SubjectPolicy = "exact"
+	// SubjectsCaseInsensitive trims and lowercases subjects, so "TDD" is
+	// recorded and looked up as "tdd".
+	SubjectsCaseInsensitive SubjectPolicy = "case-insensitive"
+)
+
+// ParseSubjectPolicy parses "exact" or "case-insensitive"; empty means exact.
+func ParseSubjectPolicy(s string) (SubjectPolicy, error) {
+	switch policy := SubjectPolicy(s); policy {
+	case "":
+		return SubjectsExact, nil
+	case SubjectsExact, SubjectsCaseInsensitive:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w %q: should be %s or %s", ErrInvalidSubjectPolicy, s, SubjectsExact, SubjectsCaseInsensitive)
+	}
+}
+
+// Normalize returns subject spelled as the policy wants it.
+func (p SubjectPolicy) Normalize(subject string) string {
+	if p == SubjectsCaseInsensitive {
+		return strings.ToLower(strings.TrimSpace(subject))
+	}
+	return subject
+}
+
+// NormalizeSubjects returns store with the subjects it records and looks up
+// spelled by policy. Sessions recorded before keep their spelling; merge them
+// with MergeSubjects. With SubjectsExact store is returned as it is.
+func NormalizeSubjects(store SubjectStore, policy SubjectPolicy) SubjectStore {
+	if policy != SubjectsCaseInsensitive {
+		return store
+	}
+	return normalizedStore{SubjectStore: store, policy: policy}
+}
+
+type normalizedStore struct {
+	SubjectStore
+	policy SubjectPolicy
+}
+
+func (n normalizedStore) GetHours(ctx context.Context, subject string) (time.Duration, error) {
+	return n.SubjectStore.GetHours(ctx, n.policy.Normalize(subject))
+}
+
+func (n normalizedStore) LogSession(ctx context.Context, session LoggedSession) (LoggedSession, error) {
+	session.Subject = n.policy.Normalize(session.Subject)
+	return n.SubjectStore.LogSession(ctx, session)
+}
+
+func (n normalizedStore) LogSessions(ctx context.Context, sessions []LoggedSession) error {
+	normalized := make([]LoggedSession, len(sessions))
+	for i, session := range sessions {
+		session.Subject = n.policy.Normalize(session.Subject)
+		normalized[i] = session
+	}
+	return n.SubjectStore.LogSessions(ctx, normalized)
+}
+
+func (n normalizedStore) LogNewSessions(ctx context.Context, sessions []LoggedSession) ([]LoggedSession, error) {
+	normalized := make([]LoggedSession, len(sessions))
+	for i, session := range sessions {
+		session.Subject = n.policy.Normalize(session.Subject)
+		normalized[i] = session
+	}
+	return n.SubjectStore.LogNewSessions(ctx, normalized)
+}
+
+func (n normalizedStore) GetSessions(ctx context.Context, subject string) ([]LoggedSession, error) {
+	return n.SubjectStore.GetSessions(ctx, n.policy.Normalize(subject))
+}
+
+func (n normalizedStore) UpdateSession(ctx context.Context, session LoggedSession) error {
+	session.Subject = n.policy.Normalize(session.Subject)
+	return n.SubjectStore.UpdateSession(ctx, session)
+}
+
+func (n normalizedStore) SaveActivePomodoro(ctx context.Context, active ActivePomodoro) error {
+	active.Subject = n.policy.Normalize(active.Subject)
+	return n.SubjectStore.SaveActivePomodoro(ctx, active)
+}
+
+func (n normalizedStore) SaveGoal(ctx context.Context, goal Goal) error {
+	goal.Subject = n.policy.Normalize(goal.Subject)
+	return n.SubjectStore.SaveGoal(ctx, goal)
+}
+
+func (n normalizedStore) DeleteGoal(ctx context.Context, subject string, period GoalPeriod) error {
+	return n.SubjectStore.DeleteGoal(ctx, n.policy.Normalize(subject), period)
+}
+
+// RenameSubject and MergeSubjects only normalize the subject sessions move to,
+// so that sessions spelled otherwise can still be moved.
+func (n normalizedStore) RenameSubject(ctx context.Context, from, to string) (int, error) {
+	return n.SubjectStore.RenameSubject(ctx, from, n.policy.Normalize(to))
+}
+
+func (n normalizedStore) MergeSubjects(ctx context.Context, into string, from []string) (int, error) {
+	return n.SubjectStore.MergeSubjects(ctx, n.policy.Normalize(into), from)
+}
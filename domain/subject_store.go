@@ -1,7 +1,25 @@
 package domain
 
+import (
+	"context"
+	"time"
+)
+
+// SubjectStore persists study sessions and derives per-subject totals from them.
 type SubjectStore interface {
-	GetHours(subject string) (int, error)
-	RecordHour(subject string, numHours int) error
-	GetReport() (Report, error)
+	StudySessionLog
+	ActivePomodoroStore
+	TokenStore
+	GoalStore
+	GetHours(ctx context.Context, subject string) (time.Duration, error)
+	GetReport(ctx context.Context, period TimeRange) (Report, error)
+	// RenameSubject moves every session of the user of ctx from subject from
+	// to subject to, which must have none yet, and returns how many moved. It
+	// returns ErrSubjectNotFound or ErrSubjectExists without moving anything.
+	RenameSubject(ctx context.Context, from, to string) (int, error)
+	// MergeSubjects moves every session of the user of ctx from the subjects
+	// from to subject into in one step and returns how many moved. Sessions
+	// keep their times and source. It returns ErrSubjectNotFound without
+	// moving anything when one of from has no sessions.
+	MergeSubjects(ctx context.Context, into string, from []string) (int, error)
 }
@@ -0,0 +1,114 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/adapters/database"
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/bryack/study_hours_tracker/testhelpers"
+	"github.com/bryack/study_hours_tracker/testhelpers/storetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubjectPolicy(t *testing.T) {
+	tests := []struct {
+		policy  domain.SubjectPolicy
+		subject string
+		want    string
+	}{
+		{domain.SubjectsExact, " TDD ", " TDD "},
+		{domain.SubjectsCaseInsensitive, " TDD ", "tdd"},
+		{domain.SubjectsCaseInsensitive, "Test-Driven", "test-driven"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.policy.Normalize(tt.subject), "%s normalizes %q", tt.policy, tt.subject)
+	}
+
+	policy, err := domain.ParseSubjectPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.SubjectsExact, policy)
+
+	_, err = domain.ParseSubjectPolicy("lowercase")
+	assert.ErrorIs(t, err, domain.ErrInvalidSubjectPolicy)
+}
+
+func TestNormalizeSubjects(t *testing.T) {
+	t.Run("records and looks up subjects case-insensitively", func(t *testing.T) {
+		stub := &testhelpers.StubSubjectStore{SubjectStore: database.NewInMemorySubjectStore()}
+		store := domain.NormalizeSubjects(stub, domain.SubjectsCaseInsensitive)
+
+		storetest.RecordHours(t, t.Context(), store, "TDD", time.Hour)
+		storetest.RecordHours(t, t.Context(), store, " Tdd", time.Hour)
+
+		assert.Equal(t, []string{"tdd", "tdd"}, stub.RecordCall)
+		hours, err := store.GetHours(t.Context(), "TDD")
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Hour, hours)
+	})
+	t.Run("moves sessions spelled otherwise to the normalized subject", func(t *testing.T) {
+		inner := database.NewInMemorySubjectStore()
+		storetest.RecordHours(t, t.Context(), inner, "TDD", time.Hour)
+		store := domain.NormalizeSubjects(inner, domain.SubjectsCaseInsensitive)
+
+		_, err := store.MergeSubjects(t.Context(), "Tdd", []string{"TDD"})
+		assert.NoError(t, err)
+		assert.Equal(t, "tdd", loggedSessions(t, inner)[0].Subject)
+	})
+	t.Run("leaves the store alone when subjects are exact", func(t *testing.T) {
+		inner := database.NewInMemorySubjectStore()
+		assert.Same(t, inner, domain.NormalizeSubjects(inner, domain.SubjectsExact))
+	})
+}
+
+func TestStudySession_RenameSubject(t *testing.T) {
+	store := database.NewInMemorySubjectStore()
+	session := domain.NewStudySession(store, &SpyPomodoroRunner{}, testhelpers.NewFakeClock(sessionStart))
+	_, err := session.RecordManual(t.Context(), "TDD", time.Hour)
+	assert.NoError(t, err)
+	_, err = session.RecordManual(t.Context(), "go", time.Hour)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		from, to string
+		wantErr  error
+	}{
+		{"rejects an empty name", "TDD", " ", domain.ErrInvalidSubject},
+		{"rejects the same name", "TDD", "TDD", domain.ErrInvalidSubject},
+		{"rejects a name that is taken", "TDD", "go", domain.ErrSubjectExists},
+		{"rejects an unknown subject", "java", "kotlin", domain.ErrSubjectNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := session.RenameSubject(t.Context(), tt.from, tt.to)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	moved, err := session.RenameSubject(t.Context(), "TDD", "tdd ")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, moved)
+	assert.Equal(t, time.Hour, hoursOf(t, store, "tdd"))
+}
+
+func TestStudySession_MergeSubjects(t *testing.T) {
+	store := database.NewInMemorySubjectStore()
+	session := domain.NewStudySession(store, &SpyPomodoroRunner{}, testhelpers.NewFakeClock(sessionStart))
+	for _, subject := range []string{"tdd", "TDD", "test-driven"} {
+		_, err := session.RecordManual(t.Context(), subject, time.Hour)
+		assert.NoError(t, err)
+	}
+
+	_, err := session.MergeSubjects(t.Context(), "tdd", []string{"TDD", "tdd"})
+	assert.ErrorIs(t, err, domain.ErrInvalidSubject, "should not merge a subject into itself")
+	_, err = session.MergeSubjects(t.Context(), "tdd", nil)
+	assert.ErrorIs(t, err, domain.ErrInvalidSubject)
+
+	moved, err := session.MergeSubjects(t.Context(), "tdd", []string{"TDD", "test-driven", "TDD"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, moved)
+	report, err := store.GetReport(t.Context(), domain.AllTime())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Report{{Subject: "tdd", Duration: 3 * time.Hour}}, report)
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidPeriod = errors.New("invalid report period")
+
+// Report periods understood by ParsePeriod.
+const (
+	PeriodAll   = "all"
+	PeriodToday = "today"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// TimeRange is a half-open [From, To) time window. A zero bound leaves that side open.
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// AllTime returns a window without bounds.
+func AllTime() TimeRange {
+	return TimeRange{}
+}
+
+// Today returns the calendar day containing now.
+func Today(now time.Time) TimeRange {
+	start := startOfDay(now)
+	return TimeRange{From: start, To: start.AddDate(0, 0, 1)}
+}
+
+// ThisWeek returns the calendar week containing now, starting on Monday.
+func ThisWeek(now time.Time) TimeRange {
+	start := startOfDay(now)
+	offset := (int(start.Weekday()) + 6) % 7
+	start = start.AddDate(0, 0, -offset)
+	return TimeRange{From: start, To: start.AddDate(0, 0, 7)}
+}
+
+// ThisMonth returns the calendar month containing now.
+func ThisMonth(now time.Time) TimeRange {
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	return TimeRange{From: start, To: start.AddDate(0, 1, 0)}
+}
+
+// ParsePeriod resolves a named period relative to now.
+func ParsePeriod(period string, now time.Time) (TimeRange, error) {
+	switch period {
+	case "", PeriodAll:
+		return AllTime(), nil
+	case PeriodToday:
+		return Today(now), nil
+	case PeriodWeek:
+		return ThisWeek(now), nil
+	case PeriodMonth:
+		return ThisMonth(now), nil
+	default:
+		return TimeRange{}, fmt.Errorf("%w %q: should be one of %s, %s, %s, %s", ErrInvalidPeriod, period, PeriodAll, PeriodToday, PeriodWeek, PeriodMonth)
+	}
+}
+
+// Contains reports whether t falls inside the window.
+func (r TimeRange) Contains(t time.Time) bool {
+	if !r.From.IsZero() && t.Before(r.From) {
+		return false
+	}
+	if !r.To.IsZero() && !t.Before(r.To) {
+		return false
+	}
+	return true
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
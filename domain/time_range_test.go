@@ -0,0 +1,62 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePeriod(t *testing.T) {
+	// Wednesday
+	now := time.Date(2026, 3, 18, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period string
+		want   domain.TimeRange
+	}{
+		{period: "", want: domain.TimeRange{}},
+		{period: domain.PeriodAll, want: domain.TimeRange{}},
+		{period: domain.PeriodToday, want: domain.TimeRange{
+			From: time.Date(2026, 3, 18, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2026, 3, 19, 0, 0, 0, 0, time.UTC),
+		}},
+		{period: domain.PeriodWeek, want: domain.TimeRange{
+			From: time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2026, 3, 23, 0, 0, 0, 0, time.UTC),
+		}},
+		{period: domain.PeriodMonth, want: domain.TimeRange{
+			From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			got, err := domain.ParsePeriod(tt.period, now)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("week starts on monday when now is sunday", func(t *testing.T) {
+		sunday := time.Date(2026, 3, 22, 10, 0, 0, 0, time.UTC)
+		got := domain.ThisWeek(sunday)
+		assert.Equal(t, time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), got.From)
+	})
+
+	t.Run("unknown period", func(t *testing.T) {
+		_, err := domain.ParsePeriod("year", now)
+		assert.ErrorIs(t, err, domain.ErrInvalidPeriod)
+	})
+}
+
+func TestTimeRange_Contains(t *testing.T) {
+	r := domain.Today(time.Date(2026, 3, 18, 15, 30, 0, 0, time.UTC))
+
+	assert.True(t, r.Contains(time.Date(2026, 3, 18, 0, 0, 0, 0, time.UTC)), "start is inclusive")
+	assert.False(t, r.Contains(time.Date(2026, 3, 19, 0, 0, 0, 0, time.UTC)), "end is exclusive")
+	assert.False(t, r.Contains(time.Date(2026, 3, 17, 23, 59, 0, 0, time.UTC)))
+	assert.True(t, domain.AllTime().Contains(time.Time{}))
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTokenNotFound = errors.New("token not found")
+	ErrInvalidScope  = errors.New("invalid scope")
+)
+
+// Scope is what an API token may do.
+type Scope string
+
+const (
+	ScopeRead   Scope = "read"   // totals, reports and watching Pomodoros
+	ScopeRecord Scope = "record" // everything read allows, plus recording time and running Pomodoros
+)
+
+// tokenPrefix marks API tokens so that they are easy to spot in configs and logs.
+const tokenPrefix = "sht_"
+
+// ParseScope parses "read" or "record".
+func ParseScope(s string) (Scope, error) {
+	switch scope := Scope(s); scope {
+	case ScopeRead, ScopeRecord:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("%w %q: should be %s or %s", ErrInvalidScope, s, ScopeRead, ScopeRecord)
+	}
+}
+
+// Allows reports whether a token of scope s may act with the required scope.
+func (s Scope) Allows(required Scope) bool {
+	return s == required || s == ScopeRecord
+}
+
+// APIToken grants access to the data of a user. Only the hash of the secret
+// is kept, so a lost token cannot be recovered, only revoked.
+type APIToken struct {
+	ID        string    `json:"id"`
+	UserID    UserID    `json:"user_id"`
+	Name      string    `json:"name,omitempty"` // what the token is for, e.g. "laptop"
+	Scope     Scope     `json:"scope"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TokenStore keeps the API tokens of every user.
+type TokenStore interface {
+	// SaveToken adds a token.
+	SaveToken(ctx context.Context, token APIToken) error
+	// GetTokenByHash finds the token of any user whose secret hashes to hash,
+	// or returns ErrTokenNotFound.
+	GetTokenByHash(ctx context.Context, hash string) (APIToken, error)
+	// GetTokens returns the tokens of the user of ctx ordered by creation time.
+	GetTokens(ctx context.Context) ([]APIToken, error)
+	// DeleteToken revokes the token of the user of ctx, or returns ErrTokenNotFound.
+	DeleteToken(ctx context.Context, id string) error
+}
+
+// NewAPIToken creates a token for user and returns it with its secret, which
+// is shown once and never stored.
+func NewAPIToken(user UserID, scope Scope, name string, now time.Time) (APIToken, string) {
+	id := strings.ToLower(rand.Text()[:8])
+	secret := tokenPrefix + id + "_" + rand.Text()
+	return APIToken{
+		ID:        id,
+		UserID:    user,
+		Name:      name,
+		Scope:     scope,
+		Hash:      HashToken(secret),
+		CreatedAt: now,
+	}, secret
+}
+
+// HashToken returns the hash a token secret is stored and looked up by.
+func HashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,42 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScope(t *testing.T) {
+	tests := []struct {
+		scope    domain.Scope
+		required domain.Scope
+		want     bool
+	}{
+		{domain.ScopeRead, domain.ScopeRead, true},
+		{domain.ScopeRead, domain.ScopeRecord, false},
+		{domain.ScopeRecord, domain.ScopeRead, true},
+		{domain.ScopeRecord, domain.ScopeRecord, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.scope.Allows(tt.required), "%s allows %s", tt.scope, tt.required)
+	}
+
+	_, err := domain.ParseScope("admin")
+	assert.ErrorIs(t, err, domain.ErrInvalidScope)
+}
+
+func TestNewAPIToken(t *testing.T) {
+	now := time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)
+	token, secret := domain.NewAPIToken("alice", domain.ScopeRead, "laptop", now)
+	other, otherSecret := domain.NewAPIToken("alice", domain.ScopeRead, "laptop", now)
+
+	assert.True(t, strings.HasPrefix(secret, "sht_"+token.ID+"_"))
+	assert.Equal(t, domain.HashToken(secret), token.Hash)
+	assert.NotContains(t, token.Hash, secret, "the secret should not be stored")
+	assert.NotEqual(t, secret, otherSecret)
+	assert.NotEqual(t, token.ID, other.ID)
+	assert.Equal(t, domain.APIToken{ID: token.ID, UserID: "alice", Name: "laptop", Scope: domain.ScopeRead, Hash: token.Hash, CreatedAt: now}, token)
+}
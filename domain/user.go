@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidUser = errors.New("invalid user")
+
+// UserID identifies the user whose study sessions are read and written.
+type UserID string
+
+// DefaultUser owns the data of single-user setups, including everything
+// recorded before there were users.
+const DefaultUser UserID = "default"
+
+const maxUserIDLength = 64
+
+type userKey struct{}
+
+// WithUser returns a context that acts on behalf of user. Stores scope every
+// read and write to the user of the context they are given.
+func WithUser(ctx context.Context, user UserID) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the user set with WithUser, or DefaultUser. The
+// CLI and the server set the user explicitly, so the fallback only serves
+// tests and code run outside of them.
+func UserFromContext(ctx context.Context) UserID {
+	if user, ok := ctx.Value(userKey{}).(UserID); ok {
+		return user
+	}
+	return DefaultUser
+}
+
+// ParseUserID checks a user name such as "alice" or "bob.smith@example.com".
+func ParseUserID(s string) (UserID, error) {
+	if s == "" || len(s) > maxUserIDLength {
+		return "", fmt.Errorf("%w %q: should be 1 to %d characters long", ErrInvalidUser, s, maxUserIDLength)
+	}
+	valid := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("._-@", r)
+	}
+	if strings.IndexFunc(s, func(r rune) bool { return !valid(r) }) >= 0 {
+		return "", fmt.Errorf("%w %q: use letters, digits, '.', '_', '-' or '@'", ErrInvalidUser, s)
+	}
+	return UserID(s), nil
+}
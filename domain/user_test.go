@@ -0,0 +1,33 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUserID(t *testing.T) {
+	valid := []string{"alice", "bob.smith@example.com", "ci-bot_2"}
+	for _, user := range valid {
+		t.Run(user, func(t *testing.T) {
+			got, err := domain.ParseUserID(user)
+			assert.NoError(t, err)
+			assert.Equal(t, domain.UserID(user), got)
+		})
+	}
+
+	invalid := []string{"", "alice smith", "alice/../bob", strings.Repeat("a", 65)}
+	for _, user := range invalid {
+		t.Run("rejects "+user, func(t *testing.T) {
+			_, err := domain.ParseUserID(user)
+			assert.ErrorIs(t, err, domain.ErrInvalidUser)
+		})
+	}
+}
+
+func TestUserFromContext(t *testing.T) {
+	assert.Equal(t, domain.DefaultUser, domain.UserFromContext(t.Context()))
+	assert.Equal(t, domain.UserID("alice"), domain.UserFromContext(domain.WithUser(t.Context(), "alice")))
+}
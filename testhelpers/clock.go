@@ -0,0 +1,180 @@
+package testhelpers
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+// FakeClock is a domain.Clock whose time only moves when Advance is called,
+// so a 25 minute Pomodoro can be run in an instant.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	waiting chan struct{} // closed while a timer is pending
+}
+
+// NewFakeClock returns a clock stopped at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now, waiting: make(chan struct{})}
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	period   time.Duration // non-zero for tickers
+	fire     func(now time.Time)
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.schedule(d, 0, func(now time.Time) { ch <- now })
+	return ch
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) domain.Timer {
+	return c.schedule(d, 0, func(time.Time) { go f() })
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) domain.Ticker {
+	ch := make(chan time.Time, 1)
+	t := c.schedule(d, d, func(now time.Time) {
+		select {
+		case ch <- now:
+		default: // like time.Ticker, drop ticks nobody reads
+		}
+	})
+	return &fakeTicker{timer: t, c: ch}
+}
+
+// Advance moves the clock forward by d, firing every timer that falls due on
+// the way in deadline order.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+	c.advanceTo(target)
+}
+
+// AdvanceToNext moves the clock to the earliest pending timer and fires it.
+// It reports false when no timer is pending.
+func (c *FakeClock) AdvanceToNext() bool {
+	c.mu.Lock()
+	if len(c.timers) == 0 {
+		c.mu.Unlock()
+		return false
+	}
+	target := c.timers[0].deadline
+	c.mu.Unlock()
+	c.advanceTo(target)
+	return true
+}
+
+// Waiting returns a channel that is closed once a timer is pending.
+func (c *FakeClock) Waiting() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.waiting
+}
+
+// Pending returns the number of timers that have not fired or been stopped.
+func (c *FakeClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+func (c *FakeClock) advanceTo(target time.Time) {
+	for {
+		c.mu.Lock()
+		if len(c.timers) == 0 || c.timers[0].deadline.After(target) {
+			if target.After(c.now) {
+				c.now = target
+			}
+			c.mu.Unlock()
+			return
+		}
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		c.now = t.deadline
+		if t.period > 0 {
+			t.deadline = t.deadline.Add(t.period)
+			c.insert(t)
+		}
+		c.updateWaiting()
+		now := c.now
+		c.mu.Unlock()
+		t.fire(now)
+	}
+}
+
+func (c *FakeClock) schedule(d, period time.Duration, fire func(time.Time)) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, deadline: c.now.Add(d), period: period, fire: fire}
+	c.insert(t)
+	return t
+}
+
+// insert adds t in deadline order, keeping timers due at the same time in
+// the order they were created. The caller holds c.mu.
+func (c *FakeClock) insert(t *fakeTimer) {
+	i, _ := slices.BinarySearchFunc(c.timers, t.deadline, func(t *fakeTimer, deadline time.Time) int {
+		if t.deadline.After(deadline) {
+			return 1
+		}
+		return -1
+	})
+	c.timers = slices.Insert(c.timers, i, t)
+	c.updateWaiting()
+}
+
+// updateWaiting keeps the waiting channel closed exactly while a timer is
+// pending. The caller holds c.mu.
+func (c *FakeClock) updateWaiting() {
+	select {
+	case <-c.waiting:
+		if len(c.timers) == 0 {
+			c.waiting = make(chan struct{})
+		}
+	default:
+		if len(c.timers) > 0 {
+			close(c.waiting)
+		}
+	}
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := slices.Index(c.timers, t)
+	if i < 0 {
+		return false
+	}
+	c.timers = slices.Delete(c.timers, i, i+1)
+	c.updateWaiting()
+	return true
+}
+
+type fakeTicker struct {
+	timer *fakeTimer
+	c     chan time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.timer.Stop()
+}
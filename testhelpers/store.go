@@ -0,0 +1,85 @@
+package testhelpers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+)
+
+// StubSubjectStore wraps a working store, such as
+// database.NewInMemorySubjectStore(), to record calls and make single methods
+// fail. Every method it does not override goes to the wrapped store.
+type StubSubjectStore struct {
+	domain.SubjectStore
+
+	mu          sync.Mutex
+	RecordCall  []string         // subjects in the order they were logged
+	ReportRange domain.TimeRange // period of the last report
+
+	// Method-specific errors
+	GetHoursErr    error
+	GetReportErr   error
+	LogSessionErr  error
+	GetSessionsErr error
+}
+
+func (s *StubSubjectStore) record(subjects ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.RecordCall = append(s.RecordCall, subjects...)
+}
+
+func (s *StubSubjectStore) LogSession(ctx context.Context, session domain.LoggedSession) (domain.LoggedSession, error) {
+	if s.LogSessionErr != nil {
+		return domain.LoggedSession{}, s.LogSessionErr
+	}
+	s.record(session.Subject)
+	return s.SubjectStore.LogSession(ctx, session)
+}
+
+func (s *StubSubjectStore) LogSessions(ctx context.Context, sessions []domain.LoggedSession) error {
+	if s.LogSessionErr != nil {
+		return s.LogSessionErr
+	}
+	for _, session := range sessions {
+		s.record(session.Subject)
+	}
+	return s.SubjectStore.LogSessions(ctx, sessions)
+}
+
+func (s *StubSubjectStore) LogNewSessions(ctx context.Context, sessions []domain.LoggedSession) ([]domain.LoggedSession, error) {
+	if s.LogSessionErr != nil {
+		return nil, s.LogSessionErr
+	}
+	fresh, err := s.SubjectStore.LogNewSessions(ctx, sessions)
+	for _, session := range fresh {
+		s.record(session.Subject)
+	}
+	return fresh, err
+}
+
+func (s *StubSubjectStore) GetSessions(ctx context.Context, subject string) ([]domain.LoggedSession, error) {
+	if s.GetSessionsErr != nil {
+		return nil, s.GetSessionsErr
+	}
+	return s.SubjectStore.GetSessions(ctx, subject)
+}
+
+func (s *StubSubjectStore) GetHours(ctx context.Context, subject string) (time.Duration, error) {
+	if s.GetHoursErr != nil {
+		return 0, s.GetHoursErr
+	}
+	return s.SubjectStore.GetHours(ctx, subject)
+}
+
+func (s *StubSubjectStore) GetReport(ctx context.Context, period domain.TimeRange) (domain.Report, error) {
+	if s.GetReportErr != nil {
+		return nil, s.GetReportErr
+	}
+	s.mu.Lock()
+	s.ReportRange = period
+	s.mu.Unlock()
+	return s.SubjectStore.GetReport(ctx, period)
+}
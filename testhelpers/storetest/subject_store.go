@@ -0,0 +1,731 @@
+// Package storetest provides a contract test suite that every
+// domain.SubjectStore implementation is expected to pass.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bryack/study_hours_tracker/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// SubjectStoreContract describes the behaviour shared by all SubjectStore adapters.
+type SubjectStoreContract struct {
+	// NewStore returns an empty store. It is called once per subtest.
+	NewStore func(t testing.TB) domain.SubjectStore
+}
+
+// Test runs the contract against the store returned by NewStore.
+func (c SubjectStoreContract) Test(t *testing.T) {
+	t.Run("accumulates recorded time per subject", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+
+		RecordHours(t, ctx, store, "tdd", 2*time.Hour)
+		RecordHours(t, ctx, store, "tdd", 30*time.Minute)
+		RecordHours(t, ctx, store, "go", time.Hour)
+
+		got, err := store.GetHours(ctx, "tdd")
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Hour+30*time.Minute, got)
+	})
+
+	t.Run("returns ErrSubjectNotFound for unknown subject", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+
+		got, err := store.GetHours(ctx, "nonexistent")
+		assert.ErrorIs(t, err, domain.ErrSubjectNotFound)
+		assert.Equal(t, time.Duration(0), got)
+	})
+
+	t.Run("logs sessions with timestamps and source", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+		startedAt := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+
+		pomodoro, err := store.LogSession(ctx, domain.LoggedSession{
+			Subject:   "go",
+			StartedAt: startedAt.Add(time.Hour),
+			EndedAt:   startedAt.Add(time.Hour + 25*time.Minute),
+			Duration:  25 * time.Minute,
+			Source:    domain.SourcePomodoro,
+		})
+		require.NoError(t, err)
+		_, err = store.LogSession(ctx, domain.LoggedSession{
+			Subject:   "go",
+			StartedAt: startedAt,
+			EndedAt:   startedAt.Add(45 * time.Minute),
+			Duration:  45 * time.Minute,
+			Source:    domain.SourceManual,
+		})
+		require.NoError(t, err)
+		RecordHours(t, ctx, store, "sql", time.Hour)
+
+		sessions, err := store.GetSessions(ctx, "go")
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+
+		assert.True(t, startedAt.Equal(sessions[0].StartedAt), "sessions should be ordered by start time")
+		assert.True(t, startedAt.Add(45*time.Minute).Equal(sessions[0].EndedAt))
+		assert.Equal(t, 45*time.Minute, sessions[0].Duration)
+		assert.Equal(t, domain.SourceManual, sessions[0].Source)
+		assert.Equal(t, domain.SourcePomodoro, sessions[1].Source)
+		assert.NotEqual(t, sessions[0].ID, sessions[1].ID)
+		assert.Equal(t, sessions[1].ID, pomodoro.ID, "should return the session with its id")
+		assert.Equal(t, domain.DefaultUser, pomodoro.UserID)
+
+		hours, err := store.GetHours(ctx, "go")
+		assert.NoError(t, err)
+		assert.Equal(t, 70*time.Minute, hours)
+	})
+
+	t.Run("returns no sessions for unknown subject", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+
+		sessions, err := store.GetSessions(ctx, "nonexistent")
+		assert.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+
+	t.Run("keeps durations in whole seconds", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+
+		logged, err := store.LogSession(ctx, manualSession("go", 25*time.Minute+1500*time.Millisecond))
+		require.NoError(t, err)
+		assert.Equal(t, 25*time.Minute+time.Second, logged.Duration, "should return the duration it stored")
+		fresh, err := store.LogNewSessions(ctx, []domain.LoggedSession{manualSession("sql", time.Hour+time.Millisecond)})
+		require.NoError(t, err)
+		require.Len(t, fresh, 1)
+		assert.Equal(t, time.Hour, fresh[0].Duration)
+
+		got, err := store.GetSession(ctx, logged.ID)
+		require.NoError(t, err)
+		assert.Equal(t, logged.Duration, got.Duration)
+
+		require.NoError(t, store.UpdateSession(ctx, domain.SessionChange{Duration: 10*time.Minute + 999*time.Millisecond}.Apply(got)))
+		hours, err := store.GetHours(ctx, "go")
+		assert.NoError(t, err)
+		assert.Equal(t, 10*time.Minute, hours)
+	})
+
+	t.Run("corrects and deletes sessions", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+
+		_, err := store.GetLastSession(ctx)
+		assert.ErrorIs(t, err, domain.ErrSessionNotFound)
+
+		RecordHours(t, ctx, store, "math", 20*time.Hour)
+		RecordHours(t, ctx, store, "go", time.Hour)
+
+		last, err := store.GetLastSession(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "go", last.Subject, "the session logged last should be found")
+
+		sessions, err := store.GetSessions(ctx, "math")
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		typo := sessions[0]
+
+		fixed := domain.SessionChange{Duration: 2 * time.Hour}.Apply(typo)
+		require.NoError(t, store.UpdateSession(ctx, fixed))
+
+		got, err := store.GetSession(ctx, typo.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Hour, got.Duration)
+		assert.True(t, typo.EndedAt.Equal(got.EndedAt))
+		assert.Equal(t, domain.SourceManual, got.Source)
+
+		hours, err := store.GetHours(ctx, "math")
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Hour, hours)
+
+		require.NoError(t, store.DeleteSession(ctx, last.ID))
+		_, err = store.GetHours(ctx, "go")
+		assert.ErrorIs(t, err, domain.ErrSubjectNotFound)
+
+		_, err = store.GetSession(ctx, last.ID)
+		assert.ErrorIs(t, err, domain.ErrSessionNotFound)
+		assert.ErrorIs(t, store.DeleteSession(ctx, last.ID), domain.ErrSessionNotFound)
+		assert.ErrorIs(t, store.UpdateSession(ctx, last), domain.ErrSessionNotFound)
+
+		last, err = store.GetLastSession(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, typo.ID, last.ID)
+	})
+
+	t.Run("undoes the last session", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+		alice := domain.WithUser(t.Context(), "alice")
+
+		_, err := store.DeleteLastSession(ctx)
+		assert.ErrorIs(t, err, domain.ErrSessionNotFound)
+
+		RecordHours(t, ctx, store, "math", 2*time.Hour)
+		RecordHours(t, ctx, store, "go", time.Hour)
+		RecordHours(t, alice, store, "sql", time.Hour)
+
+		undone, err := store.DeleteLastSession(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "go", undone.Subject, "the session logged last should be undone")
+		assert.Equal(t, time.Hour, undone.Duration)
+		_, err = store.GetSession(ctx, undone.ID)
+		assert.ErrorIs(t, err, domain.ErrSessionNotFound)
+
+		undone, err = store.DeleteLastSession(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "math", undone.Subject, "other users' sessions should not be undone")
+
+		_, err = store.DeleteLastSession(ctx)
+		assert.ErrorIs(t, err, domain.ErrSessionNotFound)
+		hours, err := store.GetHours(alice, "sql")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Hour, hours)
+	})
+
+	t.Run("renames subjects", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+		startedAt := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+
+		_, err := store.LogSession(ctx, domain.LoggedSession{
+			Subject:   "TDD",
+			StartedAt: startedAt,
+			EndedAt:   startedAt.Add(25 * time.Minute),
+			Duration:  25 * time.Minute,
+			Source:    domain.SourcePomodoro,
+		})
+		require.NoError(t, err)
+		RecordHours(t, ctx, store, "TDD", time.Hour)
+		RecordHours(t, ctx, store, "go", time.Hour)
+
+		_, err = store.RenameSubject(ctx, "TDD", "go")
+		assert.ErrorIs(t, err, domain.ErrSubjectExists)
+		_, err = store.RenameSubject(ctx, "java", "kotlin")
+		assert.ErrorIs(t, err, domain.ErrSubjectNotFound)
+
+		moved, err := store.RenameSubject(ctx, "TDD", "tdd")
+		require.NoError(t, err)
+		assert.Equal(t, 2, moved)
+
+		_, err = store.GetHours(ctx, "TDD")
+		assert.ErrorIs(t, err, domain.ErrSubjectNotFound)
+		sessions, err := store.GetSessions(ctx, "tdd")
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.True(t, startedAt.Equal(sessions[0].StartedAt), "sessions should keep their times")
+		assert.Equal(t, domain.SourcePomodoro, sessions[0].Source)
+	})
+
+	t.Run("merges subjects all at once", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+
+		RecordHours(t, ctx, store, "tdd", time.Hour)
+		RecordHours(t, ctx, store, "TDD", 2*time.Hour)
+		RecordHours(t, ctx, store, "test-driven", 30*time.Minute)
+
+		_, err := store.MergeSubjects(ctx, "tdd", []string{"TDD", "typo"})
+		assert.ErrorIs(t, err, domain.ErrSubjectNotFound)
+		hours, err := store.GetHours(ctx, "TDD")
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Hour, hours, "a failed merge should move nothing")
+
+		moved, err := store.MergeSubjects(ctx, "tdd", []string{"TDD", "test-driven"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, moved)
+
+		report, err := store.GetReport(ctx, domain.AllTime())
+		assert.NoError(t, err)
+		assert.Equal(t, domain.Report{{Subject: "tdd", Duration: 3*time.Hour + 30*time.Minute}}, report)
+	})
+
+	t.Run("orders report by time desc then subject", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+
+		RecordHours(t, ctx, store, "docker", 4*time.Hour)
+		RecordHours(t, ctx, store, "tdd", 6*time.Hour)
+		RecordHours(t, ctx, store, "bash", 4*time.Hour)
+		RecordHours(t, ctx, store, "docker", 30*time.Minute)
+
+		report, err := store.GetReport(ctx, domain.AllTime())
+		assert.NoError(t, err)
+		assert.Equal(t, domain.Report{
+			{Subject: "tdd", Duration: 6 * time.Hour},
+			{Subject: "docker", Duration: 4*time.Hour + 30*time.Minute},
+			{Subject: "bash", Duration: 4 * time.Hour},
+		}, report)
+	})
+
+	t.Run("returns empty report for empty store", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+
+		report, err := store.GetReport(ctx, domain.AllTime())
+		assert.NoError(t, err)
+		assert.Empty(t, report)
+	})
+
+	t.Run("limits report to sessions started within the period", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+		monday := time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)
+		sessions := []domain.LoggedSession{
+			{Subject: "go", StartedAt: monday.Add(-time.Minute), Duration: 5 * time.Hour},
+			{Subject: "go", StartedAt: monday, Duration: 30 * time.Minute},
+			{Subject: "sql", StartedAt: monday.AddDate(0, 0, 2), Duration: 2 * time.Hour},
+			{Subject: "sql", StartedAt: monday.AddDate(0, 0, 7), Duration: time.Hour},
+		}
+		for _, ls := range sessions {
+			ls.EndedAt = ls.StartedAt.Add(ls.Duration)
+			ls.Source = domain.SourceManual
+			_, err := store.LogSession(ctx, ls)
+			require.NoError(t, err)
+		}
+
+		report, err := store.GetReport(ctx, domain.ThisWeek(monday))
+		assert.NoError(t, err)
+		assert.Equal(t, domain.Report{
+			{Subject: "sql", Duration: 2 * time.Hour},
+			{Subject: "go", Duration: 30 * time.Minute},
+		}, report)
+	})
+
+	t.Run("lists the sessions of every subject started within the period", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+		monday := time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)
+		sessions := []domain.LoggedSession{
+			{Subject: "sql", StartedAt: monday.AddDate(0, 0, 2), Duration: 2 * time.Hour},
+			{Subject: "go", StartedAt: monday.Add(-time.Minute), Duration: 5 * time.Hour},
+			{Subject: "go", StartedAt: monday, Duration: 30 * time.Minute},
+			{Subject: "sql", StartedAt: monday.AddDate(0, 0, 7), Duration: time.Hour},
+		}
+		for _, ls := range sessions {
+			ls.EndedAt = ls.StartedAt.Add(ls.Duration)
+			ls.Source = domain.SourceManual
+			_, err := store.LogSession(ctx, ls)
+			require.NoError(t, err)
+		}
+
+		all, err := store.GetAllSessions(ctx, domain.AllTime())
+		require.NoError(t, err)
+		assert.Len(t, all, 4)
+
+		week, err := store.GetAllSessions(ctx, domain.ThisWeek(monday))
+		require.NoError(t, err)
+		if assert.Len(t, week, 2) {
+			assert.Equal(t, "go", week[0].Subject, "sessions should be ordered by start time")
+			assert.True(t, monday.Equal(week[0].StartedAt))
+			assert.Equal(t, "sql", week[1].Subject)
+			assert.Equal(t, 2*time.Hour, week[1].Duration)
+		}
+
+		var walked []string
+		require.NoError(t, store.WalkSessions(ctx, domain.AllTime(), func(ls domain.LoggedSession) error {
+			walked = append(walked, ls.Subject)
+			return nil
+		}))
+		assert.Equal(t, []string{"go", "go", "sql", "sql"}, walked, "walk should go in start order")
+
+		errStop := errors.New("stop")
+		calls := 0
+		err = store.WalkSessions(ctx, domain.ThisWeek(monday), func(domain.LoggedSession) error {
+			calls++
+			return errStop
+		})
+		assert.ErrorIs(t, err, errStop)
+		assert.Equal(t, 1, calls, "walk should stop at the first error")
+	})
+
+	t.Run("logs a batch of sessions at once", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := domain.WithUser(t.Context(), "alice")
+		start := time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)
+
+		require.NoError(t, store.LogSessions(ctx, []domain.LoggedSession{
+			{Subject: "go", StartedAt: start, EndedAt: start.Add(time.Hour), Duration: time.Hour, Source: domain.SourceImport},
+			{Subject: "sql", StartedAt: start.Add(time.Hour), EndedAt: start.Add(90 * time.Minute), Duration: 30 * time.Minute, Source: domain.SourceImport},
+		}))
+		require.NoError(t, store.LogSessions(ctx, nil))
+
+		sessions, err := store.GetAllSessions(ctx, domain.AllTime())
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.NotEqual(t, sessions[0].ID, sessions[1].ID)
+		assert.Equal(t, domain.UserID("alice"), sessions[1].UserID)
+		assert.Equal(t, domain.SourceImport, sessions[1].Source)
+		assert.Equal(t, 30*time.Minute, sessions[1].Duration)
+	})
+
+	t.Run("logs only new sessions", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+		alice := domain.WithUser(t.Context(), "alice")
+		start := time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)
+		session := func(subject string, offset, d time.Duration) domain.LoggedSession {
+			return domain.LoggedSession{Subject: subject, StartedAt: start.Add(offset), EndedAt: start.Add(offset + d), Duration: d, Source: domain.SourceImport}
+		}
+		_, err := store.LogSession(ctx, session("go", 0, time.Hour))
+		require.NoError(t, err)
+		_, err = store.LogSession(alice, session("sql", 0, time.Hour))
+		require.NoError(t, err)
+
+		fresh, err := store.LogNewSessions(ctx, []domain.LoggedSession{
+			session("Go", 500*time.Millisecond, time.Hour),
+			session("go", 0, 2*time.Hour),
+			session("sql", 0, time.Hour),
+			session("sql", 0, time.Hour),
+		})
+		require.NoError(t, err)
+		require.Len(t, fresh, 2, "logged and repeated sessions should be skipped")
+		assert.Equal(t, 2*time.Hour, fresh[0].Duration)
+		assert.Equal(t, "sql", fresh[1].Subject, "other users' sessions should not count as logged")
+
+		sessions, err := store.GetAllSessions(ctx, domain.AllTime())
+		require.NoError(t, err)
+		assert.Len(t, sessions, 3)
+
+		fresh, err = store.LogNewSessions(ctx, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, fresh)
+	})
+
+	t.Run("keeps active pomodoros until they are deleted", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+		startedAt := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+		later := domain.ActivePomodoro{
+			ID:        "later",
+			Subject:   "go",
+			StartedAt: startedAt.Add(time.Hour),
+			EndsAt:    startedAt.Add(time.Hour + 25*time.Minute),
+			Focus:     25 * time.Minute,
+		}
+		earlier := domain.ActivePomodoro{
+			ID:        "earlier",
+			Subject:   "sql",
+			StartedAt: startedAt,
+			EndsAt:    startedAt.Add(50 * time.Minute),
+			Focus:     50 * time.Minute,
+		}
+
+		active, err := store.GetActivePomodoros(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, active)
+
+		require.NoError(t, store.SaveActivePomodoro(ctx, later))
+		require.NoError(t, store.SaveActivePomodoro(ctx, earlier))
+		earlier.PausedAt = startedAt.Add(10 * time.Minute)
+		require.NoError(t, store.SaveActivePomodoro(ctx, earlier))
+
+		active, err = store.GetActivePomodoros(ctx)
+		require.NoError(t, err)
+		require.Len(t, active, 2, "saving again should replace the pomodoro")
+		assert.Equal(t, "earlier", active[0].ID, "should be ordered by start time")
+		assert.Equal(t, "sql", active[0].Subject)
+		assert.True(t, earlier.PausedAt.Equal(active[0].PausedAt))
+		assert.True(t, earlier.EndsAt.Equal(active[0].EndsAt))
+		assert.Equal(t, 50*time.Minute, active[0].Focus)
+		assert.False(t, active[1].Paused())
+
+		require.NoError(t, store.DeleteActivePomodoro(ctx, "earlier"))
+		require.NoError(t, store.DeleteActivePomodoro(ctx, "unknown"))
+
+		active, err = store.GetActivePomodoros(ctx)
+		require.NoError(t, err)
+		require.Len(t, active, 1)
+		assert.Equal(t, "later", active[0].ID)
+	})
+
+	t.Run("keeps the round of an active cycle", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+		cycle := domain.ActivePomodoro{ID: "cycle", Subject: "go", Focus: 25 * time.Minute, Round: 3, Rounds: 4}
+
+		require.NoError(t, store.SaveActivePomodoro(ctx, cycle))
+		active, err := store.GetActivePomodoros(ctx)
+		require.NoError(t, err)
+		require.Len(t, active, 1)
+		assert.Equal(t, 3, active[0].Round)
+		assert.Equal(t, 4, active[0].Rounds)
+		assert.True(t, active[0].OnBreak(), "a cycle saved without a start should be on a break")
+
+		cycle.StartedAt = time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+		cycle.EndsAt = cycle.StartedAt.Add(cycle.Focus)
+		require.NoError(t, store.SaveActivePomodoro(ctx, cycle))
+		active, err = store.GetActivePomodoros(ctx)
+		require.NoError(t, err)
+		require.Len(t, active, 1)
+		assert.False(t, active[0].OnBreak())
+		assert.True(t, cycle.EndsAt.Equal(active[0].EndsAt))
+	})
+
+	t.Run("keeps the data of each user apart", func(t *testing.T) {
+		store := c.NewStore(t)
+		alice := domain.WithUser(t.Context(), "alice")
+		bob := domain.WithUser(t.Context(), "bob")
+
+		RecordHours(t, alice, store, "go", 2*time.Hour)
+		RecordHours(t, bob, store, "go", time.Hour)
+		RecordHours(t, bob, store, "sql", time.Hour)
+
+		hours, err := store.GetHours(alice, "go")
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Hour, hours)
+
+		_, err = store.GetHours(alice, "sql")
+		assert.ErrorIs(t, err, domain.ErrSubjectNotFound)
+
+		sessions, err := store.GetSessions(bob, "go")
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, domain.UserID("bob"), sessions[0].UserID)
+
+		report, err := store.GetReport(alice, domain.AllTime())
+		assert.NoError(t, err)
+		assert.Equal(t, domain.Report{{Subject: "go", Duration: 2 * time.Hour}}, report)
+
+		report, err = store.GetReport(t.Context(), domain.AllTime())
+		assert.NoError(t, err)
+		assert.Empty(t, report, "the default user should see nobody else's sessions")
+
+		all, err := store.GetAllSessions(bob, domain.AllTime())
+		assert.NoError(t, err)
+		assert.Len(t, all, 2)
+
+		active := domain.ActivePomodoro{ID: "same", Subject: "go", StartedAt: time.Now(), EndsAt: time.Now().Add(time.Hour), Focus: time.Hour}
+		require.NoError(t, store.SaveActivePomodoro(alice, active))
+		require.NoError(t, store.SaveActivePomodoro(bob, active))
+		require.NoError(t, store.DeleteActivePomodoro(alice, "same"))
+
+		_, err = store.GetSession(alice, sessions[0].ID)
+		assert.ErrorIs(t, err, domain.ErrSessionNotFound, "users should not see others' sessions")
+		assert.ErrorIs(t, store.DeleteSession(alice, sessions[0].ID), domain.ErrSessionNotFound, "users should not delete others' sessions")
+		assert.ErrorIs(t, store.UpdateSession(alice, sessions[0]), domain.ErrSessionNotFound, "users should not correct others' sessions")
+
+		_, err = store.RenameSubject(alice, "sql", "databases")
+		assert.ErrorIs(t, err, domain.ErrSubjectNotFound, "users should not rename others' subjects")
+		moved, err := store.MergeSubjects(bob, "golang", []string{"go"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, moved, "merging should only move the user's own sessions")
+
+		last, err := store.GetLastSession(alice)
+		require.NoError(t, err)
+		assert.Equal(t, domain.UserID("alice"), last.UserID)
+
+		pomodoros, err := store.GetActivePomodoros(t.Context())
+		require.NoError(t, err)
+		require.Len(t, pomodoros, 1, "deleting should only affect the user's own pomodoro")
+		assert.Equal(t, domain.UserID("bob"), pomodoros[0].UserID)
+	})
+
+	t.Run("keeps api tokens of each user until revoked", func(t *testing.T) {
+		store := c.NewStore(t)
+		alice := domain.WithUser(t.Context(), "alice")
+		bob := domain.WithUser(t.Context(), "bob")
+		createdAt := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+
+		laptop, secret := domain.NewAPIToken("alice", domain.ScopeRecord, "laptop", createdAt.Add(time.Hour))
+		ci, _ := domain.NewAPIToken("alice", domain.ScopeRead, "", createdAt)
+		other, _ := domain.NewAPIToken("bob", domain.ScopeRead, "phone", createdAt)
+		require.NoError(t, store.SaveToken(alice, laptop))
+		require.NoError(t, store.SaveToken(alice, ci))
+		require.NoError(t, store.SaveToken(bob, other))
+
+		found, err := store.GetTokenByHash(t.Context(), domain.HashToken(secret))
+		require.NoError(t, err)
+		assert.Equal(t, laptop.ID, found.ID)
+		assert.Equal(t, domain.UserID("alice"), found.UserID)
+		assert.Equal(t, domain.ScopeRecord, found.Scope)
+		assert.Equal(t, "laptop", found.Name)
+		assert.True(t, laptop.CreatedAt.Equal(found.CreatedAt))
+
+		_, err = store.GetTokenByHash(t.Context(), domain.HashToken("sht_unknown"))
+		assert.ErrorIs(t, err, domain.ErrTokenNotFound)
+
+		tokens, err := store.GetTokens(alice)
+		require.NoError(t, err)
+		require.Len(t, tokens, 2)
+		assert.Equal(t, ci.ID, tokens[0].ID, "should be ordered by creation time")
+
+		assert.ErrorIs(t, store.DeleteToken(bob, laptop.ID), domain.ErrTokenNotFound, "users should not revoke others' tokens")
+		require.NoError(t, store.DeleteToken(alice, laptop.ID))
+		assert.ErrorIs(t, store.DeleteToken(alice, laptop.ID), domain.ErrTokenNotFound)
+
+		_, err = store.GetTokenByHash(t.Context(), domain.HashToken(secret))
+		assert.ErrorIs(t, err, domain.ErrTokenNotFound, "a revoked token should not be found")
+	})
+
+	t.Run("keeps one goal per subject and period", func(t *testing.T) {
+		store := c.NewStore(t)
+		alice := domain.WithUser(t.Context(), "alice")
+		bob := domain.WithUser(t.Context(), "bob")
+
+		require.NoError(t, store.SaveGoal(alice, domain.Goal{Subject: "sql", Period: domain.GoalWeekly, Target: 4 * time.Hour}))
+		require.NoError(t, store.SaveGoal(alice, domain.Goal{Subject: "go", Period: domain.GoalWeekly, Target: 8 * time.Hour}))
+		require.NoError(t, store.SaveGoal(alice, domain.Goal{Subject: "go", Period: domain.GoalDaily, Target: time.Hour}))
+		require.NoError(t, store.SaveGoal(alice, domain.Goal{Subject: "go", Period: domain.GoalWeekly, Target: 10 * time.Hour}))
+		require.NoError(t, store.SaveGoal(bob, domain.Goal{Subject: "go", Period: domain.GoalMonthly, Target: 20 * time.Hour}))
+
+		goals, err := store.GetGoals(alice)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.Goal{
+			{UserID: "alice", Subject: "go", Period: domain.GoalDaily, Target: time.Hour},
+			{UserID: "alice", Subject: "go", Period: domain.GoalWeekly, Target: 10 * time.Hour},
+			{UserID: "alice", Subject: "sql", Period: domain.GoalWeekly, Target: 4 * time.Hour},
+		}, goals, "saving again should replace the goal; goals should be ordered by subject and period")
+
+		assert.ErrorIs(t, store.DeleteGoal(bob, "go", domain.GoalWeekly), domain.ErrGoalNotFound, "users should not delete others' goals")
+		require.NoError(t, store.DeleteGoal(alice, "go", domain.GoalWeekly))
+		assert.ErrorIs(t, store.DeleteGoal(alice, "go", domain.GoalWeekly), domain.ErrGoalNotFound)
+
+		goals, err = store.GetGoals(alice)
+		require.NoError(t, err)
+		assert.Len(t, goals, 2)
+
+		goals, err = store.GetGoals(t.Context())
+		require.NoError(t, err)
+		assert.Empty(t, goals)
+	})
+
+	t.Run("honours a cancelled context", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := store.LogSession(ctx, manualSession("tdd", time.Hour))
+		assert.ErrorIs(t, err, context.Canceled)
+
+		_, err = store.GetReport(ctx, domain.AllTime())
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("does not lose concurrent merges", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+		parts := []string{"go part 1", "go part 2", "go part 3", "go part 4"}
+		for _, subject := range parts {
+			RecordHours(t, ctx, store, subject, time.Hour)
+		}
+
+		var wg sync.WaitGroup
+		for _, subject := range parts {
+			wg.Go(func() {
+				_, err := store.MergeSubjects(ctx, "go", []string{subject})
+				assert.NoError(t, err)
+			})
+		}
+		wg.Wait()
+
+		report, err := store.GetReport(ctx, domain.AllTime())
+		assert.NoError(t, err)
+		assert.Equal(t, domain.Report{{Subject: "go", Duration: time.Duration(len(parts)) * time.Hour}}, report)
+	})
+
+	t.Run("undoes each session once when undoing concurrently", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+		const undos = 4
+		for range undos {
+			RecordHours(t, ctx, store, "go", time.Hour)
+		}
+
+		var (
+			wg     sync.WaitGroup
+			mu     sync.Mutex
+			undone = map[int64]bool{}
+		)
+		for range undos {
+			wg.Go(func() {
+				session, err := store.DeleteLastSession(ctx)
+				if assert.NoError(t, err) {
+					mu.Lock()
+					undone[session.ID] = true
+					mu.Unlock()
+				}
+			})
+		}
+		wg.Wait()
+
+		assert.Len(t, undone, undos, "every undo should take a different session")
+		_, err := store.GetHours(ctx, "go")
+		assert.ErrorIs(t, err, domain.ErrSubjectNotFound)
+	})
+
+	t.Run("logs new sessions once when logging concurrently", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+		start := time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)
+		batch := make([]domain.LoggedSession, 10)
+		for i := range batch {
+			begin := start.Add(time.Duration(i) * time.Hour)
+			batch[i] = domain.LoggedSession{Subject: "go", StartedAt: begin, EndedAt: begin.Add(time.Hour), Duration: time.Hour, Source: domain.SourceImport}
+		}
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Go(func() {
+				_, err := store.LogNewSessions(ctx, batch)
+				assert.NoError(t, err)
+			})
+		}
+		wg.Wait()
+
+		sessions, err := store.GetSessions(ctx, "go")
+		assert.NoError(t, err)
+		assert.Len(t, sessions, len(batch))
+	})
+
+	t.Run("does not lose concurrent recordings", func(t *testing.T) {
+		store := c.NewStore(t)
+		ctx := t.Context()
+		const recordings = 50
+
+		var wg sync.WaitGroup
+		for range recordings {
+			wg.Go(func() {
+				_, err := store.LogSession(ctx, manualSession("tdd", time.Hour))
+				assert.NoError(t, err)
+			})
+		}
+		wg.Wait()
+
+		got, err := store.GetHours(ctx, "tdd")
+		assert.NoError(t, err)
+		assert.Equal(t, recordings*time.Hour, got)
+
+		sessions, err := store.GetSessions(ctx, "tdd")
+		assert.NoError(t, err)
+		assert.Len(t, sessions, recordings)
+	})
+}
+
+// RecordHours logs a manual session of subject that ends now, the way
+// StudySession.RecordManual does, and fails t when the store fails.
+func RecordHours(t testing.TB, ctx context.Context, store domain.StudySessionLog, subject string, duration time.Duration) {
+	t.Helper()
+	_, err := store.LogSession(ctx, manualSession(subject, duration))
+	require.NoError(t, err)
+}
+
+func manualSession(subject string, duration time.Duration) domain.LoggedSession {
+	now := time.Now()
+	return domain.LoggedSession{
+		Subject:   subject,
+		StartedAt: now.Add(-duration),
+		EndedAt:   now,
+		Duration:  duration,
+		Source:    domain.SourceManual,
+	}
+}
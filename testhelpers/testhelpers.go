@@ -14,62 +14,156 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
-type StubSubjectStore struct {
-	Hours      map[string]int
-	RecordCall []string
-	Report     domain.Report
-
-	// Method-specific errors
-	RecordHourErr error
-	GetHoursErr   error
-	GetReportErr  error
+type SpySession struct {
+	ManualCalls     map[string]time.Duration
+	StubRecordErr   error // returned by RecordManual and RecordPomodoro
+	PomodoroCalls   []string
+	PomodoroConfigs []domain.PomodoroConfig
+	CycleCalls      []string
+	ResumeCalls     []domain.ActivePomodoro
+	EditCalls       []domain.SessionChange
+	DeleteCalls     []int64
+	UndoCalls       int
+	StubSession     domain.LoggedSession // returned by the Record, Edit, Delete and Undo methods
+	StubSessionErr  error
+	RenameCalls     [][2]string // from, to
+	MergeCalls      [][]string  // into, followed by the merged subjects
+	StubMoved       int         // returned by RenameSubject and MergeSubjects
+	StubSubjectErr  error
+	GoalCalls       []domain.Goal
+	DeleteGoalCalls []domain.Goal
+	StubGoals       []domain.GoalProgress
+	StubGoalErr     error
+	ScheduleAlert   []byte
+	StubNow         time.Time // returned by Now, which falls back to time.Now
+	ReportCalls     []domain.TimeRange
+	StubReport      domain.Report
+	StubHours       map[string]time.Duration // returned by Hours, which reports other subjects as not found
+	StatsCalls      []int                    // weeks
+	StubStats       domain.Stats
+	StubStatsErr    error
+	ExportCalls     []domain.ExportFormat
+	StubExport      string // written by Export
+	StubExported    int    // returned by Export
+	StubExportErr   error
+	ImportCalls     []domain.ImportOptions
+	StubImport      domain.ImportSummary
+	StubImportErr   error
 }
 
-func (s *StubSubjectStore) RecordHour(subject string, numHours int) error {
-	if s.RecordHourErr != nil {
-		return s.RecordHourErr
-	}
-	if s.Hours == nil {
-		s.Hours = make(map[string]int)
+func (s *SpySession) RecordManual(ctx context.Context, subject string, duration time.Duration) (domain.LoggedSession, error) {
+	if s.ManualCalls == nil {
+		s.ManualCalls = map[string]time.Duration{}
 	}
-	s.RecordCall = append(s.RecordCall, subject)
-	s.Hours[subject] += numHours
+	s.ManualCalls[subject] = duration
+	return s.StubSession, s.StubRecordErr
+}
+
+func (s *SpySession) RecordPomodoro(ctx context.Context, subject string, config domain.PomodoroConfig, out io.Writer, control *domain.PomodoroControl) (domain.LoggedSession, error) {
+	s.PomodoroCalls = append(s.PomodoroCalls, subject)
+	s.PomodoroConfigs = append(s.PomodoroConfigs, config)
+	out.Write(s.ScheduleAlert)
+	return s.StubSession, s.StubRecordErr
+}
+
+func (s *SpySession) RecordPomodoroCycle(ctx context.Context, subject string, config domain.PomodoroConfig, out io.Writer, control *domain.PomodoroControl) error {
+	s.CycleCalls = append(s.CycleCalls, subject)
+	s.PomodoroConfigs = append(s.PomodoroConfigs, config)
+	out.Write(s.ScheduleAlert)
 	return nil
 }
 
-func (s *StubSubjectStore) GetHours(subject string) (int, error) {
-	if s.GetHoursErr != nil {
-		return 0, s.GetHoursErr
+func (s *SpySession) RecordActivePomodoro(ctx context.Context, id, subject string, config domain.PomodoroConfig, out io.Writer, control *domain.PomodoroControl) error {
+	_, err := s.RecordPomodoro(ctx, subject, config, out, control)
+	return err
+}
+
+func (s *SpySession) RecordActivePomodoroCycle(ctx context.Context, id, subject string, config domain.PomodoroConfig, out io.Writer, control *domain.PomodoroControl) error {
+	return s.RecordPomodoroCycle(ctx, subject, config, out, control)
+}
+
+func (s *SpySession) ResumeActivePomodoro(ctx context.Context, active domain.ActivePomodoro, out io.Writer, control *domain.PomodoroControl) error {
+	s.ResumeCalls = append(s.ResumeCalls, active)
+	out.Write(s.ScheduleAlert)
+	return nil
+}
+
+func (s *SpySession) EditSession(ctx context.Context, id int64, change domain.SessionChange) (domain.LoggedSession, error) {
+	s.EditCalls = append(s.EditCalls, change)
+	return s.StubSession, s.StubSessionErr
+}
+
+func (s *SpySession) DeleteSession(ctx context.Context, id int64) (domain.LoggedSession, error) {
+	s.DeleteCalls = append(s.DeleteCalls, id)
+	return s.StubSession, s.StubSessionErr
+}
+
+func (s *SpySession) UndoLastSession(ctx context.Context) (domain.LoggedSession, error) {
+	s.UndoCalls++
+	return s.StubSession, s.StubSessionErr
+}
+
+func (s *SpySession) RenameSubject(ctx context.Context, from, to string) (int, error) {
+	s.RenameCalls = append(s.RenameCalls, [2]string{from, to})
+	return s.StubMoved, s.StubSubjectErr
+}
+
+func (s *SpySession) MergeSubjects(ctx context.Context, into string, from []string) (int, error) {
+	s.MergeCalls = append(s.MergeCalls, append([]string{into}, from...))
+	return s.StubMoved, s.StubSubjectErr
+}
+
+func (s *SpySession) SetGoal(ctx context.Context, goal domain.Goal) error {
+	s.GoalCalls = append(s.GoalCalls, goal)
+	return s.StubGoalErr
+}
+
+func (s *SpySession) DeleteGoal(ctx context.Context, subject string, period domain.GoalPeriod) error {
+	s.DeleteGoalCalls = append(s.DeleteGoalCalls, domain.Goal{Subject: subject, Period: period})
+	return s.StubGoalErr
+}
+
+func (s *SpySession) Goals(ctx context.Context) ([]domain.GoalProgress, error) {
+	return s.StubGoals, s.StubGoalErr
+}
+
+func (s *SpySession) Now() time.Time {
+	if s.StubNow.IsZero() {
+		return time.Now()
 	}
-	h, ok := s.Hours[subject]
+	return s.StubNow
+}
+
+func (s *SpySession) Report(ctx context.Context, period domain.TimeRange) (domain.Report, error) {
+	s.ReportCalls = append(s.ReportCalls, period)
+	return s.StubReport, nil
+}
+
+func (s *SpySession) Hours(ctx context.Context, subject string) (time.Duration, error) {
+	hours, ok := s.StubHours[subject]
 	if !ok {
 		return 0, domain.ErrSubjectNotFound
 	}
-	return h, nil
+	return hours, nil
 }
 
-func (s *StubSubjectStore) GetReport() (domain.Report, error) {
-	if s.GetReportErr != nil {
-		return nil, s.GetReportErr
+func (s *SpySession) Export(ctx context.Context, w io.Writer, format domain.ExportFormat, period domain.TimeRange) (int, error) {
+	s.ExportCalls = append(s.ExportCalls, format)
+	if s.StubExportErr != nil {
+		return 0, s.StubExportErr
 	}
-	return s.Report, nil
+	_, err := io.WriteString(w, s.StubExport)
+	return s.StubExported, err
 }
 
-type SpySession struct {
-	ManualCalls   map[string]int
-	PomodoroCalls []string
-	ScheduleAlert []byte
+func (s *SpySession) Import(ctx context.Context, r io.Reader, options domain.ImportOptions) (domain.ImportSummary, error) {
+	s.ImportCalls = append(s.ImportCalls, options)
+	return s.StubImport, s.StubImportErr
 }
 
-func (s *SpySession) RecordManual(subject string, hours int) error {
-	s.ManualCalls[subject] = hours
-	return nil
-}
-
-func (s *SpySession) RecordPomodoro(subject string, out io.Writer) error {
-	s.PomodoroCalls = append(s.PomodoroCalls, subject)
-	out.Write(s.ScheduleAlert)
-	return nil
+func (s *SpySession) Stats(ctx context.Context, weeks int) (domain.Stats, error) {
+	s.StatsCalls = append(s.StatsCalls, weeks)
+	return s.StubStats, s.StubStatsErr
 }
 
 func SetupTestContainer(t testing.TB) string {